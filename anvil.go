@@ -0,0 +1,452 @@
+package MinecraftLightServer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Anvil format settings.
+const (
+	regionSize         = 32      // chunks along each side of a region file
+	regionChunks       = 32 * 32 // chunks in a region file
+	regionSectorSize   = 4096    // region files are made of sectors of this size
+	regionHeaderSize   = 2       // sectors of the header (chunk locations and timestamps)
+	maxChunkSectors    = 255     // maximum number of sectors of a single chunk
+	regionGzip         = 1       // chunk compressed with gzip
+	regionZlib         = 2       // chunk compressed with zlib, used when saving
+	regionUncompressed = 3       // chunk not compressed
+)
+
+// regionFile is the content of an Anvil region file, a group of 32x32 chunks.
+type regionFile struct {
+	chunks     [regionChunks][]byte // compression type and data of each chunk, nil if it's missing
+	timestamps [regionChunks]int32  // last time each chunk was saved
+}
+
+// OpenWorld creates a World saved in a directory with the Anvil format, the settings
+// of its level.dat file are loaded and the chunks found there are used instead of
+// being generated by generator.
+// dimension is an optional argument and you have to leave
+// it empty to use the overworld dimension type.
+func OpenWorld(dir string, generator ChunkGenerator, dimension ...*DimensionType) (*World, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "region"), 0755); err != nil {
+		return nil, err
+	}
+	w := NewWorld(generator, dimension...)
+	w.dir = dir
+	if err := w.LoadLevelFile(filepath.Join(dir, levelFileName)); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Directory returns the directory where the world is saved, empty if it's only in memory.
+func (w *World) Directory() string {
+	return w.dir
+}
+
+// Save writes the world settings to the level.dat file and the chunks stored in memory
+// with their block entities to the region files of the world directory, the chunks
+// of the files that haven't been loaded are kept. Worlds that have been created
+// without a directory aren't saved.
+func (w *World) Save() error {
+	if w.dir == "" {
+		return nil
+	}
+	if err := w.SaveLevelFile(filepath.Join(w.dir, levelFileName)); err != nil {
+		return err
+	}
+
+	// The items of the open containers are copied to their block entities
+	w.containers.mutex.Lock()
+	containers := make(map[Position]*Container, len(w.containers.containers))
+	for pos, c := range w.containers.containers {
+		containers[pos] = c
+	}
+	w.containers.mutex.Unlock()
+	for pos, c := range containers {
+		w.saveContainer(pos, c)
+	}
+
+	w.mutex.RLock()
+	regions := make(map[chunkPos][]*Chunk)
+	for pos, c := range w.chunks {
+		region := chunkPos{pos.x >> 5, pos.z >> 5}
+		regions[region] = append(regions[region], c)
+	}
+	w.mutex.RUnlock()
+
+	w.storage.Lock()
+	defer w.storage.Unlock()
+	now := int32(time.Now().Unix())
+	for pos, chunks := range regions {
+		path := w.regionPath(pos.x, pos.z)
+		region, err := readRegionFile(path)
+		if err != nil {
+			return err
+		}
+		for _, c := range chunks {
+			c.mutex.RLock()
+			data, err := compressChunk(c.anvilNBT())
+			c.mutex.RUnlock()
+			if err != nil {
+				return err
+			}
+			index := regionIndex(c.X, c.Z)
+			region.chunks[index], region.timestamps[index] = data, now
+		}
+		if err := region.writeFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadChunk reads a chunk from the region files of the world directory,
+// it returns nil if the chunk hasn't been saved.
+func (w *World) loadChunk(x, z int) (*Chunk, error) {
+	if w.dir == "" {
+		return nil, nil
+	}
+
+	w.storage.Lock()
+	data, err := readRegionChunk(w.regionPath(x>>5, z>>5), regionIndex(x, z))
+	w.storage.Unlock()
+	if data == nil || err != nil {
+		return nil, err
+	}
+	root, err := decompressChunk(data)
+	if err != nil {
+		return nil, err
+	}
+	c, err := chunkFromAnvil(root)
+	if err != nil {
+		return nil, err
+	}
+	c.X, c.Z = x, z
+	return c, nil
+}
+
+// regionPath returns the path of the region file at the specified region coordinates.
+func (w *World) regionPath(x, z int) string {
+	return filepath.Join(w.dir, "region", "r."+strconv.Itoa(x)+"."+strconv.Itoa(z)+".mca")
+}
+
+// regionIndex returns the index of a chunk inside its region file.
+func regionIndex(x, z int) int {
+	return (z&(regionSize-1))*regionSize + x&(regionSize-1)
+}
+
+// readRegionFile reads all the chunks of a region file, the file can be missing.
+func readRegionFile(path string) (*regionFile, error) {
+	region := new(regionFile)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return region, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(data) < regionHeaderSize*regionSectorSize {
+		return nil, errors.New("region file too short: " + path)
+	}
+
+	for i := range region.chunks {
+		location := binary.BigEndian.Uint32(data[i*4:])
+		offset := int(location>>8) * regionSectorSize
+		if offset == 0 {
+			continue
+		}
+		if offset+5 > len(data) {
+			return nil, errors.New("chunk outside of region file: " + path)
+		}
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		if length < 1 || offset+4+length > len(data) {
+			return nil, errors.New("invalid chunk length in region file: " + path)
+		}
+		region.chunks[i] = data[offset+4 : offset+4+length]
+		region.timestamps[i] = int32(binary.BigEndian.Uint32(data[regionSectorSize+i*4:]))
+	}
+	return region, nil
+}
+
+// readRegionChunk reads the compression type and the data of a single chunk
+// of a region file, it returns nil if the chunk (or the file) is missing.
+func readRegionChunk(path string, index int) ([]byte, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	location := make([]byte, 4)
+	if _, err := file.ReadAt(location, int64(index*4)); err != nil {
+		return nil, err
+	}
+	offset := int64(binary.BigEndian.Uint32(location)>>8) * regionSectorSize
+	sectors := int(location[3])
+	if offset == 0 {
+		return nil, nil
+	}
+	if _, err := file.ReadAt(location, offset); err != nil {
+		return nil, err
+	}
+	length := int(binary.BigEndian.Uint32(location))
+	if length < 1 || length > sectors*regionSectorSize-4 {
+		return nil, errors.New("invalid chunk length in region file: " + path)
+	}
+	data := make([]byte, length)
+	if _, err := file.ReadAt(data, offset+4); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// writeFile writes the region to a file, replacing it only when it's complete.
+func (r *regionFile) writeFile(path string) error {
+	header := make([]byte, regionHeaderSize*regionSectorSize)
+	out := new(bytes.Buffer)
+	sector := regionHeaderSize
+	for i, data := range r.chunks {
+		if data == nil {
+			continue
+		}
+		sectors := (len(data) + 4 + regionSectorSize - 1) / regionSectorSize
+		if sectors > maxChunkSectors {
+			return errors.New("chunk too big for the region file: " + path)
+		}
+		binary.BigEndian.PutUint32(header[i*4:], uint32(sector<<8|sectors))
+		binary.BigEndian.PutUint32(header[regionSectorSize+i*4:], uint32(r.timestamps[i]))
+
+		_, _ = Int(len(data)).WriteTo(out)
+		out.Write(data)
+		out.Write(make([]byte, sectors*regionSectorSize-len(data)-4))
+		sector += sectors
+	}
+
+	temp := path + ".tmp"
+	if err := ioutil.WriteFile(temp, append(header, out.Bytes()...), 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
+
+// compressChunk encodes the tags of a chunk with its compression type, as in the region files.
+func compressChunk(root NBTCompound) ([]byte, error) {
+	data := bytes.NewBuffer([]byte{regionZlib})
+	compressor := zlib.NewWriter(data)
+	if _, err := root.WriteTo(compressor); err != nil {
+		return nil, err
+	}
+	if err := compressor.Close(); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+// decompressChunk decodes the tags of a chunk read from a region file.
+func decompressChunk(data []byte) (NBTCompound, error) {
+	var in io.Reader = bytes.NewReader(data[1:])
+	switch data[0] {
+	case regionGzip:
+		gz, err := gzip.NewReader(in)
+		if err != nil {
+			return nil, err
+		}
+		in = gz
+	case regionZlib:
+		decompressor, err := zlib.NewReader(in)
+		if err != nil {
+			return nil, err
+		}
+		in = decompressor
+	case regionUncompressed:
+	default:
+		return nil, errors.New("unknown chunk compression: " + strconv.Itoa(int(data[0])))
+	}
+	return ReadNBT(in)
+}
+
+// anvilNBT returns the tags of the chunk saved in the region files, without locking.
+// Light isn't saved, it's calculated again when the chunk is loaded.
+func (c *Chunk) anvilNBT() NBTCompound {
+	sections := make(NBTList, 0, chunkSections)
+	for y, section := range c.sections {
+		if section != nil && section.nonAir > 0 {
+			sections = append(sections, section.anvilNBT(y))
+		}
+	}
+	biomes := make([]int32, biomeCells)
+	for i, id := range c.biomes {
+		biomes[i] = int32(id)
+	}
+	entities := make(NBTList, 0, len(c.entities))
+	for _, entity := range c.blockEntitiesNBT() {
+		entities = append(entities, entity)
+	}
+
+	level := NBTCompound{
+		"xPos":          int32(c.X),
+		"zPos":          int32(c.Z),
+		"Status":        "full",
+		"LastUpdate":    int64(0),
+		"InhabitedTime": int64(0),
+		"Sections":      sections,
+		"Heightmaps":    c.heightmapsNBT(),
+		"Biomes":        biomes,
+		"Entities":      NBTList{},
+		"TileEntities":  entities,
+	}
+	return NBTCompound{"DataVersion": int32(levelDataVersion), "Level": level}
+}
+
+// anvilNBT returns the tags of a section with its palette of block names.
+func (s *chunkSection) anvilNBT(y int) NBTCompound {
+	palette := make(NBTList, 0)
+	paletteIndex := make(map[BlockState]int)
+	values := make([]int, sectionVolume)
+	for i, state := range s.blocks {
+		index, ok := paletteIndex[state]
+		if !ok {
+			index = len(palette)
+			paletteIndex[state] = index
+			entry := NBTCompound{"Name": state.Name()}
+			if properties := blockRegistry.Properties(state); len(properties) > 0 {
+				tags := make(NBTCompound, len(properties))
+				for name, value := range properties {
+					tags[name] = value
+				}
+				entry["Properties"] = tags
+			}
+			palette = append(palette, entry)
+		}
+		values[i] = index
+	}
+
+	bits := minPaletteBits
+	for 1<<bits < len(palette) {
+		bits++
+	}
+	return NBTCompound{"Y": int8(y), "Palette": palette, "BlockStates": packLongs(values, bits)}
+}
+
+// chunkFromAnvil decodes a chunk saved in a region file. The blocks that
+// aren't in the registry become air, as in the schematics.
+func chunkFromAnvil(root NBTCompound) (*Chunk, error) {
+	level, ok := root["Level"].(NBTCompound)
+	if !ok {
+		return nil, errors.New("chunk data without Level compound")
+	}
+	x, _ := nbtNumber(level["xPos"])
+	z, _ := nbtNumber(level["zPos"])
+	c := NewChunk(x, z)
+
+	sections, _ := level["Sections"].(NBTList)
+	for _, tag := range sections {
+		section, ok := tag.(NBTCompound)
+		if !ok {
+			continue
+		}
+		// Vanilla also saves the light of the sections below and above the world
+		y, _ := nbtNumber(section["Y"])
+		palette, _ := section["Palette"].(NBTList)
+		if y < 0 || y >= chunkSections || len(palette) == 0 {
+			continue
+		}
+
+		states := make([]BlockState, len(palette))
+		for i, entry := range palette {
+			entry, _ := entry.(NBTCompound)
+			name, _ := entry["Name"].(string)
+			tags, _ := entry["Properties"].(NBTCompound)
+			properties := make(map[string]string, len(tags))
+			for key, value := range tags {
+				properties[key], _ = value.(string)
+			}
+			states[i], _ = blockRegistry.State(name, properties)
+		}
+
+		bits := minPaletteBits
+		for 1<<bits < len(palette) {
+			bits++
+		}
+		longs, _ := section["BlockStates"].([]int64)
+		values, err := unpackLongs(longs, bits, sectionVolume)
+		if err != nil {
+			return nil, err
+		}
+		for i, value := range values {
+			if value >= len(states) {
+				return nil, errors.New("invalid palette index in chunk section")
+			}
+			c.setBlock(i&15, y<<4|i>>8, i>>4&15, states[value])
+		}
+	}
+
+	if biomes, ok := level["Biomes"].([]int32); ok && len(biomes) == biomeCells {
+		for i, id := range biomes {
+			if biomeRegistry.Biome(int(id)) != nil {
+				c.biomes[i] = int(id)
+			}
+		}
+	}
+
+	entities, _ := level["TileEntities"].(NBTList)
+	for _, tag := range entities {
+		tag, ok := tag.(NBTCompound)
+		if !ok {
+			continue
+		}
+		id, _ := tag["id"].(string)
+		x, okX := nbtNumber(tag["x"])
+		y, okY := nbtNumber(tag["y"])
+		z, okZ := nbtNumber(tag["z"])
+		if id == "" || !okX || !okY || !okZ || x>>4 != c.X || z>>4 != c.Z {
+			continue
+		}
+
+		e := NewBlockEntity(id)
+		for name, value := range tag {
+			switch name {
+			case "id", "x", "y", "z", "keepPacked":
+			default:
+				e.Data[name] = value
+			}
+		}
+		c.setBlockEntity(x&15, y, z&15, e)
+	}
+
+	// The heightmaps have been calculated while placing the blocks, the saved ones
+	// are used if they're valid
+	heightmaps, _ := level["Heightmaps"].(NBTCompound)
+	for heightmap, name := range heightmapNames {
+		longs, _ := heightmaps[name].([]int64)
+		if values, err := unpackLongs(longs, heightmapBits, len(c.heightmaps[heightmap])); err == nil {
+			copy(c.heightmaps[heightmap][:], values)
+		}
+	}
+	return c, nil
+}
+
+// unpackLongs reads count values packed by packLongs.
+func unpackLongs(longs []int64, bits, count int) ([]int, error) {
+	perLong := 64 / bits
+	if len(longs) != (count+perLong-1)/perLong {
+		return nil, errors.New("invalid length of packed array")
+	}
+	values := make([]int, count)
+	for i := range values {
+		values[i] = int(uint64(longs[i/perLong]) >> uint((i%perLong)*bits) & (1<<uint(bits) - 1))
+	}
+	return values, nil
+}
@@ -0,0 +1,132 @@
+package MinecraftLightServer
+
+import "testing"
+
+// testOpenWorld opens a world saved in a directory, it stops the test on errors.
+func testOpenWorld(t *testing.T, dir string, generator ChunkGenerator) *World {
+	t.Helper()
+	w, err := OpenWorld(dir, generator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestAnvilChunks(t *testing.T) {
+	dir := t.TempDir()
+	generator, _ := NewFlatGenerator(defaultFlatLayers)
+	stairs := testBlock(t, "minecraft:oak_stairs[facing=east,half=top]")
+	blocks := map[Position]BlockState{
+		{0, 3, 0}:     blockAir,
+		{5, 100, 7}:   blockStone,
+		{-1, 20, -1}:  stairs,
+		{40, 10, -50}: blockWater,
+	}
+
+	w := testOpenWorld(t, dir, generator)
+	for pos, state := range blocks {
+		w.SetBlock(pos.X, pos.Y, pos.Z, state)
+	}
+	w.Chunk(0, 0).SetBiome(0, 0, 0, 2)
+	if err := w.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The chunks that haven't been saved are generated empty
+	loaded := testOpenWorld(t, dir, VoidGenerator{})
+	for pos, state := range blocks {
+		if got := loaded.GetBlock(pos.X, pos.Y, pos.Z); got != state {
+			t.Errorf("the block at %v is %v, want %v", pos, got, state)
+		}
+	}
+	if got := loaded.GetBlock(1, 2, 1); got != w.GetBlock(1, 2, 1) {
+		t.Errorf("the generated block is %v, want %v", got, w.GetBlock(1, 2, 1))
+	}
+	if got := loaded.Chunk(0, 0).Biome(0, 0, 0); got != 2 {
+		t.Errorf("the biome is %d, want 2", got)
+	}
+	if loaded.Chunk(20, 20).sectionMask() != 0 {
+		t.Error("a chunk that hasn't been saved isn't empty")
+	}
+	for _, pos := range []chunkPos{{0, 0}, {-1, -1}, {2, -4}} {
+		saved, got := w.Chunk(pos.x, pos.z), loaded.Chunk(pos.x, pos.z)
+		if saved.heightmaps != got.heightmaps {
+			t.Errorf("the heightmaps of chunk %v are different", pos)
+		}
+	}
+
+	// Saving again keeps the chunks of the region that haven't been loaded
+	if err := loaded.Save(); err != nil {
+		t.Fatal(err)
+	}
+	partial := testOpenWorld(t, dir, VoidGenerator{})
+	partial.SetBlock(1, 50, 1, blockStone)
+	if err := partial.Save(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := testOpenWorld(t, dir, VoidGenerator{})
+	if reloaded.GetBlock(1, 50, 1) != blockStone || reloaded.GetBlock(5, 100, 7) != blockStone || reloaded.GetBlock(-1, 20, -1) != stairs {
+		t.Error("the chunks saved before have been lost")
+	}
+}
+
+func TestAnvilHeightmaps(t *testing.T) {
+	c := NewChunk(0, 0)
+	c.SetBlock(3, 60, 4, blockStone)
+	c.SetBlock(3, 61, 4, blockSnow)
+	c.SetBlock(8, 10, 8, blockWater)
+
+	// The saved heightmaps are used instead of the calculated ones
+	root := c.anvilNBT()
+	heightmaps := root["Level"].(NBTCompound)["Heightmaps"].(NBTCompound)
+	values := make([]int, 256)
+	values[0] = 200
+	heightmaps["WORLD_SURFACE"] = packLongs(values, heightmapBits)
+	loaded, err := chunkFromAnvil(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.heightmaps[motionBlocking] != c.heightmaps[motionBlocking] {
+		t.Error("the motion blocking heightmap is different")
+	}
+	if loaded.heightmaps[worldSurface][0] != 200 {
+		t.Errorf("the saved world surface is %d, want 200", loaded.heightmaps[worldSurface][0])
+	}
+
+	// Invalid heightmaps are calculated again
+	heightmaps["WORLD_SURFACE"] = []int64{1, 2, 3}
+	if loaded, err = chunkFromAnvil(root); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.heightmaps != c.heightmaps {
+		t.Error("the heightmaps haven't been calculated")
+	}
+}
+
+func TestAnvilBlockEntities(t *testing.T) {
+	dir := t.TempDir()
+	w := testOpenWorld(t, dir, VoidGenerator{})
+	sign, chest := Position{1, 10, -2}, Position{-20, 30, 40}
+	w.SetBlock(sign.X, sign.Y, sign.Z, testBlock(t, "minecraft:oak_sign"))
+	w.SetBlockEntity(sign.X, sign.Y, sign.Z, NewSign("first", "second"))
+	w.SetBlock(chest.X, chest.Y, chest.Z, testBlock(t, "minecraft:chest"))
+	w.SetBlockEntity(chest.X, chest.Y, chest.Z, NewChest())
+
+	// The items of a container that is still open are saved too
+	w.blockContainer(chest).slots[3] = testItem(t, "diamond", 5)
+	if err := w.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := testOpenWorld(t, dir, VoidGenerator{})
+	if lines := loaded.BlockEntity(sign.X, sign.Y, sign.Z).SignText(); lines != [4]string{"first", "second"} {
+		t.Errorf("the sign text is %q", lines)
+	}
+	e := loaded.BlockEntity(chest.X, chest.Y, chest.Z)
+	if e == nil || e.ID != "minecraft:chest" {
+		t.Fatalf("the chest block entity is %v", e)
+	}
+	if id, count := e.Item(3); id != "minecraft:diamond" || count != 5 {
+		t.Errorf("the chest contains %d %s, want 5 minecraft:diamond", count, id)
+	}
+}
@@ -0,0 +1,253 @@
+package MinecraftLightServer
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Chunk biome storage, biomes are stored in 4x4x4 cells.
+const (
+	biomeCells   = 4 * 4 * 64 // biome cells in a chunk
+	defaultBiome = 1          // minecraft:plains
+)
+
+// Biome is a biome sent to the clients in the dimension codec.
+type Biome struct {
+	Name          string       // namespaced name
+	Category      string       // biome category (plains, forest, ocean...)
+	Precipitation string       // none, rain or snow
+	Temperature   float32      // affects snow and grass color
+	Downfall      float32      // affects grass and foliage color
+	Depth         float32      // terrain height
+	Scale         float32      // terrain variation
+	Effects       BiomeEffects // biome colors and sounds
+}
+
+// BiomeEffects are the colors and the ambient sounds of a biome.
+type BiomeEffects struct {
+	SkyColor      int32  // RGB sky color
+	FogColor      int32  // RGB fog color
+	WaterColor    int32  // RGB water color
+	WaterFogColor int32  // RGB underwater fog color
+	GrassColor    int32  // RGB grass color, 0 uses the client color map
+	FoliageColor  int32  // RGB leaves color, 0 uses the client color map
+	AmbientSound  string // optional sound played in loop
+}
+
+// BiomeRegistry assigns the numeric IDs used in chunk data to biomes.
+type BiomeRegistry struct {
+	biomes map[int]*Biome // biomes by ID
+	ids    map[string]int // IDs by name
+	mutex  sync.RWMutex   // registry mutex
+}
+
+// biomeRegistry is the registry used for chunks and the dimension codec.
+var biomeRegistry = newBiomeRegistry(builtinBiomes)
+
+// newBiomeRegistry creates a registry with the specified biomes.
+func newBiomeRegistry(biomes map[int]*Biome) *BiomeRegistry {
+	r := &BiomeRegistry{biomes: make(map[int]*Biome), ids: make(map[string]int)}
+	for id, biome := range biomes {
+		r.biomes[id] = biome
+		r.ids[biome.Name] = id
+	}
+	return r
+}
+
+// Register adds a biome to the registry and returns its ID. Players that
+// are already connected only know the biomes registered before they joined.
+func (r *BiomeRegistry) Register(biome Biome) (int, error) {
+	biome.Name = namespaced(biome.Name)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.ids[biome.Name]; ok {
+		return 0, errors.New("biome already registered: " + biome.Name)
+	}
+
+	// Use the first free ID
+	id := 0
+	for r.biomes[id] != nil {
+		id++
+	}
+	r.biomes[id] = &biome
+	r.ids[biome.Name] = id
+	return id, nil
+}
+
+// ID returns the ID of a biome.
+func (r *BiomeRegistry) ID(name string) (int, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	id, ok := r.ids[namespaced(name)]
+	return id, ok
+}
+
+// Biome returns the biome with the specified ID, nil if it doesn't exist.
+func (r *BiomeRegistry) Biome(id int) *Biome {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.biomes[id]
+}
+
+// codecNBT returns the "minecraft:worldgen/biome" entry of the dimension codec.
+func (r *BiomeRegistry) codecNBT() NBTCompound {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	ids := make([]int, 0, len(r.biomes))
+	for id := range r.biomes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	values := make(NBTList, 0, len(ids))
+	for _, id := range ids {
+		biome := r.biomes[id]
+		values = append(values, NBTCompound{
+			"name":    biome.Name,
+			"id":      int32(id),
+			"element": biome.nbt(),
+		})
+	}
+	return NBTCompound{"type": "minecraft:worldgen/biome", "value": values}
+}
+
+// nbt returns the biome settings as they're sent in the dimension codec.
+func (b *Biome) nbt() NBTCompound {
+	effects := NBTCompound{
+		"sky_color":       b.Effects.SkyColor,
+		"fog_color":       b.Effects.FogColor,
+		"water_color":     b.Effects.WaterColor,
+		"water_fog_color": b.Effects.WaterFogColor,
+		"mood_sound": NBTCompound{
+			"sound":               "minecraft:ambient.cave",
+			"tick_delay":          int32(6000),
+			"block_search_extent": int32(8),
+			"offset":              2.0,
+		},
+	}
+	if b.Effects.GrassColor != 0 {
+		effects["grass_color"] = b.Effects.GrassColor
+	}
+	if b.Effects.FoliageColor != 0 {
+		effects["foliage_color"] = b.Effects.FoliageColor
+	}
+	if b.Effects.AmbientSound != "" {
+		effects["ambient_sound"] = b.Effects.AmbientSound
+	}
+
+	return NBTCompound{
+		"category":      b.Category,
+		"precipitation": b.Precipitation,
+		"temperature":   b.Temperature,
+		"downfall":      b.Downfall,
+		"depth":         b.Depth,
+		"scale":         b.Scale,
+		"effects":       effects,
+	}
+}
+
+// RegisterBiome adds a custom biome to the server and returns its ID.
+func RegisterBiome(biome Biome) (int, error) {
+	return biomeRegistry.Register(biome)
+}
+
+// BiomeID returns the ID of a biome using its name.
+func BiomeID(name string) (int, bool) {
+	return biomeRegistry.ID(name)
+}
+
+// biomeIndex returns the index of the biome cell that contains
+// the block at chunk relative coordinates.
+func biomeIndex(x, y, z int) int {
+	return (y>>2)<<4 | (z>>2)<<2 | x>>2
+}
+
+// Biome returns the biome ID at chunk relative coordinates.
+func (c *Chunk) Biome(x, y, z int) int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if y < 0 || y >= chunkHeight {
+		return defaultBiome
+	}
+	return c.biomes[biomeIndex(x, y, z)]
+}
+
+// SetBiome changes the biome of the 4x4x4 cell that contains
+// the block at chunk relative coordinates.
+func (c *Chunk) SetBiome(x, y, z int, id int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if y >= 0 && y < chunkHeight {
+		c.biomes[biomeIndex(x, y, z)] = id
+	}
+}
+
+// SetColumnBiome changes the biome of the whole column that contains
+// the block at chunk relative coordinates x and z.
+func (c *Chunk) SetColumnBiome(x, z int, id int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for y := 0; y < chunkHeight; y += 4 {
+		c.biomes[biomeIndex(x, y, z)] = id
+	}
+}
+
+// FillBiome changes the biome of the whole chunk.
+func (c *Chunk) FillBiome(id int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i := range c.biomes {
+		c.biomes[i] = id
+	}
+}
+
+// builtinBiomes are the vanilla biomes known by the server, with their vanilla IDs.
+var builtinBiomes = map[int]*Biome{
+	0:   overworldBiome("ocean", "ocean", "rain", 0.5, 0.5, -1, 0.1, 8103167),
+	1:   overworldBiome("plains", "plains", "rain", 0.8, 0.4, 0.125, 0.05, 7907327),
+	2:   overworldBiome("desert", "desert", "none", 2, 0, 0.125, 0.05, 7254527),
+	3:   overworldBiome("mountains", "extreme_hills", "rain", 0.2, 0.3, 1, 0.5, 8233727),
+	4:   overworldBiome("forest", "forest", "rain", 0.7, 0.8, 0.1, 0.2, 7972607),
+	5:   overworldBiome("taiga", "taiga", "rain", 0.25, 0.8, 0.2, 0.2, 8233983),
+	7:   overworldBiome("river", "river", "rain", 0.5, 0.5, -0.5, 0, 8103167),
+	8:   netherBiome("nether_wastes", 3344392, "minecraft:ambient.nether_wastes.loop"),
+	9:   {Name: "minecraft:the_end", Category: "the_end", Precipitation: "none", Temperature: 0.5, Downfall: 0.5, Depth: 0.1, Scale: 0.2, Effects: BiomeEffects{SkyColor: 0, FogColor: 10518688, WaterColor: 4159204, WaterFogColor: 329011}},
+	12:  overworldBiome("snowy_tundra", "icy", "snow", 0, 0.5, 0.125, 0.05, 8364543),
+	16:  overworldBiome("beach", "beach", "rain", 0.8, 0.4, 0, 0.025, 7907327),
+	27:  overworldBiome("birch_forest", "forest", "rain", 0.6, 0.6, 0.1, 0.2, 8037887),
+	127: overworldBiome("the_void", "none", "none", 0.5, 0.5, 0.1, 0.2, 8103167),
+	170: netherBiome("soul_sand_valley", 1787717, "minecraft:ambient.soul_sand_valley.loop"),
+	171: netherBiome("crimson_forest", 3343107, "minecraft:ambient.crimson_forest.loop"),
+	172: netherBiome("warped_forest", 1705242, "minecraft:ambient.warped_forest.loop"),
+	173: netherBiome("basalt_deltas", 6840176, "minecraft:ambient.basalt_deltas.loop"),
+}
+
+// overworldBiome creates a biome with the default overworld colors.
+func overworldBiome(name, category, precipitation string, temperature, downfall, depth, scale float32, sky int32) *Biome {
+	return &Biome{
+		Name:          namespaced(name),
+		Category:      category,
+		Precipitation: precipitation,
+		Temperature:   temperature,
+		Downfall:      downfall,
+		Depth:         depth,
+		Scale:         scale,
+		Effects:       BiomeEffects{SkyColor: sky, FogColor: 12638463, WaterColor: 4159204, WaterFogColor: 329011},
+	}
+}
+
+// netherBiome creates a nether biome with its fog color and ambient sound.
+func netherBiome(name string, fog int32, sound string) *Biome {
+	return &Biome{
+		Name:          namespaced(name),
+		Category:      "nether",
+		Precipitation: "none",
+		Temperature:   2,
+		Depth:         0.1,
+		Scale:         0.2,
+		Effects:       BiomeEffects{SkyColor: 7254527, FogColor: fog, WaterColor: 4159204, WaterFogColor: 329011, AmbientSound: sound},
+	}
+}
@@ -0,0 +1,232 @@
+package MinecraftLightServer
+
+import "encoding/json"
+
+// Block Entity Data actions of the block entity types that the client renders.
+var blockEntityActions = map[string]UnsignedByte{
+	"minecraft:mob_spawner":     1,
+	"minecraft:command_block":   2,
+	"minecraft:beacon":          3,
+	"minecraft:skull":           4,
+	"minecraft:conduit":         5,
+	"minecraft:banner":          6,
+	"minecraft:structure_block": 7,
+	"minecraft:end_gateway":     8,
+	"minecraft:sign":            9,
+	"minecraft:bed":             11,
+	"minecraft:jigsaw":          12,
+	"minecraft:campfire":        13,
+	"minecraft:beehive":         14,
+}
+
+// BlockEntity is the additional data of a block, like the text of a sign
+// or the items of a chest.
+type BlockEntity struct {
+	ID   string      // namespaced block entity type
+	Data NBTCompound // tags of the block entity, without id and coordinates
+}
+
+// BannerPattern is a layer of a banner.
+type BannerPattern struct {
+	Pattern string // pattern code, for example "bs" (base) or "cr" (cross)
+	Color   int    // dye color, from 0 (white) to 15 (black)
+}
+
+// NewBlockEntity creates an empty block entity of the specified type.
+func NewBlockEntity(id string) *BlockEntity {
+	return &BlockEntity{ID: namespaced(id), Data: make(NBTCompound)}
+}
+
+// NewSign creates a sign block entity with up to four lines of text.
+func NewSign(lines ...string) *BlockEntity {
+	e := NewBlockEntity("minecraft:sign")
+	e.Data["Color"] = "black"
+	var text [4]string
+	copy(text[:], lines)
+	e.SetSignText(text)
+	return e
+}
+
+// NewChest creates an empty chest block entity.
+func NewChest() *BlockEntity {
+	e := NewBlockEntity("minecraft:chest")
+	e.Data["Items"] = NBTList{}
+	return e
+}
+
+// NewBanner creates a banner block entity with the specified layers,
+// the base color of the banner depends on its block.
+func NewBanner(patterns ...BannerPattern) *BlockEntity {
+	e := NewBlockEntity("minecraft:banner")
+	list := make(NBTList, 0, len(patterns))
+	for _, pattern := range patterns {
+		list = append(list, NBTCompound{"Pattern": pattern.Pattern, "Color": int32(pattern.Color)})
+	}
+	e.Data["Patterns"] = list
+	return e
+}
+
+// NewSkull creates a player head block entity with the skin of a player.
+func NewSkull(owner string) *BlockEntity {
+	e := NewBlockEntity("minecraft:skull")
+	e.Data["SkullOwner"] = NBTCompound{"Name": owner}
+	return e
+}
+
+// Clone returns a copy of the block entity that can be changed
+// without affecting the original one.
+func (e *BlockEntity) Clone() *BlockEntity {
+	clone := NewBlockEntity(e.ID)
+	for name, tag := range e.Data {
+		clone.Data[name] = tag
+	}
+	return clone
+}
+
+// isSign checks if a block entity is a sign.
+func (e *BlockEntity) isSign() bool {
+	return e != nil && e.ID == "minecraft:sign"
+}
+
+// SignText returns the text of the four lines of a sign.
+func (e *BlockEntity) SignText() (lines [4]string) {
+	for i := range lines {
+		component, _ := e.Data["Text"+string(rune('1'+i))].(string)
+		lines[i] = plainText(component)
+	}
+	return
+}
+
+// SetSignText changes the text of the four lines of a sign.
+func (e *BlockEntity) SetSignText(lines [4]string) {
+	for i, line := range lines {
+		e.Data["Text"+string(rune('1'+i))] = textComponent(line)
+	}
+}
+
+// Item returns the item in a slot of a container block entity,
+// the count is 0 if the slot is empty.
+func (e *BlockEntity) Item(slot int) (id string, count int) {
+	items, _ := e.Data["Items"].(NBTList)
+	for _, item := range items {
+		if item, ok := item.(NBTCompound); ok && item["Slot"] == int8(slot) {
+			id, _ = item["id"].(string)
+			c, _ := item["Count"].(int8)
+			return id, int(c)
+		}
+	}
+	return "", 0
+}
+
+// SetItem changes the item in a slot of a container block entity,
+// a count of 0 empties the slot.
+func (e *BlockEntity) SetItem(slot int, id string, count int) {
+	items, _ := e.Data["Items"].(NBTList)
+	updated := make(NBTList, 0, len(items)+1)
+	for _, item := range items {
+		if item, ok := item.(NBTCompound); !ok || item["Slot"] != int8(slot) {
+			updated = append(updated, item)
+		}
+	}
+	if count > 0 {
+		updated = append(updated, NBTCompound{"Slot": int8(slot), "id": namespaced(id), "Count": int8(count)})
+	}
+	e.Data["Items"] = updated
+}
+
+// ItemStack returns the items in a slot of a container block entity with their data,
+// nil if the slot is empty.
+func (e *BlockEntity) ItemStack(slot int) *ItemStack {
+	items, _ := e.Data["Items"].(NBTList)
+	for _, item := range items {
+		if item, ok := item.(NBTCompound); ok && item["Slot"] == int8(slot) {
+			_, stack := itemStackFromNBT(item)
+			return stack
+		}
+	}
+	return nil
+}
+
+// SetItemStack changes the items in a slot of a container block entity, nil empties the slot.
+func (e *BlockEntity) SetItemStack(slot int, stack *ItemStack) {
+	e.SetItem(slot, "", 0)
+	if stack != nil && !stack.IsEmpty() {
+		e.Data["Items"] = append(e.Data["Items"].(NBTList), stack.nbt(slot))
+	}
+}
+
+// nbt returns the block entity tags with its type and world coordinates,
+// as they're sent in chunk data and block entity updates and saved in the region files.
+func (e *BlockEntity) nbt(x, y, z int) NBTCompound {
+	compound := make(NBTCompound, len(e.Data)+4)
+	for name, tag := range e.Data {
+		compound[name] = tag
+	}
+	compound["id"] = e.ID
+	compound["x"], compound["y"], compound["z"] = int32(x), int32(y), int32(z)
+	return compound
+}
+
+// blockEntityIndex returns the key of a block entity in a chunk
+// using chunk relative coordinates.
+func blockEntityIndex(x, y, z int) int {
+	return y<<8 | z<<4 | x
+}
+
+// BlockEntity returns the block entity at chunk relative coordinates, nil if there isn't one.
+// Changes to it must be applied with World.SetBlockEntity to be sent to the players.
+func (c *Chunk) BlockEntity(x, y, z int) *BlockEntity {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.entities[blockEntityIndex(x, y, z)]
+}
+
+// SetBlockEntity changes the block entity at chunk relative coordinates, nil removes it.
+func (c *Chunk) SetBlockEntity(x, y, z int, e *BlockEntity) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.setBlockEntity(x, y, z, e)
+}
+
+// setBlockEntity is SetBlockEntity without locking.
+func (c *Chunk) setBlockEntity(x, y, z int, e *BlockEntity) {
+	if y < 0 || y >= chunkHeight {
+		return
+	}
+
+	if e == nil {
+		delete(c.entities, blockEntityIndex(x, y, z))
+		return
+	}
+	if c.entities == nil {
+		c.entities = make(map[int]*BlockEntity)
+	}
+	c.entities[blockEntityIndex(x, y, z)] = e
+}
+
+// blockEntitiesNBT returns the tags of all the block entities of the chunk.
+func (c *Chunk) blockEntitiesNBT() []NBTCompound {
+	entities := make([]NBTCompound, 0, len(c.entities))
+	for index, e := range c.entities {
+		x, y, z := index&15, index>>8, index>>4&15
+		entities = append(entities, e.nbt(c.X*16+x, y, c.Z*16+z))
+	}
+	return entities
+}
+
+// textComponent converts plain text to a JSON text component.
+func textComponent(text string) string {
+	component, _ := json.Marshal(struct {
+		Text string `json:"text"`
+	}{text})
+	return string(component)
+}
+
+// plainText returns the text of a JSON text component made by textComponent.
+func plainText(component string) string {
+	var text struct {
+		Text string `json:"text"`
+	}
+	_ = json.Unmarshal([]byte(component), &text)
+	return text.Text
+}
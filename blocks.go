@@ -0,0 +1,485 @@
+package MinecraftLightServer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BlockState is a global block state ID of the Minecraft 1.16.5 protocol.
+type BlockState uint16
+
+// Block states used by the server (default state of each block).
+const (
+	blockAir          BlockState = 0
+	blockStone        BlockState = 1
+	blockGranite      BlockState = 2
+	blockDiorite      BlockState = 4
+	blockAndesite     BlockState = 6
+	blockGrass        BlockState = 9
+	blockDirt         BlockState = 10
+	blockCobblestone  BlockState = 14
+	blockOakPlanks    BlockState = 15
+	blockBedrock      BlockState = 33
+	blockWater        BlockState = 34
+	blockLava         BlockState = 50
+	blockSand         BlockState = 66
+	blockGravel       BlockState = 68
+	blockGoldOre      BlockState = 69
+	blockIronOre      BlockState = 70
+	blockCoalOre      BlockState = 71
+	blockOakLog       BlockState = 74
+	blockSpruceLog    BlockState = 77
+	blockBirchLog     BlockState = 80
+	blockOakLeaves    BlockState = 158
+	blockSpruceLeaves BlockState = 172
+	blockBirchLeaves  BlockState = 186
+	blockSandstone    BlockState = 246
+	blockTallGrass    BlockState = 1342
+	blockDeadBush     BlockState = 1344
+	blockDandelion    BlockState = 1412
+	blockPoppy        BlockState = 1413
+	blockDiamondOre   BlockState = 3354
+	blockSnow         BlockState = 3921
+	blockIce          BlockState = 3929
+	blockSnowBlock    BlockState = 3930
+	blockCactus       BlockState = 3931
+	blockClay         BlockState = 3947
+)
+
+// blockRegistry is the registry used to convert block states to names and back.
+var blockRegistry = newBlockRegistry(builtinBlocks)
+
+// BlockRegistry maps namespaced block names and properties
+// to global block state IDs and back.
+type BlockRegistry struct {
+	blocks map[string]*blockType // blocks by name
+	sorted []*blockType          // blocks sorted by first state
+}
+
+// blockType is a block with all its states.
+type blockType struct {
+	name       string          // namespaced name
+	first      BlockState      // first state ID
+	properties []blockProperty // properties sorted by name
+	hardness   float64         // how long the block takes to be broken, -1 if it can't be broken
+	resistance float64         // how much the block stops explosions
+	opacity    int             // how much the block reduces the light passing through it
+	emission   int             // light level emitted by the block
+	solid      bool            // the block stops entities
+}
+
+// unbreakable is the blast resistance of the blocks that can't be destroyed.
+const unbreakable = 3600000.0
+
+// blockProperty is a property of a block and its possible values.
+type blockProperty struct {
+	name   string   // property name
+	values []string // possible values
+	def    int      // index of the default value
+}
+
+// Common property values.
+var (
+	boolValues   = []string{"true", "false"}
+	facing4      = []string{"north", "south", "west", "east"}
+	facing6      = []string{"north", "east", "south", "west", "up", "down"}
+	axisValues   = []string{"x", "y", "z"}
+	colorValues  = []string{"white", "orange", "magenta", "light_blue", "yellow", "lime", "pink", "gray", "light_gray", "cyan", "purple", "blue", "brown", "green", "red", "black"}
+	woodValues   = []string{"oak", "spruce", "birch", "jungle", "acacia", "dark_oak"}
+	stairsShapes = []string{"straight", "inner_left", "inner_right", "outer_left", "outer_right"}
+	wireSides    = []string{"up", "side", "none"}
+)
+
+// intValues returns the values of an integer property from min to max.
+func intValues(min, max int) []string {
+	values := make([]string, 0, max-min+1)
+	for i := min; i <= max; i++ {
+		values = append(values, strconv.Itoa(i))
+	}
+	return values
+}
+
+// newBlockRegistry creates a registry from a list of blocks.
+func newBlockRegistry(blocks []*blockType) *BlockRegistry {
+	r := &BlockRegistry{blocks: make(map[string]*blockType)}
+	for _, block := range blocks {
+		sort.Slice(block.properties, func(i, j int) bool {
+			return block.properties[i].name < block.properties[j].name
+		})
+		block.hardness, block.resistance = blockStrength(block.name)
+		block.opacity, block.emission = lightProperties(block.name)
+		block.solid = blocksMotion(block.name)
+		r.blocks[block.name] = block
+		r.sorted = append(r.sorted, block)
+	}
+	sort.Slice(r.sorted, func(i, j int) bool {
+		return r.sorted[i].first < r.sorted[j].first
+	})
+	return r
+}
+
+// blockStrength returns the hardness and the blast resistance of a block, they aren't in the
+// vanilla reports so they're found from the block name. Stairs, slabs and walls are as strong
+// as their full blocks. Unknown blocks can't be broken.
+func blockStrength(name string) (hardness, resistance float64) {
+	short := strings.TrimPrefix(name, "minecraft:")
+	if strength, ok := blockStrengths[short]; ok {
+		return strength[0], strength[1]
+	}
+	if matchesAny(name, fragileBlocks) {
+		return 0, 0
+	}
+	for _, part := range blockStrengthParts {
+		if strings.Contains(short, part.part) {
+			return part.hardness, part.resistance
+		}
+	}
+	for _, suffix := range []string{"_stairs", "_slab", "_wall"} {
+		if !strings.HasSuffix(short, suffix) {
+			continue
+		}
+		material := strings.TrimSuffix(short, suffix)
+		for _, full := range []string{material, material + "s", material + "_block", material + "_planks"} {
+			if strength, ok := blockStrengths[full]; ok {
+				return strength[0], strength[1]
+			}
+		}
+		for _, wood := range append(woodValues, "crimson", "warped") {
+			if material == wood {
+				return 2, 3
+			}
+		}
+	}
+	return -1, unbreakable
+}
+
+// LoadBlockRegistry reads the blocks.json data report generated by the vanilla
+// server (java -cp server.jar net.minecraft.data.Main --reports).
+func LoadBlockRegistry(r io.Reader) (*BlockRegistry, error) {
+	var report map[string]struct {
+		Properties map[string][]string `json:"properties"`
+		States     []struct {
+			ID         int               `json:"id"`
+			Default    bool              `json:"default"`
+			Properties map[string]string `json:"properties"`
+		} `json:"states"`
+	}
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, err
+	}
+
+	blocks := make([]*blockType, 0, len(report))
+	for name, data := range report {
+		if len(data.States) == 0 {
+			return nil, errors.New("block without states: " + name)
+		}
+
+		block := &blockType{name: name, first: BlockState(data.States[0].ID)}
+		for property, values := range data.Properties {
+			block.properties = append(block.properties, blockProperty{name: property, values: values})
+		}
+		sort.Slice(block.properties, func(i, j int) bool {
+			return block.properties[i].name < block.properties[j].name
+		})
+
+		for _, state := range data.States {
+			if BlockState(state.ID) < block.first {
+				block.first = BlockState(state.ID)
+			}
+		}
+
+		// States must be ordered like vanilla does, check it and get the default values
+		for _, state := range data.States {
+			offset := int(BlockState(state.ID) - block.first)
+			for i := len(block.properties) - 1; i >= 0; i-- {
+				property := &block.properties[i]
+				index := offset % len(property.values)
+				offset /= len(property.values)
+
+				if property.values[index] != state.Properties[property.name] {
+					return nil, errors.New("unexpected state order: " + name)
+				}
+				if state.Default {
+					property.def = index
+				}
+			}
+		}
+		blocks = append(blocks, block)
+	}
+
+	return newBlockRegistry(blocks), nil
+}
+
+// SetBlockRegistry changes the registry used by the server, for example with
+// one loaded from the full vanilla report. It must be called before starting the server.
+func SetBlockRegistry(r *BlockRegistry) {
+	blockRegistry = r
+}
+
+// count returns the number of states of a block.
+func (b *blockType) count() int {
+	count := 1
+	for _, property := range b.properties {
+		count *= len(property.values)
+	}
+	return count
+}
+
+// state returns the state ID using an index for each property value.
+func (b *blockType) state(indexes []int) BlockState {
+	offset := 0
+	for i, property := range b.properties {
+		offset = offset*len(property.values) + indexes[i]
+	}
+	return b.first + BlockState(offset)
+}
+
+// indexes returns the index of each property value of a state.
+func (b *blockType) indexes(state BlockState) []int {
+	offset := int(state - b.first)
+	indexes := make([]int, len(b.properties))
+	for i := len(b.properties) - 1; i >= 0; i-- {
+		indexes[i] = offset % len(b.properties[i].values)
+		offset /= len(b.properties[i].values)
+	}
+	return indexes
+}
+
+// defaultState returns the default state of the block.
+func (b *blockType) defaultState() BlockState {
+	indexes := make([]int, len(b.properties))
+	for i, property := range b.properties {
+		indexes[i] = property.def
+	}
+	return b.state(indexes)
+}
+
+// block returns the block that contains a state.
+func (r *BlockRegistry) block(state BlockState) *blockType {
+	i := sort.Search(len(r.sorted), func(i int) bool {
+		return r.sorted[i].first > state
+	}) - 1
+	if i < 0 || int(state-r.sorted[i].first) >= r.sorted[i].count() {
+		return nil
+	}
+	return r.sorted[i]
+}
+
+// DefaultState returns the default state of a block, the "minecraft:"
+// namespace can be omitted.
+func (r *BlockRegistry) DefaultState(name string) (BlockState, bool) {
+	block, ok := r.blocks[namespaced(name)]
+	if !ok {
+		return blockAir, false
+	}
+	return block.defaultState(), true
+}
+
+// State returns the state of a block with the specified properties,
+// the ones that are missing use their default value.
+func (r *BlockRegistry) State(name string, properties map[string]string) (BlockState, error) {
+	block, ok := r.blocks[namespaced(name)]
+	if !ok {
+		return blockAir, errors.New("unknown block: " + name)
+	}
+
+	indexes := block.indexes(block.defaultState())
+	for key, value := range properties {
+		found := false
+		for i, property := range block.properties {
+			if property.name != key {
+				continue
+			}
+			for j, v := range property.values {
+				if v == value {
+					indexes[i], found = j, true
+				}
+			}
+		}
+		if !found {
+			return blockAir, errors.New("invalid property " + key + "=" + value + " of " + name)
+		}
+	}
+	return block.state(indexes), nil
+}
+
+// Parse returns the state described by a string like "minecraft:oak_stairs[facing=north]".
+func (r *BlockRegistry) Parse(s string) (BlockState, error) {
+	name, properties := s, make(map[string]string)
+	if i := strings.Index(s, "["); i != -1 {
+		if !strings.HasSuffix(s, "]") {
+			return blockAir, errors.New("invalid block state: " + s)
+		}
+		name = s[:i]
+
+		for _, property := range strings.Split(s[i+1:len(s)-1], ",") {
+			keyValue := strings.SplitN(property, "=", 2)
+			if len(keyValue) != 2 {
+				return blockAir, errors.New("invalid block property: " + property)
+			}
+			properties[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
+		}
+	}
+	return r.State(strings.TrimSpace(name), properties)
+}
+
+// Hardness returns how long a block takes to be broken, -1 if it can't be broken.
+func (r *BlockRegistry) Hardness(state BlockState) float64 {
+	if block := r.block(state); block != nil {
+		return block.hardness
+	}
+	return -1
+}
+
+// BlastResistance returns how much a block stops explosions.
+func (r *BlockRegistry) BlastResistance(state BlockState) float64 {
+	if block := r.block(state); block != nil {
+		return block.resistance
+	}
+	return unbreakable
+}
+
+// Opacity returns how much a block reduces the light passing through it.
+func (r *BlockRegistry) Opacity(state BlockState) int {
+	if block := r.block(state); block != nil {
+		return block.opacity
+	}
+	return maxLight
+}
+
+// Emission returns the light level emitted by a block, blocks with
+// a lit property emit light only when they're lit.
+func (r *BlockRegistry) Emission(state BlockState) int {
+	block := r.block(state)
+	if block == nil || block.emission == 0 {
+		return 0
+	}
+	for i, index := range block.indexes(state) {
+		if property := block.properties[i]; property.name == "lit" && property.values[index] != "true" {
+			return 0
+		}
+	}
+	return block.emission
+}
+
+// BlocksMotion checks if a block stops entities.
+func (r *BlockRegistry) BlocksMotion(state BlockState) bool {
+	if block := r.block(state); block != nil {
+		return block.solid
+	}
+	return true
+}
+
+// Name returns the namespaced name of the block of a state.
+func (r *BlockRegistry) Name(state BlockState) string {
+	if block := r.block(state); block != nil {
+		return block.name
+	}
+	return ""
+}
+
+// Properties returns the property values of a state.
+func (r *BlockRegistry) Properties(state BlockState) map[string]string {
+	properties := make(map[string]string)
+	if block := r.block(state); block != nil {
+		for i, index := range block.indexes(state) {
+			properties[block.properties[i].name] = block.properties[i].values[index]
+		}
+	}
+	return properties
+}
+
+// Property returns the value of a property of a state.
+func (r *BlockRegistry) Property(state BlockState, name string) (string, bool) {
+	value, ok := r.Properties(state)[name]
+	return value, ok
+}
+
+// WithProperty returns the state of the same block with a property changed.
+func (r *BlockRegistry) WithProperty(state BlockState, name, value string) (BlockState, bool) {
+	block := r.block(state)
+	if block == nil {
+		return state, false
+	}
+
+	indexes := block.indexes(state)
+	for i, property := range block.properties {
+		if property.name == name {
+			for j, v := range property.values {
+				if v == value {
+					indexes[i] = j
+					return block.state(indexes), true
+				}
+			}
+		}
+	}
+	return state, false
+}
+
+// String returns a state in the "minecraft:name[property=value,...]" format.
+func (r *BlockRegistry) String(state BlockState) string {
+	block := r.block(state)
+	if block == nil {
+		return "unknown:" + strconv.Itoa(int(state))
+	}
+	if len(block.properties) == 0 {
+		return block.name
+	}
+
+	properties := make([]string, len(block.properties))
+	for i, index := range block.indexes(state) {
+		properties[i] = block.properties[i].name + "=" + block.properties[i].values[index]
+	}
+	return block.name + "[" + strings.Join(properties, ",") + "]"
+}
+
+// ParseBlockState returns the state described by a string like
+// "minecraft:oak_stairs[facing=north]" using the server registry.
+func ParseBlockState(s string) (BlockState, error) {
+	return blockRegistry.Parse(s)
+}
+
+// Name returns the namespaced name of the block.
+func (b BlockState) Name() string {
+	return blockRegistry.Name(b)
+}
+
+// Property returns the value of a block property, or an empty string if missing.
+func (b BlockState) Property(name string) string {
+	value, _ := blockRegistry.Property(b, name)
+	return value
+}
+
+// Hardness returns how long the block takes to be broken, -1 if it can't be broken.
+func (b BlockState) Hardness() float64 {
+	return blockRegistry.Hardness(b)
+}
+
+// BlastResistance returns how much the block stops explosions.
+func (b BlockState) BlastResistance() float64 {
+	return blockRegistry.BlastResistance(b)
+}
+
+// With returns the state of the same block with a property changed.
+// If the property or the value are invalid, the state doesn't change.
+func (b BlockState) With(name, value string) BlockState {
+	state, _ := blockRegistry.WithProperty(b, name, value)
+	return state
+}
+
+// String returns the block state as "minecraft:name[property=value,...]".
+func (b BlockState) String() string {
+	return blockRegistry.String(b)
+}
+
+// namespaced adds the "minecraft:" namespace to a name if it doesn't have one.
+func namespaced(name string) string {
+	if !strings.Contains(name, ":") {
+		return "minecraft:" + name
+	}
+	return name
+}
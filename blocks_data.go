@@ -0,0 +1,328 @@
+package MinecraftLightServer
+
+// builtinBlocks are the 1.16.5 blocks known by the server without
+// loading the vanilla data report (see LoadBlockRegistry).
+var builtinBlocks = func() []*blockType {
+	// Frequently used properties
+	axis := prop("axis", axisValues, "y")
+	facing := prop("facing", facing4, "north")
+	lit := prop("lit", boolValues, "false")
+	powered := prop("powered", boolValues, "false")
+	snowy := prop("snowy", boolValues, "false")
+	stage := prop("stage", intValues(0, 1), "0")
+	waterlogged := prop("waterlogged", boolValues, "false")
+	fence := []blockProperty{prop("east", boolValues, "false"), prop("north", boolValues, "false"),
+		prop("south", boolValues, "false"), waterlogged, prop("west", boolValues, "false")}
+	door := []blockProperty{facing, prop("half", []string{"upper", "lower"}, "lower"),
+		prop("hinge", []string{"left", "right"}, "left"), prop("open", boolValues, "false"), powered}
+	stairs := []blockProperty{facing, prop("half", []string{"top", "bottom"}, "bottom"),
+		prop("shape", stairsShapes, "straight"), waterlogged}
+	trapdoor := []blockProperty{facing, prop("half", []string{"top", "bottom"}, "bottom"),
+		prop("open", boolValues, "false"), powered, waterlogged}
+	button := []blockProperty{prop("face", []string{"floor", "wall", "ceiling"}, "wall"), facing, powered}
+	railShapes := []string{"north_south", "east_west", "ascending_east", "ascending_west", "ascending_north", "ascending_south"}
+
+	blocks := []*blockType{
+		block("air", 0),
+		block("stone", 1),
+		block("granite", 2),
+		block("polished_granite", 3),
+		block("diorite", 4),
+		block("polished_diorite", 5),
+		block("andesite", 6),
+		block("polished_andesite", 7),
+		block("grass_block", 8, snowy),
+		block("dirt", 10),
+		block("coarse_dirt", 11),
+		block("podzol", 12, snowy),
+		block("cobblestone", 14),
+		block("bedrock", 33),
+		block("water", 34, prop("level", intValues(0, 15), "0")),
+		block("lava", 50, prop("level", intValues(0, 15), "0")),
+		block("sand", 66),
+		block("red_sand", 67),
+		block("gravel", 68),
+		block("gold_ore", 69),
+		block("iron_ore", 70),
+		block("coal_ore", 71),
+		block("nether_gold_ore", 72),
+		block("sponge", 229),
+		block("wet_sponge", 230),
+		block("glass", 231),
+		block("lapis_ore", 232),
+		block("lapis_block", 233),
+		block("dispenser", 234, prop("facing", facing6, "north"), prop("triggered", boolValues, "false")),
+		block("sandstone", 246),
+		block("chiseled_sandstone", 247),
+		block("cut_sandstone", 248),
+		block("note_block", 249, prop("instrument", []string{"harp", "basedrum", "snare", "hat", "bass", "flute",
+			"bell", "guitar", "chime", "xylophone", "iron_xylophone", "cow_bell", "didgeridoo", "bit", "banjo", "pling"}, "harp"),
+			prop("note", intValues(0, 24), "0"), powered),
+		block("powered_rail", 1305, powered, prop("shape", railShapes, "north_south")),
+		block("detector_rail", 1317, powered, prop("shape", railShapes, "north_south")),
+		block("sticky_piston", 1329, prop("extended", boolValues, "false"), prop("facing", facing6, "north")),
+		block("cobweb", 1341),
+		block("grass", 1342),
+		block("fern", 1343),
+		block("dead_bush", 1344),
+		block("seagrass", 1345),
+		block("tall_seagrass", 1346, prop("half", []string{"upper", "lower"}, "lower")),
+		block("piston", 1348, prop("extended", boolValues, "false"), prop("facing", facing6, "north")),
+		block("piston_head", 1360, prop("facing", facing6, "north"), prop("short", boolValues, "false"),
+			prop("type", []string{"normal", "sticky"}, "normal")),
+		block("moving_piston", 1400, prop("facing", facing6, "north"), prop("type", []string{"normal", "sticky"}, "normal")),
+		block("dandelion", 1412),
+		block("poppy", 1413),
+		block("blue_orchid", 1414),
+		block("allium", 1415),
+		block("azure_bluet", 1416),
+		block("red_tulip", 1417),
+		block("orange_tulip", 1418),
+		block("white_tulip", 1419),
+		block("pink_tulip", 1420),
+		block("oxeye_daisy", 1421),
+		block("cornflower", 1422),
+		block("wither_rose", 1423),
+		block("lily_of_the_valley", 1424),
+		block("brown_mushroom", 1425),
+		block("red_mushroom", 1426),
+		block("gold_block", 1427),
+		block("iron_block", 1428),
+		block("bricks", 1429),
+		block("tnt", 1430, prop("unstable", boolValues, "false")),
+		block("bookshelf", 1432),
+		block("mossy_cobblestone", 1433),
+		block("obsidian", 1434),
+		block("torch", 1435),
+		block("wall_torch", 1436, facing),
+		block("fire", 1440, prop("age", intValues(0, 15), "0"), prop("east", boolValues, "false"),
+			prop("north", boolValues, "false"), prop("south", boolValues, "false"),
+			prop("up", boolValues, "false"), prop("west", boolValues, "false")),
+		block("soul_fire", 1952),
+		block("spawner", 1953),
+		block("oak_stairs", 1954, stairs...),
+		block("chest", 2034, facing, prop("type", []string{"single", "left", "right"}, "single"), waterlogged),
+		block("redstone_wire", 2058, prop("east", wireSides, "none"), prop("north", wireSides, "none"),
+			prop("power", intValues(0, 15), "0"), prop("south", wireSides, "none"), prop("west", wireSides, "none")),
+		block("diamond_ore", 3354),
+		block("diamond_block", 3355),
+		block("crafting_table", 3356),
+		block("wheat", 3357, prop("age", intValues(0, 7), "0")),
+		block("farmland", 3365, prop("moisture", intValues(0, 7), "0")),
+		block("furnace", 3373, facing, lit),
+		block("oak_door", 3573, door...),
+		block("ladder", 3637, facing, waterlogged),
+		block("rail", 3645, prop("shape", append(railShapes, "south_east", "south_west", "north_west", "north_east"), "north_south")),
+		block("cobblestone_stairs", 3655, stairs...),
+		block("lever", 3783, button...),
+		block("stone_pressure_plate", 3807, powered),
+		block("iron_door", 3809, door...),
+		block("redstone_ore", 3885, lit),
+		block("redstone_torch", 3887, prop("lit", boolValues, "true")),
+		block("redstone_wall_torch", 3889, facing, prop("lit", boolValues, "true")),
+		block("stone_button", 3897, button...),
+		block("snow", 3921, prop("layers", intValues(1, 8), "1")),
+		block("ice", 3929),
+		block("snow_block", 3930),
+		block("cactus", 3931, prop("age", intValues(0, 15), "0")),
+		block("clay", 3947),
+		block("sugar_cane", 3948, prop("age", intValues(0, 15), "0")),
+		block("jukebox", 3964, prop("has_record", boolValues, "false")),
+		block("oak_fence", 3966, fence...),
+		block("pumpkin", 3998),
+		block("netherrack", 3999),
+		block("soul_sand", 4000),
+		block("soul_soil", 4001),
+		block("basalt", 4002, axis),
+		block("polished_basalt", 4005, axis),
+		block("soul_torch", 4008),
+		block("soul_wall_torch", 4009, facing),
+		block("glowstone", 4013),
+		block("nether_portal", 4014, prop("axis", []string{"x", "z"}, "x")),
+		block("carved_pumpkin", 4016, facing),
+		block("jack_o_lantern", 4020, facing),
+		block("cake", 4024, prop("bites", intValues(0, 6), "0")),
+		block("repeater", 4031, prop("delay", intValues(1, 4), "1"), facing, prop("locked", boolValues, "false"), powered),
+		block("mycelium", 5016, snowy),
+		block("redstone_lamp", 5160, lit),
+		block("carrots", 6330, prop("age", intValues(0, 7), "0")),
+		block("potatoes", 6338, prop("age", intValues(0, 7), "0")),
+		block("beetroots", 9219, prop("age", intValues(0, 3), "0")),
+		block("crimson_door", 15271, door...),
+		block("warped_door", 15335, door...),
+		block("crimson_button", 15223, button...),
+		block("warped_button", 15247, button...),
+	}
+
+	// Blocks with a variant for each wood type
+	for i, wood := range woodValues {
+		blocks = append(blocks,
+			block(wood+"_planks", BlockState(15+i)),
+			block(wood+"_sapling", BlockState(21+2*i), stage),
+			block(wood+"_log", BlockState(73+3*i), axis),
+			block(wood+"_wood", BlockState(109+3*i), axis),
+			block("stripped_"+wood+"_wood", BlockState(127+3*i), axis),
+			block(wood+"_leaves", BlockState(145+14*i), prop("distance", intValues(1, 7), "7"), prop("persistent", boolValues, "false")),
+			block(wood+"_pressure_plate", BlockState(3873+2*i), powered),
+			block(wood+"_button", BlockState(6346+24*i), button...),
+			block(wood+"_trapdoor", BlockState(4111+64*i), trapdoor...),
+		)
+	}
+
+	// Doors of the other woods come after oak door
+	for i, wood := range woodValues[1:] {
+		blocks = append(blocks, block(wood+"_door", BlockState(8738+64*i), door...))
+	}
+
+	// Stripped logs start from spruce, stripped oak log is the last one
+	for i, wood := range woodValues {
+		first := 91 + 3*(i+len(woodValues)-1)%(3*len(woodValues))
+		blocks = append(blocks, block("stripped_"+wood+"_log", BlockState(first), axis))
+	}
+
+	// Signs use a different order of wood types
+	for i, wood := range []string{"oak", "spruce", "birch", "acacia", "jungle", "dark_oak"} {
+		blocks = append(blocks,
+			block(wood+"_sign", BlockState(3381+32*i), prop("rotation", intValues(0, 15), "0"), waterlogged),
+			block(wood+"_wall_sign", BlockState(3735+8*i), facing, waterlogged),
+		)
+	}
+
+	// Blocks with a variant for each color
+	for i, color := range colorValues {
+		blocks = append(blocks,
+			block(color+"_bed", BlockState(1049+16*i), facing,
+				prop("occupied", boolValues, "false"), prop("part", []string{"head", "foot"}, "foot")),
+			block(color+"_wool", BlockState(1384+i)),
+			block(color+"_stained_glass", BlockState(4095+i)),
+			block(color+"_concrete", BlockState(9442+i)),
+			block(color+"_concrete_powder", BlockState(9458+i)),
+		)
+	}
+
+	return blocks
+}()
+
+// block creates a block definition, the "minecraft:" namespace is added to name.
+func block(name string, first BlockState, properties ...blockProperty) *blockType {
+	return &blockType{
+		name:       namespaced(name),
+		first:      first,
+		properties: append([]blockProperty(nil), properties...),
+	}
+}
+
+// prop creates a block property definition with its default value.
+func prop(name string, values []string, def string) blockProperty {
+	property := blockProperty{name: name, values: values}
+	for i, value := range values {
+		if value == def {
+			property.def = i
+		}
+	}
+	return property
+}
+
+// blockStrengths are the hardness and the blast resistance of the blocks that aren't
+// found by their name parts in blockStrengthParts, by name without namespace.
+var blockStrengths = map[string][2]float64{
+	"air": {0, 0}, "cave_air": {0, 0}, "void_air": {0, 0},
+	"stone": {1.5, 6}, "granite": {1.5, 6}, "polished_granite": {1.5, 6}, "diorite": {1.5, 6},
+	"polished_diorite": {1.5, 6}, "andesite": {1.5, 6}, "polished_andesite": {1.5, 6},
+	"grass_block": {0.6, 0.6}, "dirt": {0.5, 0.5}, "coarse_dirt": {0.5, 0.5}, "podzol": {0.5, 0.5},
+	"crimson_nylium": {0.4, 0.4}, "warped_nylium": {0.4, 0.4}, "mycelium": {0.6, 0.6},
+	"farmland": {0.6, 0.6}, "grass_path": {0.65, 0.65}, "clay": {0.6, 0.6},
+	"cobblestone": {2, 6}, "mossy_cobblestone": {2, 6}, "bricks": {2, 6},
+	"bedrock": {-1, unbreakable}, "water": {100, 100}, "lava": {100, 100}, "bubble_column": {0, 0},
+	"sand": {0.5, 0.5}, "red_sand": {0.5, 0.5}, "gravel": {0.6, 0.6},
+	"sponge": {0.6, 0.6}, "wet_sponge": {0.6, 0.6}, "glass": {0.3, 0.3}, "glass_pane": {0.3, 0.3},
+	"gold_block": {3, 6}, "iron_block": {5, 6}, "diamond_block": {5, 6}, "emerald_block": {5, 6},
+	"redstone_block": {5, 6}, "coal_block": {5, 6}, "lapis_block": {3, 3}, "netherite_block": {50, 1200},
+	"sandstone": {0.8, 0.8}, "chiseled_sandstone": {0.8, 0.8}, "cut_sandstone": {0.8, 0.8},
+	"red_sandstone": {0.8, 0.8}, "chiseled_red_sandstone": {0.8, 0.8}, "cut_red_sandstone": {0.8, 0.8},
+	"smooth_stone": {2, 6}, "smooth_sandstone": {2, 6}, "smooth_red_sandstone": {2, 6}, "smooth_quartz": {2, 6},
+	"stone_bricks": {1.5, 6}, "mossy_stone_bricks": {1.5, 6}, "cracked_stone_bricks": {1.5, 6},
+	"chiseled_stone_bricks": {1.5, 6}, "nether_bricks": {2, 6}, "cracked_nether_bricks": {2, 6},
+	"chiseled_nether_bricks": {2, 6}, "red_nether_bricks": {2, 6}, "nether_brick_fence": {2, 6},
+	"end_stone": {3, 9}, "end_stone_bricks": {3, 9}, "dragon_egg": {3, 9},
+	"quartz_block": {0.8, 0.8}, "chiseled_quartz_block": {0.8, 0.8}, "quartz_pillar": {0.8, 0.8},
+	"quartz_bricks": {0.8, 0.8}, "purpur_block": {1.5, 6}, "purpur_pillar": {1.5, 6},
+	"prismarine": {1.5, 6}, "prismarine_bricks": {1.5, 6}, "dark_prismarine": {1.5, 6},
+	"blackstone": {1.5, 6}, "gilded_blackstone": {1.5, 6}, "polished_blackstone": {2, 6},
+	"polished_blackstone_bricks": {1.5, 6}, "cracked_polished_blackstone_bricks": {1.5, 6},
+	"chiseled_polished_blackstone": {1.5, 6}, "terracotta": {1.25, 4.2},
+	"netherrack": {0.4, 0.4}, "soul_sand": {0.5, 0.5}, "soul_soil": {0.5, 0.5},
+	"basalt": {1.25, 4.2}, "polished_basalt": {1.25, 4.2}, "magma_block": {0.5, 0.5},
+	"obsidian": {50, 1200}, "crying_obsidian": {50, 1200}, "respawn_anchor": {50, 1200},
+	"ancient_debris": {30, 1200}, "enchanting_table": {5, 1200}, "ender_chest": {22.5, 600},
+	"anvil": {5, 1200}, "chipped_anvil": {5, 1200}, "damaged_anvil": {5, 1200},
+	"bookshelf": {1.5, 1.5}, "spawner": {5, 5}, "cobweb": {4, 4}, "beacon": {3, 3}, "conduit": {3, 3},
+	"observer": {3, 3}, "hopper": {3, 4.8}, "bell": {5, 5}, "lodestone": {3.5, 3.5},
+	"dispenser": {3.5, 3.5}, "dropper": {3.5, 3.5}, "furnace": {3.5, 3.5}, "smoker": {3.5, 3.5},
+	"blast_furnace": {3.5, 3.5}, "stonecutter": {3.5, 3.5}, "lantern": {3.5, 3.5}, "soul_lantern": {3.5, 3.5},
+	"chest": {2.5, 2.5}, "trapped_chest": {2.5, 2.5}, "crafting_table": {2.5, 2.5}, "barrel": {2.5, 2.5},
+	"loom": {2.5, 2.5}, "cartography_table": {2.5, 2.5}, "fletching_table": {2.5, 2.5},
+	"smithing_table": {2.5, 2.5}, "lectern": {2.5, 2.5}, "grindstone": {2, 6}, "jukebox": {2, 6},
+	"cauldron": {2, 2}, "brewing_stand": {0.5, 0.5}, "composter": {0.6, 0.6}, "bone_block": {2, 2},
+	"campfire": {2, 2}, "soul_campfire": {2, 2}, "note_block": {0.8, 0.8},
+	"piston": {1.5, 1.5}, "sticky_piston": {1.5, 1.5}, "piston_head": {1.5, 1.5}, "moving_piston": {-1, 0},
+	"iron_bars": {5, 6}, "chain": {5, 6}, "iron_door": {5, 5}, "iron_trapdoor": {5, 5},
+	"daylight_detector": {0.2, 0.2}, "target": {0.5, 0.5}, "lever": {0.5, 0.5}, "ladder": {0.4, 0.4},
+	"snow": {0.1, 0.1}, "snow_block": {0.2, 0.2}, "ice": {0.5, 0.5}, "packed_ice": {0.5, 0.5},
+	"frosted_ice": {0.5, 0.5}, "blue_ice": {2.8, 2.8}, "cactus": {0.4, 0.4}, "cake": {0.5, 0.5},
+	"pumpkin": {1, 1}, "carved_pumpkin": {1, 1}, "jack_o_lantern": {1, 1}, "melon": {1, 1},
+	"glowstone": {0.3, 0.3}, "sea_lantern": {0.3, 0.3}, "redstone_lamp": {0.3, 0.3}, "shroomlight": {1, 1},
+	"hay_block": {0.5, 0.5}, "dried_kelp_block": {0.5, 2.5}, "turtle_egg": {0.5, 0.5},
+	"nether_wart_block": {1, 1}, "warped_wart_block": {1, 1}, "brown_mushroom_block": {0.2, 0.2},
+	"red_mushroom_block": {0.2, 0.2}, "mushroom_stem": {0.2, 0.2}, "vine": {0.2, 0.2}, "cocoa": {0.2, 3},
+	"chorus_plant": {0.4, 0.4}, "chorus_flower": {0.4, 0.4}, "bamboo": {1, 1}, "bamboo_sapling": {1, 1},
+	"honeycomb_block": {0.6, 0.6}, "bee_nest": {0.3, 0.3}, "beehive": {0.6, 0.6},
+	"nether_portal": {-1, 0}, "end_portal": {-1, unbreakable}, "end_gateway": {-1, unbreakable},
+	"end_portal_frame": {-1, unbreakable}, "barrier": {-1, unbreakable}, "command_block": {-1, unbreakable},
+	"chain_command_block": {-1, unbreakable}, "repeating_command_block": {-1, unbreakable},
+	"structure_block": {-1, unbreakable}, "jigsaw": {-1, unbreakable},
+	// Slabs that are harder than their full blocks
+	"stone_slab": {2, 6}, "smooth_stone_slab": {2, 6}, "sandstone_slab": {2, 6}, "cut_sandstone_slab": {2, 6},
+	"red_sandstone_slab": {2, 6}, "cut_red_sandstone_slab": {2, 6}, "cobblestone_slab": {2, 6},
+	"brick_slab": {2, 6}, "stone_brick_slab": {2, 6}, "nether_brick_slab": {2, 6}, "quartz_slab": {2, 6},
+	"purpur_slab": {2, 6}, "petrified_oak_slab": {2, 6},
+}
+
+// blockStrengthParts are the hardness and the blast resistance of the blocks that contain
+// a name part, in the order they're checked.
+var blockStrengthParts = []struct {
+	part                 string  // part of the block name
+	hardness, resistance float64 // strength of the blocks
+}{
+	{"infested_", 0, 0.75}, {"potted_", 0, 0}, {"coral_block", 1.5, 6}, {"coral", 0, 0}, {"_ore", 3, 3},
+	{"_planks", 2, 3}, {"_log", 2, 2}, {"_wood", 2, 2}, {"_stem", 2, 2}, {"_hyphae", 2, 2},
+	{"_leaves", 0.2, 0.2}, {"_bed", 0.2, 0.2}, {"rail", 0.7, 0.7}, {"_wool", 0.8, 0.8},
+	{"_carpet", 0.1, 0.1}, {"glass", 0.3, 0.3}, {"_glazed_terracotta", 1.4, 1.4},
+	{"_terracotta", 1.25, 4.2}, {"_concrete_powder", 0.5, 0.5}, {"_concrete", 1.8, 1.8},
+	{"shulker_box", 2, 2}, {"_banner", 1, 1}, {"_sign", 1, 1}, {"_pressure_plate", 0.5, 0.5},
+	{"_button", 0.5, 0.5}, {"_fence", 2, 3}, {"_trapdoor", 3, 3}, {"_door", 3, 3},
+	{"_head", 1, 1}, {"_skull", 1, 1},
+}
+
+// plantBlocks are the plants without collisions, that let light through.
+var plantBlocks = []string{"_sapling", "minecraft:grass", "minecraft:fern", "minecraft:dead_bush", "minecraft:tall_grass",
+	"minecraft:large_fern", "minecraft:dandelion", "minecraft:poppy", "minecraft:blue_orchid", "minecraft:allium",
+	"minecraft:azure_bluet", "_tulip", "minecraft:oxeye_daisy", "minecraft:cornflower", "minecraft:lily_of_the_valley",
+	"minecraft:wither_rose", "minecraft:sunflower", "minecraft:lilac", "minecraft:rose_bush", "minecraft:peony",
+	"minecraft:brown_mushroom", "minecraft:red_mushroom", "_fungus", "_roots", "minecraft:nether_sprouts", "vine",
+	"minecraft:sugar_cane", "minecraft:sweet_berry_bush", "minecraft:wheat", "minecraft:carrots", "minecraft:potatoes",
+	"minecraft:beetroots", "minecraft:nether_wart", "minecraft:pumpkin_stem", "minecraft:melon_stem",
+	"minecraft:attached_pumpkin_stem", "minecraft:attached_melon_stem"}
+
+// fragileBlocks are the blocks that break instantly and don't stop explosions.
+// The names without namespace match every block that contains them.
+var fragileBlocks = []string{"_sapling", "torch", "minecraft:fire", "minecraft:soul_fire", "mushroom", "_fungus", "_roots", "vines", "sprouts",
+	"_tulip", "grass", "fern", "kelp", "bush", "minecraft:dandelion", "minecraft:poppy", "minecraft:blue_orchid",
+	"minecraft:allium", "minecraft:azure_bluet", "minecraft:oxeye_daisy", "minecraft:cornflower",
+	"minecraft:lily_of_the_valley", "minecraft:wither_rose", "minecraft:sunflower", "minecraft:lilac",
+	"minecraft:rose_bush", "minecraft:peony", "minecraft:sugar_cane", "minecraft:lily_pad", "minecraft:wheat",
+	"minecraft:carrots", "minecraft:potatoes", "minecraft:beetroots", "minecraft:nether_wart", "minecraft:pumpkin_stem",
+	"minecraft:melon_stem", "minecraft:attached_pumpkin_stem", "minecraft:attached_melon_stem",
+	"minecraft:sea_pickle", "minecraft:tnt", "minecraft:redstone_wire", "minecraft:repeater",
+	"minecraft:comparator", "tripwire", "minecraft:flower_pot", "minecraft:slime_block",
+	"minecraft:honey_block", "minecraft:scaffolding", "minecraft:end_rod", "minecraft:structure_void"}
@@ -0,0 +1,55 @@
+package MinecraftLightServer
+
+import "testing"
+
+func TestBlockStrength(t *testing.T) {
+	tests := []struct {
+		name                 string
+		hardness, resistance float64
+	}{
+		{"minecraft:air", 0, 0},
+		{"minecraft:stone", 1.5, 6},
+		{"minecraft:stone_bricks", 1.5, 6},
+		{"minecraft:end_stone", 3, 9},
+		{"minecraft:nether_bricks", 2, 6},
+		{"minecraft:diamond_ore", 3, 3},
+		{"minecraft:obsidian", 50, 1200},
+		{"minecraft:bedrock", -1, unbreakable},
+		{"minecraft:barrier", -1, unbreakable},
+		{"minecraft:end_portal_frame", -1, unbreakable},
+		{"minecraft:command_block", -1, unbreakable},
+		{"minecraft:oak_planks", 2, 3},
+		{"minecraft:stripped_crimson_stem", 2, 2},
+		{"minecraft:pumpkin_stem", 0, 0},
+		{"minecraft:mushroom_stem", 0.2, 0.2},
+		{"minecraft:fire", 0, 0},
+		{"minecraft:fire_coral_block", 1.5, 6},
+		{"minecraft:redstone_wall_torch", 0, 0},
+		{"minecraft:white_glazed_terracotta", 1.4, 1.4},
+		{"minecraft:white_concrete_powder", 0.5, 0.5},
+		{"minecraft:oak_stairs", 2, 3},
+		{"minecraft:crimson_slab", 2, 3},
+		{"minecraft:quartz_stairs", 0.8, 0.8},
+		{"minecraft:stone_slab", 2, 6},
+		{"minecraft:end_stone_brick_wall", 3, 9},
+		{"minecraft:polished_blackstone_brick_slab", 1.5, 6},
+		{"plugin:unknown_block", -1, unbreakable},
+	}
+	for _, test := range tests {
+		hardness, resistance := blockStrength(test.name)
+		if hardness != test.hardness || resistance != test.resistance {
+			t.Errorf("%s: strength %v, %v, want %v, %v", test.name, hardness, resistance, test.hardness, test.resistance)
+		}
+	}
+}
+
+func TestBuiltinBlockStrengths(t *testing.T) {
+	unbreakableBlocks := map[string]bool{
+		"minecraft:bedrock": true, "minecraft:moving_piston": true, "minecraft:nether_portal": true,
+	}
+	for _, block := range blockRegistry.sorted {
+		if (block.hardness < 0) != unbreakableBlocks[block.name] {
+			t.Errorf("%s: hardness %v", block.name, block.hardness)
+		}
+	}
+}
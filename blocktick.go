@@ -0,0 +1,83 @@
+package MinecraftLightServer
+
+import (
+	"sort"
+	"sync"
+)
+
+// maxScheduledTicks is the maximum number of scheduled block ticks run during
+// a server tick, the others are delayed to the next one.
+const maxScheduledTicks = 65536
+
+// blockTickHandler runs the scheduled tick of a block.
+type blockTickHandler func(w *World, pos Position, state BlockState)
+
+// blockTickHandlers are the handlers of the blocks with scheduled ticks, by block name.
+var blockTickHandlers = make(map[string]blockTickHandler)
+
+// scheduledTicks are the block ticks that will run in the future.
+type scheduledTicks struct {
+	due     map[int64][]Position // positions to tick by world age
+	pending map[Position]bool    // positions with a scheduled tick
+	mutex   sync.Mutex           // scheduled ticks mutex
+}
+
+// ScheduleTick schedules a tick of the block at a position after the specified
+// number of ticks. Blocks with a scheduled tick aren't scheduled again.
+func (w *World) ScheduleTick(pos Position, delay int) {
+	if delay < 1 {
+		delay = 1
+	}
+	due := w.Age() + int64(delay)
+
+	w.scheduled.mutex.Lock()
+	defer w.scheduled.mutex.Unlock()
+	if w.scheduled.pending[pos] {
+		return
+	}
+	if w.scheduled.due == nil {
+		w.scheduled.due = make(map[int64][]Position)
+		w.scheduled.pending = make(map[Position]bool)
+	}
+	w.scheduled.pending[pos] = true
+	w.scheduled.due[due] = append(w.scheduled.due[due], pos)
+}
+
+// tickScheduled runs the block ticks scheduled for the current tick and the ones
+// left behind when the age of the world jumps forward, the ticks of blocks in
+// chunks that aren't loaded are discarded.
+func (w *World) tickScheduled() {
+	age := w.Age()
+
+	w.scheduled.mutex.Lock()
+	var ages []int64
+	for due := range w.scheduled.due {
+		if due <= age {
+			ages = append(ages, due)
+		}
+	}
+	sort.Slice(ages, func(i, j int) bool { return ages[i] < ages[j] })
+	var positions []Position
+	for _, due := range ages {
+		positions = append(positions, w.scheduled.due[due]...)
+		delete(w.scheduled.due, due)
+	}
+	if len(positions) > maxScheduledTicks {
+		w.scheduled.due[age+1] = append(positions[maxScheduledTicks:], w.scheduled.due[age+1]...)
+		positions = positions[:maxScheduledTicks]
+	}
+	for _, pos := range positions {
+		delete(w.scheduled.pending, pos)
+	}
+	w.scheduled.mutex.Unlock()
+
+	for _, pos := range positions {
+		if w.loadedChunk(pos.X>>4, pos.Z>>4) == nil {
+			continue
+		}
+		state := w.GetBlock(pos.X, pos.Y, pos.Z)
+		if handler, ok := blockTickHandlers[state.Name()]; ok {
+			handler(w, pos, state)
+		}
+	}
+}
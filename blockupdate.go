@@ -0,0 +1,70 @@
+package MinecraftLightServer
+
+import "sync"
+
+// maxNeighborUpdates is the maximum number of neighbor updates caused by a single
+// block change, it stops infinite chains of updates.
+const maxNeighborUpdates = 65536
+
+// neighborUpdateHandler reacts to a change of a block next to pos or of pos itself.
+type neighborUpdateHandler func(w *World, pos Position, state BlockState)
+
+// neighborUpdateHandlers are the handlers of the blocks that react to changes around them, by block name.
+var neighborUpdateHandlers = make(map[string]neighborUpdateHandler)
+
+// Offsets of the neighbors of a block.
+var (
+	allDirections        = []Position{{0, -1, 0}, {0, 1, 0}, {0, 0, -1}, {0, 0, 1}, {-1, 0, 0}, {1, 0, 0}}
+	horizontalDirections = allDirections[2:]
+)
+
+// neighborUpdates are the pending block updates of a world, they're run in order
+// instead of recursively to avoid deep chains of calls.
+type neighborUpdates struct {
+	queue   []Position // blocks to update
+	running bool       // the queue is being processed
+	mutex   sync.Mutex // neighbor updates mutex
+}
+
+// UpdateNeighbors notifies the block at a position and its six neighbors that the block
+// at the position has changed. It's called by SetBlock.
+func (w *World) UpdateNeighbors(pos Position) {
+	w.updates.mutex.Lock()
+	w.updates.queue = append(w.updates.queue, pos)
+	for _, dir := range allDirections {
+		w.updates.queue = append(w.updates.queue, pos.offset(dir))
+	}
+	if w.updates.running {
+		// The updates are run by the caller that started the chain
+		w.updates.mutex.Unlock()
+		return
+	}
+	w.updates.running = true
+	w.updates.mutex.Unlock()
+
+	for count := 0; ; count++ {
+		w.updates.mutex.Lock()
+		if len(w.updates.queue) == 0 || count >= maxNeighborUpdates {
+			w.updates.queue, w.updates.running = nil, false
+			w.updates.mutex.Unlock()
+			return
+		}
+		next := w.updates.queue[0]
+		w.updates.queue = w.updates.queue[1:]
+		w.updates.mutex.Unlock()
+
+		w.updateBlock(next)
+	}
+}
+
+// updateBlock runs the neighbor update handler of a block, blocks in chunks
+// that aren't loaded aren't updated.
+func (w *World) updateBlock(pos Position) {
+	if pos.Y < 0 || pos.Y >= chunkHeight || w.loadedChunk(pos.X>>4, pos.Z>>4) == nil {
+		return
+	}
+	state := w.GetBlock(pos.X, pos.Y, pos.Z)
+	if handler, ok := neighborUpdateHandlers[state.Name()]; ok {
+		handler(w, pos, state)
+	}
+}
@@ -0,0 +1,233 @@
+package MinecraftLightServer
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Default world border settings, the same as vanilla.
+const (
+	defaultBorderDiameter        = 59999968 // diameter of the border in blocks
+	defaultBorderWarningTime     = 15       // seconds before a shrinking border reaches the player
+	defaultBorderWarningBlocks   = 5        // distance from the border when the warning starts
+	defaultBorderDamage          = 0.2      // damage per block outside the buffer
+	defaultBorderDamageBuffer    = 5        // distance outside the border without damage
+	borderPortalTeleportBoundary = 29999984 // maximum coordinate of portal destinations
+)
+
+// World border packet actions.
+const (
+	borderSetSize       = 0
+	borderLerpSize      = 1
+	borderSetCenter     = 2
+	borderInitialize    = 3
+	borderWarningTime   = 4
+	borderWarningBlocks = 5
+)
+
+// WorldBorder is the square border of a world, players can't go outside it.
+type WorldBorder struct {
+	centerX, centerZ float64      // center of the border
+	diameter         float64      // current side length
+	target           float64      // side length at the end of the current resize
+	remaining        int64        // ticks until the end of the current resize
+	warningTime      int          // warning time in seconds
+	warningBlocks    int          // warning distance in blocks
+	damagePerBlock   float64      // damage for each block outside the buffer
+	damageBuffer     float64      // distance outside the border without damage
+	world            *World       // world of the border, notified of changes
+	mutex            sync.RWMutex // border mutex
+}
+
+// newWorldBorder creates the default border of a world.
+func newWorldBorder(w *World) *WorldBorder {
+	return &WorldBorder{
+		diameter:       defaultBorderDiameter,
+		target:         defaultBorderDiameter,
+		warningTime:    defaultBorderWarningTime,
+		warningBlocks:  defaultBorderWarningBlocks,
+		damagePerBlock: defaultBorderDamage,
+		damageBuffer:   defaultBorderDamageBuffer,
+		world:          w,
+	}
+}
+
+// changed marks an action of the border as changed, it's sent to the players on the next tick.
+func (b *WorldBorder) changed(action int) {
+	b.world.changes.mutex.Lock()
+	b.world.changes.border |= 1 << action
+	b.world.changes.mutex.Unlock()
+}
+
+// Center returns the center of the border.
+func (b *WorldBorder) Center() (x, z float64) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.centerX, b.centerZ
+}
+
+// SetCenter moves the center of the border.
+func (b *WorldBorder) SetCenter(x, z float64) {
+	b.mutex.Lock()
+	b.centerX, b.centerZ = x, z
+	b.mutex.Unlock()
+	b.changed(borderSetCenter)
+}
+
+// Diameter returns the current side length of the border.
+func (b *WorldBorder) Diameter() float64 {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.diameter
+}
+
+// SetDiameter changes immediately the side length of the border.
+func (b *WorldBorder) SetDiameter(diameter float64) {
+	b.ResizeTo(diameter, 0)
+}
+
+// ResizeTo changes the side length of the border smoothly during the specified time.
+func (b *WorldBorder) ResizeTo(diameter float64, duration time.Duration) {
+	b.mutex.Lock()
+	b.target = math.Max(1, math.Min(diameter, defaultBorderDiameter))
+	b.remaining = int64(duration / tickRate)
+	if b.remaining <= 0 {
+		b.diameter, b.remaining = b.target, 0
+	}
+	b.mutex.Unlock()
+	b.changed(borderLerpSize)
+}
+
+// SetWarning changes when the players see the red warning: seconds before a shrinking
+// border reaches them and the distance from the border in blocks.
+func (b *WorldBorder) SetWarning(seconds, blocks int) {
+	b.mutex.Lock()
+	b.warningTime, b.warningBlocks = seconds, blocks
+	b.mutex.Unlock()
+	b.changed(borderWarningTime)
+	b.changed(borderWarningBlocks)
+}
+
+// SetDamage changes the damage per block of the players that are outside
+// the border by more than buffer blocks.
+func (b *WorldBorder) SetDamage(perBlock, buffer float64) {
+	b.mutex.Lock()
+	b.damagePerBlock, b.damageBuffer = perBlock, buffer
+	b.mutex.Unlock()
+}
+
+// Damage returns the damage dealt by the border to a player at the specified
+// coordinates, 0 if the player is inside the border or its buffer.
+func (b *WorldBorder) Damage(x, z float64) float64 {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	outside := b.distanceOutside(x, z) - b.damageBuffer
+	if outside <= 0 || b.damagePerBlock <= 0 {
+		return 0
+	}
+	return math.Max(1, math.Floor(outside*b.damagePerBlock))
+}
+
+// Contains checks if the specified coordinates are inside the border.
+func (b *WorldBorder) Contains(x, z float64) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.distanceOutside(x, z) <= 0
+}
+
+// distanceOutside returns how far the coordinates are outside the border,
+// a negative value if they're inside.
+func (b *WorldBorder) distanceOutside(x, z float64) float64 {
+	radius := b.diameter / 2
+	return math.Max(math.Abs(x-b.centerX), math.Abs(z-b.centerZ)) - radius
+}
+
+// clamp returns the nearest coordinates inside the border,
+// keeping a margin from the edges.
+func (b *WorldBorder) clamp(x, z, margin float64) (float64, float64) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	radius := math.Max(b.diameter/2-margin, 0)
+	x = math.Max(b.centerX-radius, math.Min(b.centerX+radius, x))
+	z = math.Max(b.centerZ-radius, math.Min(b.centerZ+radius, z))
+	return x, z
+}
+
+// tick advances the current resize of the border.
+func (b *WorldBorder) tick() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.remaining > 0 {
+		b.diameter += (b.target - b.diameter) / float64(b.remaining)
+		b.remaining--
+	}
+}
+
+// resizeTime returns the time until the end of the current resize in milliseconds.
+func (b *WorldBorder) resizeTime() VarLong {
+	return VarLong(b.remaining * int64(tickRate/time.Millisecond))
+}
+
+// writeWorldBorder sends the whole state of a world border to the client.
+func (p *Player) writeWorldBorder(b *WorldBorder) error {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return NewPacket(worldBorderPacketID,
+		VarInt(borderInitialize),             // action
+		Double(b.centerX), Double(b.centerZ), // center
+		Double(b.diameter), Double(b.target), // current and final diameter
+		b.resizeTime(),                       // resize time in milliseconds
+		VarInt(borderPortalTeleportBoundary), // portal teleport boundary
+		VarInt(b.warningTime),                // warning time in seconds
+		VarInt(b.warningBlocks),              // warning distance in blocks
+	).Pack(p.connection)
+}
+
+// writeWorldBorderActions sends the changed settings of a world border to the client,
+// actions is a bit mask of the border actions.
+func (p *Player) writeWorldBorderActions(b *WorldBorder, actions int) error {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for action := borderSetSize; action <= borderWarningBlocks; action++ {
+		if actions&(1<<action) == 0 {
+			continue
+		}
+
+		var packet *Packet
+		switch action {
+		case borderLerpSize:
+			if b.remaining == 0 {
+				// Resizes without a duration are immediate
+				packet = NewPacket(worldBorderPacketID, VarInt(borderSetSize), Double(b.diameter))
+			} else {
+				packet = NewPacket(worldBorderPacketID, VarInt(action), Double(b.diameter), Double(b.target), b.resizeTime())
+			}
+		case borderSetCenter:
+			packet = NewPacket(worldBorderPacketID, VarInt(action), Double(b.centerX), Double(b.centerZ))
+		case borderWarningTime:
+			packet = NewPacket(worldBorderPacketID, VarInt(action), VarInt(b.warningTime))
+		case borderWarningBlocks:
+			packet = NewPacket(worldBorderPacketID, VarInt(action), VarInt(b.warningBlocks))
+		default:
+			continue
+		}
+		if err := packet.Pack(p.connection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enforceBorder moves the player back inside the border of its world if it's outside.
+func (p *Player) enforceBorder() error {
+	border := p.World().Border()
+	if border.Contains(float64(p.x), float64(p.z)) {
+		return nil
+	}
+
+	x, z := border.clamp(float64(p.x), float64(p.z), 0.5)
+	p.x, p.z = Double(x), Double(z)
+	return p.writePlayerPosition(p.x, p.y, p.z, p.yawAbs, p.pitchAbs, Byte(0x00), VarInt(p.int32FromUUID()))
+}
@@ -0,0 +1,214 @@
+package MinecraftLightServer
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Chunk dimensions.
+const (
+	chunkSections     = 16           // vertical sections in a chunk
+	chunkHeight       = 16 * 16      // blocks from y 0 to the top of the world
+	sectionVolume     = 16 * 16 * 16 // blocks in a single section
+	directPaletteBits = 15           // bits per block of the global palette
+	maxPaletteBits    = 8            // maximum bits per block of a section palette
+	minPaletteBits    = 4            // minimum bits per block of a section palette
+)
+
+// Chunk is a 16x256x16 column of blocks of the world.
+type Chunk struct {
+	X, Z       int                          // chunk coordinates
+	sections   [chunkSections]*chunkSection // nil sections are only air
+	skyLight   [chunkSections]*nibbleArray  // sky light of each section, nil is dark
+	blockLight [chunkSections]*nibbleArray  // block light of each section, nil is dark
+	heightmaps [heightmapTypes][256]int     // height of each column (z, x order)
+	biomes     [biomeCells]int              // biome ID of each 4x4x4 cell (y, z, x order)
+	entities   map[int]*BlockEntity         // block entities by block index (y, z, x order)
+	mutex      sync.RWMutex                 // chunk data mutex
+}
+
+// chunkSection is a 16x16x16 cube of blocks.
+type chunkSection struct {
+	blocks [sectionVolume]BlockState // global state of each block (y, z, x order)
+	nonAir int                       // number of blocks that aren't air
+}
+
+// NewChunk creates a new empty chunk at the specified chunk coordinates.
+func NewChunk(x, z int) *Chunk {
+	c := &Chunk{X: x, Z: z}
+	for i := range c.biomes {
+		c.biomes[i] = defaultBiome
+	}
+	return c
+}
+
+// sectionIndex returns the index of a block inside a section
+// using coordinates relative to the section.
+func sectionIndex(x, y, z int) int {
+	return y<<8 | z<<4 | x
+}
+
+// GetBlock returns the block state at chunk relative coordinates.
+func (c *Chunk) GetBlock(x, y, z int) BlockState {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.getBlock(x, y, z)
+}
+
+// getBlock is GetBlock without locking.
+func (c *Chunk) getBlock(x, y, z int) BlockState {
+	if y < 0 || y >= chunkHeight {
+		return blockAir
+	}
+
+	section := c.sections[y>>4]
+	if section == nil {
+		return blockAir
+	}
+	return section.blocks[sectionIndex(x, y&15, z)]
+}
+
+// SetBlock changes the block state at chunk relative coordinates.
+func (c *Chunk) SetBlock(x, y, z int, state BlockState) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.setBlock(x, y, z, state)
+}
+
+// setBlock is SetBlock without locking, it returns the old block state.
+func (c *Chunk) setBlock(x, y, z int, state BlockState) BlockState {
+	if y < 0 || y >= chunkHeight {
+		return blockAir
+	}
+
+	section := c.sections[y>>4]
+	if section == nil {
+		if state == blockAir {
+			// Nothing to change
+			return blockAir
+		}
+		section = new(chunkSection)
+		c.sections[y>>4] = section
+	}
+
+	index := sectionIndex(x, y&15, z)
+	old := section.blocks[index]
+	section.blocks[index] = state
+
+	// Update non-air blocks counter
+	if old == blockAir && state != blockAir {
+		section.nonAir++
+	} else if old != blockAir && state == blockAir {
+		section.nonAir--
+	}
+
+	if old != state {
+		c.updateHeightmaps(x, y, z, state)
+
+		// Block entities belong to a block type
+		if len(c.entities) > 0 && blockRegistry.Name(old) != blockRegistry.Name(state) {
+			delete(c.entities, blockEntityIndex(x, y, z))
+		}
+	}
+	return old
+}
+
+// Fill sets every block of the chunk between y values minY and maxY (included).
+func (c *Chunk) Fill(minY, maxY int, state BlockState) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for y := minY; y <= maxY; y++ {
+		for z := 0; z < 16; z++ {
+			for x := 0; x < 16; x++ {
+				c.setBlock(x, y, z, state)
+			}
+		}
+	}
+}
+
+// sectionMask returns the bit mask of the non-empty sections.
+func (c *Chunk) sectionMask() VarInt {
+	var mask VarInt
+	for i, section := range c.sections {
+		if section != nil && section.nonAir > 0 {
+			mask |= 1 << i
+		}
+	}
+	return mask
+}
+
+// writeSections encodes the non-empty sections of the chunk
+// (in the same order of the section mask) to w.
+func (c *Chunk) writeSections(w io.Writer) error {
+	for _, section := range c.sections {
+		if section != nil && section.nonAir > 0 {
+			if _, err := section.WriteTo(w); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteTo encodes a chunk section using the smallest palette possible.
+func (s *chunkSection) WriteTo(w io.Writer) (n int64, err error) {
+	// Build section palette
+	var palette []BlockState
+	paletteIndex := make(map[BlockState]int)
+	for _, state := range s.blocks {
+		if _, ok := paletteIndex[state]; !ok {
+			paletteIndex[state] = len(palette)
+			palette = append(palette, state)
+		}
+	}
+
+	// Calculate bits per block
+	bits := minPaletteBits
+	for 1<<bits < len(palette) {
+		bits++
+	}
+	if bits > maxPaletteBits {
+		bits = directPaletteBits
+	}
+
+	data := new(bytes.Buffer)
+	_, _ = Short(s.nonAir).WriteTo(data)    // non-air blocks
+	_, _ = UnsignedByte(bits).WriteTo(data) // bits per block
+	if bits != directPaletteBits {
+		_, _ = VarInt(len(palette)).WriteTo(data) // palette length
+		for _, state := range palette {
+			_, _ = VarInt(state).WriteTo(data)
+		}
+	}
+
+	// Pack blocks into longs
+	values := make([]int, sectionVolume)
+	for i, state := range s.blocks {
+		if bits != directPaletteBits {
+			values[i] = paletteIndex[state]
+		} else {
+			values[i] = int(state)
+		}
+	}
+	longs := packLongs(values, bits)
+
+	_, _ = VarInt(len(longs)).WriteTo(data) // data array length
+	for _, l := range longs {
+		_, _ = Long(l).WriteTo(data)
+	}
+
+	return data.WriteTo(w)
+}
+
+// packLongs packs values using bits for each value, without
+// spanning values over multiple longs.
+func packLongs(values []int, bits int) []int64 {
+	perLong := 64 / bits
+	longs := make([]int64, (len(values)+perLong-1)/perLong)
+	for i, value := range values {
+		longs[i/perLong] |= int64(uint64(value) << uint((i%perLong)*bits))
+	}
+	return longs
+}
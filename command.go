@@ -0,0 +1,380 @@
+package MinecraftLightServer
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schematicsDirectory is the directory of the files used by the schem command.
+const schematicsDirectory = "schematics"
+
+// CommandHandler executes a command sent by a player, args are the words
+// that follow the command name. The returned message is sent to the player.
+type CommandHandler func(s *Server, p *Player, args []string) (string, error)
+
+// command is a command that the players can send in chat.
+type command struct {
+	usage    string         // arguments description
+	handler  CommandHandler // function that executes the command
+	operator bool           // only the operators can use the command
+}
+
+// RegisterCommand adds a command that players can use by sending "/name" in chat,
+// usage describes its arguments. It replaces the command with the same name.
+func (s *Server) RegisterCommand(name, usage string, handler CommandHandler) {
+	s.registerCommand(name, &command{usage: usage, handler: handler})
+}
+
+// RegisterOperatorCommand adds a command that only the operators can use, like RegisterCommand.
+func (s *Server) RegisterOperatorCommand(name, usage string, handler CommandHandler) {
+	s.registerCommand(name, &command{usage: usage, handler: handler, operator: true})
+}
+
+// registerCommand adds a command to the server, replacing the command with the same name.
+func (s *Server) registerCommand(name string, cmd *command) {
+	s.commands.mutex.Lock()
+	defer s.commands.mutex.Unlock()
+	if s.commands.handlers == nil {
+		s.commands.handlers = make(map[string]*command)
+	}
+	s.commands.handlers[strings.ToLower(name)] = cmd
+}
+
+// registerBuiltinCommands adds the commands available by default, the ones that change
+// the worlds or the game mode can only be used by the operators.
+func (s *Server) registerBuiltinCommands() {
+	s.RegisterCommand("help", "", helpCommand)
+	s.RegisterOperatorCommand("time", "set <ticks|day|noon|night|midnight> | add <ticks> | query <daytime|gametime|day>", timeCommand)
+	s.RegisterOperatorCommand("gamerule", "<rule> [<value>]", gameRuleCommand)
+	s.RegisterOperatorCommand("setworldspawn", "[<x> <y> <z>]", setWorldSpawnCommand)
+	s.RegisterOperatorCommand("spawnpoint", "[<x> <y> <z>]", spawnPointCommand)
+	s.RegisterOperatorCommand("gamemode", "<survival|creative|adventure|spectator>", gameModeCommand)
+	s.RegisterOperatorCommand("weather", "<clear|rain|thunder> [<seconds>] | query", weatherCommand)
+	s.RegisterOperatorCommand("schem", "load <name> [<x> <y> <z>] | save <name> <x1> <y1> <z1> <x2> <y2> <z2>", schemCommand)
+}
+
+// SetOperator allows or forbids a player to use the operator commands, by username.
+// There aren't operators by default.
+func (s *Server) SetOperator(username string, operator bool) {
+	s.operators.mutex.Lock()
+	defer s.operators.mutex.Unlock()
+	if s.operators.names == nil {
+		s.operators.names = make(map[string]bool)
+	}
+	if operator {
+		s.operators.names[strings.ToLower(username)] = true
+	} else {
+		delete(s.operators.names, strings.ToLower(username))
+	}
+}
+
+// IsOperator checks if a player can use the operator commands.
+func (s *Server) IsOperator(p *Player) bool {
+	s.operators.mutex.RLock()
+	defer s.operators.mutex.RUnlock()
+	return s.operators.names[strings.ToLower(string(p.username))]
+}
+
+// executeCommand runs a command line sent by a player (without the leading slash)
+// and sends the result to the player.
+func (s *Server) executeCommand(p *Player, line string) error {
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		return nil
+	}
+
+	s.commands.mutex.RLock()
+	cmd, ok := s.commands.handlers[strings.ToLower(args[0])]
+	s.commands.mutex.RUnlock()
+	if !ok {
+		return p.writeSystemMessage("Unknown command: "+args[0], "red")
+	}
+	if cmd.operator && !s.IsOperator(p) {
+		return p.writeSystemMessage("You don't have permission to use this command", "red")
+	}
+
+	result, err := cmd.handler(s, p, args[1:])
+	if err != nil {
+		return p.writeSystemMessage(err.Error(), "red")
+	}
+	if result != "" {
+		return p.writeSystemMessage(result, "")
+	}
+	return nil
+}
+
+// helpCommand lists the commands that the player can use.
+func helpCommand(s *Server, p *Player, args []string) (string, error) {
+	operator := s.IsOperator(p)
+	s.commands.mutex.RLock()
+	defer s.commands.mutex.RUnlock()
+
+	names := make([]string, 0, len(s.commands.handlers))
+	for name, cmd := range s.commands.handlers {
+		if operator || !cmd.operator {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, strings.TrimSpace("/"+name+" "+s.commands.handlers[name].usage))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// schemCommand loads and saves schematics in the schematics directory.
+func schemCommand(s *Server, p *Player, args []string) (string, error) {
+	if len(args) < 2 {
+		return "", errors.New("usage: /schem load <name> [<x> <y> <z>] | save <name> <x1> <y1> <z1> <x2> <y2> <z2>")
+	}
+
+	// Only files inside the schematics directory can be used
+	name := filepath.Base(args[1])
+	path := filepath.Join(schematicsDirectory, name)
+
+	switch args[0] {
+	case "load":
+		pos := p.blockPosition()
+		if len(args) == 5 {
+			var err error
+			if pos, err = parsePosition(p, args[2:5]); err != nil {
+				return "", err
+			}
+		} else if len(args) != 2 {
+			return "", errors.New("usage: /schem load <name> [<x> <y> <z>]")
+		}
+
+		// The extension is optional
+		if filepath.Ext(path) == "" {
+			for _, ext := range []string{".schem", ".schematic"} {
+				if _, err := os.Stat(path + ext); err == nil {
+					path += ext
+					break
+				}
+			}
+		}
+
+		schematic, err := LoadSchematicFile(path)
+		if err != nil {
+			return "", err
+		}
+		schematic.Paste(p.World(), pos)
+		result := "Pasted " + filepath.Base(path) + " at " + pos.String()
+		if schematic.Unknown > 0 {
+			result += " (" + strconv.Itoa(schematic.Unknown) + " unknown blocks replaced by air)"
+		}
+		return result, nil
+
+	case "save":
+		if len(args) != 8 {
+			return "", errors.New("usage: /schem save <name> <x1> <y1> <z1> <x2> <y2> <z2>")
+		}
+		from, err := parsePosition(p, args[2:5])
+		if err != nil {
+			return "", err
+		}
+		to, err := parsePosition(p, args[5:8])
+		if err != nil {
+			return "", err
+		}
+
+		schematic, err := CopySchematic(p.World(), from, to)
+		if err != nil {
+			return "", err
+		}
+		if filepath.Ext(path) == "" {
+			path += ".schem"
+		}
+		if err := os.MkdirAll(schematicsDirectory, 0755); err != nil {
+			return "", err
+		}
+		if err := schematic.SaveFile(path); err != nil {
+			return "", err
+		}
+		return "Saved " + filepath.Base(path), nil
+	}
+	return "", errors.New("unknown schem action: " + args[0])
+}
+
+// timeCommand changes and shows the time of the world of the player.
+func timeCommand(s *Server, p *Player, args []string) (string, error) {
+	w := p.World()
+	if len(args) != 2 {
+		return "", errors.New("usage: /time set <ticks|day|noon|night|midnight> | add <ticks> | query <daytime|gametime|day>")
+	}
+
+	switch args[0] {
+	case "set", "add":
+		ticks, ok := namedTimes[args[1]]
+		if !ok || args[0] == "add" {
+			var err error
+			if ticks, err = strconv.ParseInt(args[1], 10, 64); err != nil || ticks < 0 {
+				return "", errors.New("invalid time: " + args[1])
+			}
+		}
+		if args[0] == "add" {
+			w.AddTime(ticks)
+			return "Added " + strconv.FormatInt(ticks, 10) + " to the time", nil
+		}
+		w.SetTime(ticks)
+		return "Set the time to " + strconv.FormatInt(w.Time(), 10), nil
+
+	case "query":
+		switch args[1] {
+		case "daytime":
+			return "The time is " + strconv.FormatInt(w.Time()%dayLength, 10), nil
+		case "gametime":
+			return "The time is " + strconv.FormatInt(w.Age(), 10), nil
+		case "day":
+			return "The time is " + strconv.FormatInt(w.Time()/dayLength, 10), nil
+		}
+	}
+	return "", errors.New("unknown time action: " + strings.Join(args, " "))
+}
+
+// gameRuleCommand changes and shows the game rules of the world of the player.
+func gameRuleCommand(s *Server, p *Player, args []string) (string, error) {
+	rules := p.World().GameRules()
+	switch len(args) {
+	case 1:
+		value, err := rules.Get(args[0])
+		if err != nil {
+			return "", err
+		}
+		return "Game rule " + args[0] + " is " + value, nil
+	case 2:
+		if err := rules.Set(args[0], args[1]); err != nil {
+			return "", err
+		}
+		value, _ := rules.Get(args[0])
+		return "Game rule " + args[0] + " is now " + value, nil
+	}
+	return "", errors.New("usage: /gamerule <rule> [<value>], rules: " + strings.Join(GameRuleNames(), ", "))
+}
+
+// setWorldSpawnCommand changes the spawn point of the world of the player,
+// the default position is the one of the player.
+func setWorldSpawnCommand(s *Server, p *Player, args []string) (string, error) {
+	pos, err := commandPosition(p, args)
+	if err != nil {
+		return "", err
+	}
+	p.World().SetSpawn(pos)
+	return "Set the world spawn point to " + pos.String(), nil
+}
+
+// spawnPointCommand changes the respawn point of the player,
+// the default position is the one of the player.
+func spawnPointCommand(s *Server, p *Player, args []string) (string, error) {
+	pos, err := commandPosition(p, args)
+	if err != nil {
+		return "", err
+	}
+	p.SetRespawnPoint(p.World(), pos)
+	return "Set the spawn point of " + string(p.username) + " to " + pos.String(), nil
+}
+
+// weatherCommand changes and shows the weather of the world of the player.
+func weatherCommand(s *Server, p *Player, args []string) (string, error) {
+	w := p.World()
+	if len(args) < 1 || len(args) > 2 {
+		return "", errors.New("usage: /weather <clear|rain|thunder> [<seconds>] | query")
+	}
+	if args[0] == "query" {
+		return "The weather is " + w.Weather().String(), nil
+	}
+
+	weather, err := ParseWeather(args[0])
+	if err != nil {
+		return "", err
+	}
+	var duration time.Duration
+	if len(args) == 2 {
+		seconds, err := strconv.Atoi(args[1])
+		if err != nil || seconds <= 0 {
+			return "", errors.New("invalid duration: " + args[1])
+		}
+		duration = time.Duration(seconds) * time.Second
+	}
+	if !w.hasWeather() {
+		return "", errors.New("this world has no weather")
+	}
+	w.SetWeather(weather, duration)
+	return "Set the weather to " + weather.String(), nil
+}
+
+// gameModeCommand changes the game mode of the player.
+func gameModeCommand(s *Server, p *Player, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("usage: /gamemode <survival|creative|adventure|spectator>")
+	}
+	mode, err := ParseGameMode(args[0])
+	if err != nil {
+		return "", err
+	}
+	if err := p.SetGameMode(mode); err != nil {
+		return "", err
+	}
+
+	// The tab list shows the game modes
+	s.broadcastPlayerInfo()
+	return "Set own game mode to " + mode.String(), nil
+}
+
+// parseCoordinate parses a command coordinate, "~" is relative to base.
+func parseCoordinate(arg string, base float64) (int, error) {
+	if strings.HasPrefix(arg, "~") {
+		offset := 0
+		if len(arg) > 1 {
+			var err error
+			if offset, err = strconv.Atoi(arg[1:]); err != nil {
+				return 0, errors.New("invalid coordinate: " + arg)
+			}
+		}
+		return int(math.Floor(base)) + offset, nil
+	}
+
+	value, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, errors.New("invalid coordinate: " + arg)
+	}
+	return value, nil
+}
+
+// parsePosition parses the three coordinates of a block position,
+// relative coordinates use the position of the player.
+func parsePosition(p *Player, args []string) (pos Position, err error) {
+	if pos.X, err = parseCoordinate(args[0], float64(p.x)); err != nil {
+		return
+	}
+	if pos.Y, err = parseCoordinate(args[1], float64(p.y)); err != nil {
+		return
+	}
+	pos.Z, err = parseCoordinate(args[2], float64(p.z))
+	return
+}
+
+// commandPosition parses the optional position argument of a command,
+// the default one is the position of the player.
+func commandPosition(p *Player, args []string) (Position, error) {
+	switch len(args) {
+	case 0:
+		return p.blockPosition(), nil
+	case 3:
+		return parsePosition(p, args)
+	}
+	return Position{}, errors.New("expected three coordinates or none")
+}
+
+// blockPosition returns the position of the block where the player is.
+func (p *Player) blockPosition() Position {
+	return Position{int(math.Floor(float64(p.x))), int(math.Floor(float64(p.y))), int(math.Floor(float64(p.z)))}
+}
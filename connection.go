@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"io"
 	"net"
+	"strings"
 )
 
 // listen starts listening for minecraft clients and
@@ -52,9 +54,6 @@ func (s *Server) newPlayer(conn net.Conn) {
 		connection: conn,
 		id:         UUID(uuid.New()),
 		isDeleted:  false,
-		x:          0,
-		y:          5,
-		z:          0,
 		yawAbs:     0,
 		pitchAbs:   0,
 		pitch:      0,
@@ -133,8 +132,12 @@ func (s *Server) newPlayer(conn net.Conn) {
 		}
 	}
 
-	// Set Player initial parameters
-	if err := current.writeJoinGame(); err != nil {
+	// Set Player initial parameters, new players spawn around the spawn point
+	current.chunks.world = s.World()
+	spawn := current.World().spawnLocation()
+	current.x, current.y, current.z = Double(spawn.X)+0.5, Double(spawn.Y), Double(spawn.Z)+0.5
+	current.resetHealth()
+	if err := current.writeJoinGame(s.Worlds(), s.ViewDistance()); err != nil {
 		s.removePlayerAndExit(&current, err)
 	}
 	if err := current.writePlayerPosition(
@@ -143,16 +146,40 @@ func (s *Server) newPlayer(conn net.Conn) {
 		Byte(0x00), VarInt(current.int32FromUUID())); err != nil {
 		s.removePlayerAndExit(&current, err)
 	}
-	if err := current.writeServerDifficulty(); err != nil {
+	if err := current.writeServerDifficulty(current.World()); err != nil {
+		s.removePlayerAndExit(&current, err)
+	}
+	if err := current.writeSpawnPosition(current.World()); err != nil {
+		s.removePlayerAndExit(&current, err)
+	}
+	if err := current.writeWorldBorder(current.World().Border()); err != nil {
+		s.removePlayerAndExit(&current, err)
+	}
+	if err := current.writeTimeUpdate(current.World()); err != nil {
+		s.removePlayerAndExit(&current, err)
+	}
+	if err := current.writeWeather(current.World(), true); err != nil {
+		s.removePlayerAndExit(&current, err)
+	}
+	if err := current.writeWindowItems(); err != nil {
+		s.removePlayerAndExit(&current, err)
+	}
+	if err := current.SetHeldSlot(current.inventory.HeldSlot()); err != nil {
+		s.removePlayerAndExit(&current, err)
+	}
+	if err := current.writeUpdateHealth(); err != nil {
+		s.removePlayerAndExit(&current, err)
+	}
+	if err := current.writeRecipeBook(); err != nil {
 		s.removePlayerAndExit(&current, err)
 	}
 
-	// Send 4 chunks to client
-	chunks := [][]Int{{-1, 0}, {0, 0}, {-1, -1}, {0, -1}}
-	for _, position := range chunks {
-		if err := current.writeChunk(position[0], position[1]); err != nil {
-			s.removePlayerAndExit(&current, err)
-		}
+	// Queue chunks around the player, the tick loop sends them
+	if err := current.updateViewPosition(); err != nil {
+		s.removePlayerAndExit(&current, err)
+	}
+	if err := current.updateChunks(s.ViewDistance()); err != nil {
+		s.removePlayerAndExit(&current, err)
 	}
 
 	// Send current player information to other connected clients
@@ -187,15 +214,164 @@ func (s *Server) handlePacket(p *Player) {
 				if _, err := message.ReadFrom(packet); err != nil {
 					s.removePlayerAndExit(p, err)
 				}
-				s.broadcastChatMessage(string(message), string(p.username))
 
-			case readKeepAlivePacketID:
+				// Messages that start with a slash are commands
+				if strings.HasPrefix(string(message), "/") {
+					if err := s.executeCommand(p, string(message[1:])); err != nil {
+						s.removePlayerAndExit(p, err)
+					}
+				} else {
+					s.broadcastChatMessage(string(message), string(p.username))
+				}
+
+			case readClientStatusPacketID:
+				var action VarInt
+				if _, err := action.ReadFrom(packet); err != nil {
+					s.removePlayerAndExit(p, err)
+				}
+				if action == clientStatusRespawn {
+					if err := p.performRespawn(s); err != nil {
+						s.removePlayerAndExit(p, err)
+					}
+				}
+
+			case readInteractEntityPacketID:
+				var target, kind VarInt
+				for _, field := range []io.ReaderFrom{&target, &kind} {
+					if _, err := field.ReadFrom(packet); err != nil {
+						s.removePlayerAndExit(p, err)
+					}
+				}
+				if kind == interactAttack {
+					p.attack(s.playerByEntityID(int32(target)))
+				}
+
+			case readKeepAlivePacketID, readWindowConfirmationPacketID:
 				// Do nothing
 
+			case readClickWindowPacketID:
+				var window UnsignedByte
+				var slot, action Short
+				var button Byte
+				var mode VarInt
+				var clicked Slot
+				for _, field := range []io.ReaderFrom{&window, &slot, &button, &action, &mode, &clicked} {
+					if _, err := field.ReadFrom(packet); err != nil {
+						s.removePlayerAndExit(p, err)
+					}
+				}
+
+				// The server runs the click too, the client is synchronized again if they don't agree.
+				var expected Slot
+				var dropped []Slot
+				var ok bool
+				if p.IsDead() {
+					// Dead players can't move items, the client is synchronized again
+				} else if window == playerWindowID {
+					expected, dropped, ok = p.clickInventory(int(slot), int(button), int(mode))
+				} else {
+					id, c := p.OpenContainer()
+					if c == nil || int(window) != id {
+						break
+					}
+					if c.valid != nil && !c.valid(p) {
+						if err := p.CloseWindow(); err != nil {
+							s.removePlayerAndExit(p, err)
+						}
+						break
+					}
+					if c.allowClick(p, int(slot), int(button), int(mode)) {
+						expected, dropped, ok = c.click(p, int(slot), int(button), int(mode))
+					}
+				}
+				p.dropItems(dropped)
+				accepted := ok && expected.Equals(clicked)
+				if err := p.writeWindowConfirmation(int(window), int(action), accepted); err != nil {
+					s.removePlayerAndExit(p, err)
+				}
+				if !accepted {
+					if err := p.writeOpenWindowItems(); err != nil {
+						s.removePlayerAndExit(p, err)
+					}
+				}
+
+			case readCloseWindowPacketID:
+				var window UnsignedByte
+				if _, err := window.ReadFrom(packet); err != nil {
+					s.removePlayerAndExit(p, err)
+				}
+				if window == playerWindowID {
+					p.dropItems(p.inventory.close())
+					if err := p.writeWindowItems(); err != nil {
+						s.removePlayerAndExit(p, err)
+					}
+				} else {
+					p.dropItems(p.closeWindow(int(window)))
+				}
+
+			case readCraftRecipeRequestPacketID:
+				var window Byte
+				var recipe String
+				var all Boolean
+				for _, field := range []io.ReaderFrom{&window, &recipe, &all} {
+					if _, err := field.ReadFrom(packet); err != nil {
+						s.removePlayerAndExit(p, err)
+					}
+				}
+				if err := p.craftRecipe(int(window), string(recipe), bool(all)); err != nil {
+					s.removePlayerAndExit(p, err)
+				}
+
+			case readRecipeBookStatePacketID:
+				var book VarInt
+				var open, filter Boolean
+				for _, field := range []io.ReaderFrom{&book, &open, &filter} {
+					if _, err := field.ReadFrom(packet); err != nil {
+						s.removePlayerAndExit(p, err)
+					}
+				}
+				p.setRecipeBookState(int(book), bool(open), bool(filter))
+
+			case readDisplayedRecipePacketID:
+				// The recipes aren't highlighted, there's nothing to change
+
+			case readHeldItemChangePacketID:
+				var slot Short
+				if _, err := slot.ReadFrom(packet); err != nil {
+					s.removePlayerAndExit(p, err)
+				}
+				p.inventory.setHeld(int(slot))
+
+			case readCreativeActionPacketID:
+				var slot Short
+				var item Slot
+				if _, err := slot.ReadFrom(packet); err != nil {
+					s.removePlayerAndExit(p, err)
+				}
+				if _, err := item.ReadFrom(packet); err != nil {
+					s.removePlayerAndExit(p, err)
+				}
+
+				// Items dropped from the creative inventory are thrown
+				if slot == cursorSlot {
+					if p.GameMode() == GameModeCreative && item.isValid() {
+						p.throwCreative(item)
+					}
+					break
+				}
+
+				// Only players in creative mode can take any item, the others get their slot back
+				if p.GameMode() == GameModeCreative && p.inventory.setCreative(int(slot), item) {
+					break
+				}
+				if err := p.writeSetSlot(playerWindowID, int(slot), p.inventory.Slot(int(slot))); err != nil {
+					s.removePlayerAndExit(p, err)
+				}
+
 			case readPositionPacketID:
-				// Old position
-				oldX := p.x
-				oldZ := p.z
+				// Old chunk and position
+				oldChunk := p.chunkPosition()
+				oldX, oldY, oldZ, wasOnGround := float64(p.x), float64(p.y), float64(p.z), bool(p.onGround)
 
 				if _, err := p.x.ReadFrom(packet); err != nil {
 					s.removePlayerAndExit(p, err)
@@ -210,20 +386,36 @@ func (s *Server) handlePacket(p *Player) {
 					s.removePlayerAndExit(p, err)
 				}
 
+				// Players can't go outside the world border
+				if err := p.enforceBorder(); err != nil {
+					s.removePlayerAndExit(p, err)
+				}
+
+				// Players on the ground press the pressure plates, dead players don't
+				if bool(p.onGround) && !p.IsDead() {
+					p.World().stepOn(p.blockPosition())
+				}
+
+				// Falls hurt the players and moving makes them hungry
+				p.moved(oldX, oldY, oldZ, wasOnGround)
+
 				// Update player chunk view if chunk has changed
-				if p.z != oldZ || coordinateToChunk(p.x) != coordinateToChunk(oldX) {
+				if p.chunkPosition() != oldChunk {
 					if err := p.updateViewPosition(); err != nil {
 						s.removePlayerAndExit(p, err)
 					}
+					if err := p.updateChunks(s.ViewDistance()); err != nil {
+						s.removePlayerAndExit(p, err)
+					}
 				}
 
 				// Send to other players
 				s.broadcastPlayerPosAndLook(VarInt(p.int32FromUUID()), p.x, p.y, p.z, p.yaw, p.pitch, p.onGround)
 
 			case readPositionAndLookPacketID:
-				// Old position
-				oldX := p.x
-				oldZ := p.z
+				// Old chunk and position
+				oldChunk := p.chunkPosition()
+				oldX, oldY, oldZ, wasOnGround := float64(p.x), float64(p.y), float64(p.z), bool(p.onGround)
 
 				if _, err := p.x.ReadFrom(packet); err != nil {
 					s.removePlayerAndExit(p, err)
@@ -248,11 +440,27 @@ func (s *Server) handlePacket(p *Player) {
 				p.yaw = p.yawAbs.toAngle()
 				p.pitch = p.pitchAbs.toAngle()
 
+				// Players can't go outside the world border
+				if err := p.enforceBorder(); err != nil {
+					s.removePlayerAndExit(p, err)
+				}
+
+				// Players on the ground press the pressure plates, dead players don't
+				if bool(p.onGround) && !p.IsDead() {
+					p.World().stepOn(p.blockPosition())
+				}
+
+				// Falls hurt the players and moving makes them hungry
+				p.moved(oldX, oldY, oldZ, wasOnGround)
+
 				// Update player chunk view if chunk has changed
-				if p.z != oldZ || coordinateToChunk(p.x) != coordinateToChunk(oldX) {
+				if p.chunkPosition() != oldChunk {
 					if err := p.updateViewPosition(); err != nil {
 						s.removePlayerAndExit(p, err)
 					}
+					if err := p.updateChunks(s.ViewDistance()); err != nil {
+						s.removePlayerAndExit(p, err)
+					}
 				}
 
 				// Send to other players
@@ -276,6 +484,19 @@ func (s *Server) handlePacket(p *Player) {
 				// Send to other players
 				s.broadcastPlayerRotation(VarInt(p.int32FromUUID()), p.yaw, p.pitch, p.onGround)
 
+			case readPlayerDiggingPacketID:
+				var status VarInt
+				var pos Position
+				var face Byte
+				for _, field := range []io.ReaderFrom{&status, &pos, &face} {
+					if _, err := field.ReadFrom(packet); err != nil {
+						s.removePlayerAndExit(p, err)
+					}
+				}
+				if err := p.dig(int(status), pos); err != nil {
+					s.removePlayerAndExit(p, err)
+				}
+
 			case readEntityActionPacketID:
 				// Discard Entity ID
 				_, _ = new(VarInt).ReadFrom(packet)
@@ -284,8 +505,58 @@ func (s *Server) handlePacket(p *Player) {
 				if _, err := actionID.ReadFrom(packet); err != nil {
 					s.removePlayerAndExit(p, err)
 				}
+				if actionID == entityActionStartSprinting || actionID == entityActionStopSprinting {
+					p.setSprinting(actionID == entityActionStartSprinting)
+				}
 				s.broadcastEntityAction(VarInt(p.int32FromUUID()), actionID)
 
+			case readUpdateSignPacketID:
+				var pos Position
+				var lines [4]string
+				if _, err := pos.ReadFrom(packet); err != nil {
+					s.removePlayerAndExit(p, err)
+				}
+				for i := range lines {
+					var line String
+					if _, err := line.ReadFrom(packet); err != nil {
+						s.removePlayerAndExit(p, err)
+					}
+					lines[i] = string(line)
+				}
+
+				// Only the sign opened in the editor can be changed
+				if p.editingSign == nil || *p.editingSign != pos {
+					break
+				}
+				p.editingSign = nil
+				if sign := p.World().BlockEntity(pos.X, pos.Y, pos.Z); sign.isSign() {
+					sign = sign.Clone()
+					sign.SetSignText(lines)
+					p.World().SetBlockEntity(pos.X, pos.Y, pos.Z, sign)
+				}
+
+			case readBlockPlacementPacketID:
+				var hand, face VarInt
+				var pos Position
+				var cursorX, cursorY, cursorZ Float
+				var inside Boolean
+				for _, field := range []io.ReaderFrom{&hand, &pos, &face, &cursorX, &cursorY, &cursorZ, &inside} {
+					if _, err := field.ReadFrom(packet); err != nil {
+						s.removePlayerAndExit(p, err)
+					}
+				}
+
+				// Containers, levers, buttons and doors react to the main hand
+				if hand == 0 && p.canInteract(pos) {
+					opened, err := p.openBlockWindow(pos)
+					if err != nil {
+						s.removePlayerAndExit(p, err)
+					}
+					if !opened {
+						p.World().UseBlock(pos)
+					}
+				}
+
 			case readAnimationPacketID:
 				var animationID VarInt
 				if _, err := animationID.ReadFrom(packet); err != nil {
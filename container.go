@@ -0,0 +1,412 @@
+package MinecraftLightServer
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// Container window settings.
+const (
+	maxWindowID       = 100 // window IDs go from 1 to 100
+	maxUseDistance    = 8   // players farther than this from a container block can't use it
+	craftingInputSlot = 1   // first slot of the 3x3 grid of the crafting table
+)
+
+// ContainerClickHandler is called when a player clicks a slot of a container window,
+// before the click is run. The click is cancelled if it returns false.
+type ContainerClickHandler func(p *Player, slot, button, mode int) bool
+
+// Container is a list of slots that players can open in a window. It can belong
+// to a block, like a chest, or be a menu built by a plugin from items.
+type Container struct {
+	windowType   WindowType            // type of the window that shows the container
+	title        string                // title of the window
+	slots        []Slot                // items of the container
+	viewers      map[*Player]int       // players that have opened the container, with their window IDs
+	clickHandler ContainerClickHandler // called before the clicks, nil if they're always allowed
+	closeHandler func(p *Player)       // called when a player closes the window
+	valid        func(p *Player) bool  // checks if a player can still use the container, nil if always
+	furnace      *furnace              // fuel and cooking progress, nil if the container isn't a furnace
+	mutex        sync.Mutex            // container mutex
+}
+
+// NewContainer creates an empty container shown in a window of the specified type and title.
+func NewContainer(windowType WindowType, title string) (*Container, error) {
+	layout, ok := windowLayouts[windowType]
+	if !ok {
+		return nil, errors.New("unsupported window type: " + strconv.Itoa(int(windowType)))
+	}
+	return &Container{
+		windowType: windowType,
+		title:      title,
+		slots:      make([]Slot, layout.size),
+		viewers:    make(map[*Player]int),
+	}, nil
+}
+
+// Type returns the type of the window that shows the container.
+func (c *Container) Type() WindowType {
+	return c.windowType
+}
+
+// Title returns the title of the window.
+func (c *Container) Title() string {
+	return c.title
+}
+
+// Size returns the number of slots of the container.
+func (c *Container) Size() int {
+	return len(c.slots)
+}
+
+// Slot returns the item in a slot of the container.
+func (c *Container) Slot(slot int) Slot {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if slot < 0 || slot >= len(c.slots) {
+		return Slot{}
+	}
+	return c.slots[slot]
+}
+
+// SetSlot changes a slot of the container and sends it to the players that opened it.
+func (c *Container) SetSlot(slot int, item Slot) {
+	c.mutex.Lock()
+	if slot < 0 || slot >= len(c.slots) {
+		c.mutex.Unlock()
+		return
+	}
+	c.slots[slot] = item
+	viewers := c.viewersLocked()
+	c.mutex.Unlock()
+
+	for p, window := range viewers {
+		_ = p.writeSetSlot(window, slot, item)
+	}
+}
+
+// OnClick sets the handler called before the clicks of the players, to build menus.
+func (c *Container) OnClick(handler ContainerClickHandler) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.clickHandler = handler
+}
+
+// OnClose sets the handler called when a player closes the window of the container.
+func (c *Container) OnClose(handler func(p *Player)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.closeHandler = handler
+}
+
+// allowClick calls the click handler of the container, it returns false if the click is cancelled.
+func (c *Container) allowClick(p *Player, slot, button, mode int) bool {
+	c.mutex.Lock()
+	handler := c.clickHandler
+	c.mutex.Unlock()
+	return handler == nil || handler(p, slot, button, mode)
+}
+
+// Viewers returns the players that have opened the container.
+func (c *Container) Viewers() []*Player {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	players := make([]*Player, 0, len(c.viewers))
+	for p := range c.viewers {
+		players = append(players, p)
+	}
+	return players
+}
+
+// viewersLocked returns a copy of the viewers, the container must be locked.
+func (c *Container) viewersLocked() map[*Player]int {
+	viewers := make(map[*Player]int, len(c.viewers))
+	for p, window := range c.viewers {
+		viewers[p] = window
+	}
+	return viewers
+}
+
+// click runs a Click Window action of a player on the container, see windowView.click.
+// It also sends the changed slots of the container to the other players that opened it.
+func (c *Container) click(p *Player, slot, button, mode int) (expected Slot, dropped []Slot, ok bool) {
+	c.mutex.Lock()
+	before := append([]Slot(nil), c.slots...)
+	p.inventory.mutex.Lock()
+	expected, dropped, ok = p.windowView(c).click(slot, button, mode, p.GameMode() == GameModeCreative)
+	p.inventory.mutex.Unlock()
+
+	type change struct {
+		slot int
+		item Slot
+	}
+	var changes []change
+	for i, item := range c.slots {
+		if !item.Equals(before[i]) {
+			changes = append(changes, change{i, item})
+		}
+	}
+	viewers := c.viewersLocked()
+	c.mutex.Unlock()
+
+	delete(viewers, p)
+	for viewer, window := range viewers {
+		for _, change := range changes {
+			_ = viewer.writeSetSlot(window, change.slot, change.item)
+		}
+	}
+	return expected, dropped, ok
+}
+
+// OpenWindow shows a container to the player, closing the window already open.
+func (p *Player) OpenWindow(c *Container) error {
+	if err := p.CloseWindow(); err != nil {
+		return err
+	}
+
+	p.window.mutex.Lock()
+	p.window.counter = p.window.counter%maxWindowID + 1
+	window := p.window.counter
+	p.window.id, p.window.container = window, c
+	p.window.mutex.Unlock()
+
+	c.mutex.Lock()
+	c.viewers[p] = window
+	c.mutex.Unlock()
+
+	if err := NewPacket(openWindowPacketID, VarInt(window), VarInt(c.windowType), String(textComponent(c.title))).Pack(p.connection); err != nil {
+		return err
+	}
+	if err := p.writeContainerItems(window, c); err != nil {
+		return err
+	}
+	if c.furnace != nil {
+		return p.writeFurnaceProperties(window, c)
+	}
+	return nil
+}
+
+// CloseWindow closes the container window open by the player, if there's one.
+func (p *Player) CloseWindow() error {
+	window, c := p.OpenContainer()
+	if c == nil {
+		return nil
+	}
+	p.dropItems(p.closeWindow(window))
+	return NewPacket(closeWindowPacketID, UnsignedByte(window)).Pack(p.connection)
+}
+
+// OpenContainer returns the container shown by the open window with its ID,
+// the container is nil if only the player inventory is open.
+func (p *Player) OpenContainer() (int, *Container) {
+	p.window.mutex.Lock()
+	defer p.window.mutex.Unlock()
+	return p.window.id, p.window.container
+}
+
+// closeWindow removes the player from the viewers of the container shown by a window,
+// it returns the items held by the cursor. Nothing changes if the window isn't open.
+func (p *Player) closeWindow(window int) []Slot {
+	p.window.mutex.Lock()
+	c := p.window.container
+	if c == nil || p.window.id != window {
+		p.window.mutex.Unlock()
+		return nil
+	}
+	p.window.id, p.window.container = playerWindowID, nil
+	p.window.mutex.Unlock()
+
+	c.mutex.Lock()
+	delete(c.viewers, p)
+	handler := c.closeHandler
+	c.mutex.Unlock()
+	if handler != nil {
+		handler(p)
+	}
+
+	p.inventory.mutex.Lock()
+	defer p.inventory.mutex.Unlock()
+	p.inventory.drag.active = false
+	if p.inventory.cursor.IsEmpty() {
+		return nil
+	}
+	cursor := p.inventory.cursor
+	p.inventory.cursor = Slot{}
+	return []Slot{cursor}
+}
+
+// checkWindow closes the container window when the player can't use it anymore.
+func (p *Player) checkWindow() error {
+	_, c := p.OpenContainer()
+	if c == nil || c.valid == nil || c.valid(p) {
+		return nil
+	}
+	return p.CloseWindow()
+}
+
+// writeContainerItems sends all the slots of a container window and the item held by the cursor.
+func (p *Player) writeContainerItems(window int, c *Container) error {
+	c.mutex.Lock()
+	p.inventory.mutex.Lock()
+	view := c.containerView(&p.inventory)
+	slots := make([]Slot, len(view.slots))
+	for i, slot := range view.slots {
+		slots[i] = *slot
+	}
+	cursor := p.inventory.cursor
+	p.inventory.mutex.Unlock()
+	c.mutex.Unlock()
+
+	packet := NewPacket(windowItemsPacketID, UnsignedByte(window), Short(len(slots)))
+	for _, slot := range slots {
+		_, _ = slot.WriteTo(packet)
+	}
+	if err := packet.Pack(p.connection); err != nil {
+		return err
+	}
+	return p.writeSetSlot(cursorWindowID, cursorSlot, cursor)
+}
+
+// writeOpenWindowItems sends again all the slots of the window open by the player.
+func (p *Player) writeOpenWindowItems() error {
+	if window, c := p.OpenContainer(); c != nil {
+		return p.writeContainerItems(window, c)
+	}
+	return p.writeWindowItems()
+}
+
+// blockContainers are the containers of the blocks of a world that keep their items.
+type blockContainers struct {
+	containers map[Position]*Container // containers by block position
+	mutex      sync.Mutex              // block containers mutex
+}
+
+// blockWindows are the windows opened by using blocks, with their titles.
+var blockWindows = map[string]struct {
+	windowType WindowType
+	title      string
+}{
+	"minecraft:chest":          {WindowGeneric9x3, "Chest"},
+	"minecraft:crafting_table": {WindowCrafting, "Crafting"},
+	"minecraft:furnace":        {WindowFurnace, "Furnace"},
+}
+
+// blockContainer returns the container of the block at a position, it's created
+// the first time it's used. It returns nil if the block doesn't have a container.
+func (w *World) blockContainer(pos Position) *Container {
+	name := w.GetBlock(pos.X, pos.Y, pos.Z).Name()
+	window, ok := blockWindows[name]
+	if !ok {
+		return nil
+	}
+
+	// The crafting table doesn't keep items, every player uses its own grid
+	if window.windowType == WindowCrafting {
+		c, _ := NewContainer(window.windowType, window.title)
+		c.valid = w.canUseBlock(pos, name)
+		c.closeHandler = func(p *Player) {
+			c.mutex.Lock()
+			p.inventory.mutex.Lock()
+			var dropped []Slot
+			for slot := craftingInputSlot; slot < len(c.slots); slot++ {
+				// The items that don't fit are thrown
+				if left := p.inventory.add(c.slots[slot]); !left.IsEmpty() {
+					dropped = append(dropped, left)
+				}
+				c.slots[slot] = Slot{}
+			}
+			p.inventory.mutex.Unlock()
+			c.mutex.Unlock()
+			p.dropItems(dropped)
+			_ = p.writeWindowItems()
+		}
+		return c
+	}
+
+	w.containers.mutex.Lock()
+	defer w.containers.mutex.Unlock()
+	if c := w.containers.containers[pos]; c != nil {
+		return c
+	}
+	if w.containers.containers == nil {
+		w.containers.containers = make(map[Position]*Container)
+	}
+	c, _ := NewContainer(window.windowType, window.title)
+	c.valid = w.canUseBlock(pos, name)
+	if window.windowType == WindowFurnace {
+		c.furnace = &furnace{}
+	}
+	if e := w.BlockEntity(pos.X, pos.Y, pos.Z); e != nil {
+		// The items placed in the block entity by the world generator or by plugins
+		for i := range c.slots {
+			if stack := e.ItemStack(i); stack != nil {
+				c.slots[i], _ = stack.Slot()
+			}
+		}
+		if c.furnace != nil {
+			burnTime, _ := e.Data["BurnTime"].(int16)
+			cookTime, _ := e.Data["CookTime"].(int16)
+			cookTotal, _ := e.Data["CookTimeTotal"].(int16)
+			c.furnace.burnTime, c.furnace.cookTime, c.furnace.cookTotal = int(burnTime), int(cookTime), int(cookTotal)
+			c.furnace.burnDuration = fuelTicks(c.slots[furnaceFuelSlot])
+		}
+		c.closeHandler = func(*Player) { w.saveContainer(pos, c) }
+	}
+	w.containers.containers[pos] = c
+	return c
+}
+
+// saveContainer copies the items of the container of a block to its block entity.
+func (w *World) saveContainer(pos Position, c *Container) {
+	e := w.BlockEntity(pos.X, pos.Y, pos.Z)
+	if e == nil {
+		return
+	}
+	e = e.Clone()
+	c.mutex.Lock()
+	for i, slot := range c.slots {
+		e.SetItemStack(i, ItemStackFromSlot(slot))
+	}
+	if c.furnace != nil {
+		e.Data["BurnTime"] = int16(c.furnace.burnTime)
+		e.Data["CookTime"] = int16(c.furnace.cookTime)
+		e.Data["CookTimeTotal"] = int16(c.furnace.cookTotal)
+	}
+	c.mutex.Unlock()
+	w.SetBlockEntity(pos.X, pos.Y, pos.Z, e)
+}
+
+// canUseBlock returns a function that checks if a player is near a block and the block
+// hasn't been replaced. The container of a block that has been replaced is removed.
+func (w *World) canUseBlock(pos Position, name string) func(p *Player) bool {
+	return func(p *Player) bool {
+		if p.World() != w || !p.canReach(pos) {
+			return false
+		}
+		if w.GetBlock(pos.X, pos.Y, pos.Z).Name() != name {
+			w.containers.mutex.Lock()
+			delete(w.containers.containers, pos)
+			w.containers.mutex.Unlock()
+			return false
+		}
+		return true
+	}
+}
+
+// openBlockWindow opens the container of a block used by the player,
+// it returns false if the block doesn't have a container.
+func (p *Player) openBlockWindow(pos Position) (bool, error) {
+	if !p.canReach(pos) {
+		return false, nil
+	}
+	c := p.World().blockContainer(pos)
+	if c == nil {
+		return false, nil
+	}
+	if !c.valid(p) {
+		// The block is a container, but it can't be used
+		return true, nil
+	}
+	return true, p.OpenWindow(c)
+}
@@ -0,0 +1,61 @@
+package MinecraftLightServer
+
+import "errors"
+
+// Difficulty is the difficulty of a world, it changes how hunger hurts the players.
+type Difficulty int
+
+// Difficulties, with their protocol IDs.
+const (
+	DifficultyPeaceful Difficulty = iota // players don't get hungry and regenerate their health
+	DifficultyEasy                       // starvation stops at 5 hearts
+	DifficultyNormal                     // starvation stops at half a heart
+	DifficultyHard                       // starvation kills the players
+)
+
+// String returns the name of the difficulty.
+func (d Difficulty) String() string {
+	switch d {
+	case DifficultyEasy:
+		return "easy"
+	case DifficultyNormal:
+		return "normal"
+	case DifficultyHard:
+		return "hard"
+	}
+	return "peaceful"
+}
+
+// ParseDifficulty returns the difficulty with the specified name.
+func ParseDifficulty(name string) (Difficulty, error) {
+	for d := DifficultyPeaceful; d <= DifficultyHard; d++ {
+		if d.String() == name {
+			return d, nil
+		}
+	}
+	return DifficultyPeaceful, errors.New("unknown difficulty: " + name)
+}
+
+// Difficulty returns the difficulty of the world, peaceful by default.
+func (w *World) Difficulty() Difficulty {
+	w.difficulty.mutex.RLock()
+	defer w.difficulty.mutex.RUnlock()
+	return w.difficulty.value
+}
+
+// SetDifficulty changes the difficulty of the world, it's sent to the players on the next tick.
+func (w *World) SetDifficulty(d Difficulty) {
+	w.difficulty.mutex.Lock()
+	w.difficulty.value = d
+	w.difficulty.mutex.Unlock()
+
+	w.changes.mutex.Lock()
+	w.changes.difficulty = true
+	w.changes.mutex.Unlock()
+}
+
+// writeServerDifficulty sends the difficulty of a world to the client,
+// it's locked because the players can't change it.
+func (p *Player) writeServerDifficulty(w *World) error {
+	return NewPacket(serverDifficultyPacketID, UnsignedByte(w.Difficulty()), Boolean(true)).Pack(p.connection)
+}
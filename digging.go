@@ -0,0 +1,292 @@
+package MinecraftLightServer
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// Block breaking settings, the same as vanilla.
+const (
+	breakTicksPerHardness = 30  // ticks to break a block of hardness 1 by hand, with the right tool
+	breakTolerance        = 0.7 // fraction of the breaking time after which a broken block is accepted
+)
+
+// toolSpeeds are how many times faster than a hand the tools of each material break blocks.
+var toolSpeeds = map[string]float64{"wooden": 2, "stone": 4, "iron": 6, "diamond": 8, "netherite": 9, "golden": 12}
+
+// Player Digging statuses.
+const (
+	diggingStarted   = iota // the player has started to break a block
+	diggingCancelled        // the player has stopped breaking a block
+	diggingFinished         // the player has broken a block
+	diggingDropStack        // the player has thrown the held stack
+	diggingDropItem         // the player has thrown one of the held items
+)
+
+// noDropBlocks are the blocks that don't drop anything when they're broken without tools.
+// The names without namespace match every block that contains them.
+var noDropBlocks = []string{"glass", "_leaves", "ice", "_portal", "minecraft:fire", "minecraft:soul_fire",
+	"minecraft:grass", "minecraft:fern", "minecraft:tall_grass", "minecraft:large_fern", "minecraft:seagrass",
+	"minecraft:tall_seagrass", "minecraft:dead_bush", "minecraft:cobweb", "minecraft:snow", "minecraft:spawner",
+	"minecraft:piston_head", "minecraft:moving_piston", "minecraft:infested_stone"}
+
+// blockDrops are the items dropped by the blocks that don't drop themselves.
+var blockDrops = map[string]string{
+	"minecraft:stone":        "minecraft:cobblestone",
+	"minecraft:grass_block":  "minecraft:dirt",
+	"minecraft:podzol":       "minecraft:dirt",
+	"minecraft:mycelium":     "minecraft:dirt",
+	"minecraft:farmland":     "minecraft:dirt",
+	"minecraft:grass_path":   "minecraft:dirt",
+	"minecraft:coal_ore":     "minecraft:coal",
+	"minecraft:diamond_ore":  "minecraft:diamond",
+	"minecraft:emerald_ore":  "minecraft:emerald",
+	"minecraft:snow_block":   "minecraft:snowball",
+	"minecraft:glowstone":    "minecraft:glowstone_dust",
+	"minecraft:melon":        "minecraft:melon_slice",
+	"minecraft:redstone_ore": "minecraft:redstone",
+	"minecraft:bookshelf":    "minecraft:book",
+	"minecraft:clay":         "minecraft:clay_ball",
+}
+
+func init() {
+	// Blocks placed by items with a different name drop those items
+	for item, block := range itemBlocks {
+		blockDrops[block] = item
+	}
+}
+
+// dig runs a Player Digging action: the block is broken when the player finishes breaking it,
+// immediately in creative mode, and the held items are thrown with the drop key. Blocks that
+// are finished before the time needed to break them are rejected.
+func (p *Player) dig(status int, pos Position) error {
+	switch status {
+	case diggingDropStack, diggingDropItem:
+		if p.IsDead() {
+			return nil
+		}
+		p.DropItem(p.inventory.dropHeld(status == diggingDropStack))
+		held := p.inventory.HeldSlot()
+		return p.writeSetSlot(playerWindowID, hotbarSlot+held, p.inventory.Slot(hotbarSlot+held))
+	case diggingStarted, diggingCancelled, diggingFinished:
+	default:
+		return nil
+	}
+
+	// Blocks that can't be used aren't read, to not load their chunks
+	w := p.World()
+	mode := p.GameMode()
+	if mode == GameModeAdventure || !p.canInteract(pos) {
+		p.stopDigging()
+		state := blockAir
+		if c := w.loadedChunk(pos.X>>4, pos.Z>>4); c != nil {
+			state = c.GetBlock(pos.X&15, pos.Y, pos.Z&15)
+		}
+		return p.writeAcknowledgeDigging(pos, state, status, status == diggingCancelled)
+	}
+
+	state := w.GetBlock(pos.X, pos.Y, pos.Z)
+	accepted := status != diggingFinished
+	// Blocks broken in a tick are only started by the client
+	ticks := breakTicks(state, p.inventory.HeldItem())
+	instant := mode == GameModeCreative || (ticks >= 0 && ticks <= 1)
+	switch {
+	case status == diggingStarted && instant:
+		accepted = w.BreakBlock(pos, mode != GameModeCreative)
+	case status == diggingStarted:
+		p.startDigging(w, pos)
+	case status == diggingFinished && mode == GameModeSurvival:
+		accepted = p.finishDigging(w, pos, state) && w.BreakBlock(pos, true)
+	case status == diggingCancelled:
+		p.stopDigging()
+	}
+	return p.writeAcknowledgeDigging(pos, w.GetBlock(pos.X, pos.Y, pos.Z), status, accepted)
+}
+
+// writeAcknowledgeDigging sends the state of a block after a digging action,
+// the client shows the block again if the action isn't accepted.
+func (p *Player) writeAcknowledgeDigging(pos Position, state BlockState, status int, accepted bool) error {
+	return NewPacket(acknowledgeDiggingPacketID,
+		pos, VarInt(state), VarInt(status), Boolean(accepted),
+	).Pack(p.connection)
+}
+
+// startDigging remembers when the player has started breaking a block.
+func (p *Player) startDigging(w *World, pos Position) {
+	p.digging.mutex.Lock()
+	defer p.digging.mutex.Unlock()
+	p.digging.world, p.digging.pos, p.digging.started, p.digging.active = w, pos, w.Age(), true
+}
+
+// stopDigging forgets the block that the player was breaking.
+func (p *Player) stopDigging() {
+	p.digging.mutex.Lock()
+	p.digging.active = false
+	p.digging.mutex.Unlock()
+}
+
+// finishDigging stops the breaking of a block, it returns true if the player
+// has been breaking it for long enough.
+func (p *Player) finishDigging(w *World, pos Position, state BlockState) bool {
+	p.digging.mutex.Lock()
+	defer p.digging.mutex.Unlock()
+	if !p.digging.active || p.digging.world != w || p.digging.pos != pos {
+		return false
+	}
+	p.digging.active = false
+	ticks := breakTicks(state, p.inventory.HeldItem())
+	return ticks >= 0 && float64(w.Age()-p.digging.started) >= math.Floor(float64(ticks)*breakTolerance)
+}
+
+// breakTicks returns the ticks needed to break a block with an item, -1 if it can't be broken.
+// It's the shortest time of vanilla: held tools are always considered the right ones, and the
+// players are on the ground and without effects.
+func breakTicks(state BlockState, item Slot) int {
+	hardness := state.Hardness()
+	if hardness < 0 {
+		return -1
+	}
+	return int(math.Ceil(hardness * breakTicksPerHardness / breakSpeed(state, item)))
+}
+
+// breakSpeed returns how many times faster than a hand an item breaks a block.
+func breakSpeed(state BlockState, item Slot) float64 {
+	if item.IsEmpty() {
+		return 1
+	}
+	name := strings.TrimPrefix(itemRegistry.Name(item.ItemID), "minecraft:")
+	speed := 1.0
+	switch i := strings.LastIndexByte(name, '_'); {
+	case name == "shears" || (strings.HasSuffix(name, "_sword") && state.Name() == "minecraft:cobweb"):
+		speed = 15
+	case strings.HasSuffix(name, "_sword"):
+		speed = 1.5
+	case i >= 0 && toolSpeeds[name[:i]] > 0:
+		for _, tool := range toolTypes {
+			if name[i+1:] == tool {
+				speed = toolSpeeds[name[:i]]
+			}
+		}
+	}
+
+	// Efficiency makes the tools faster
+	if level := ItemStackFromSlot(item).Enchantments()["minecraft:efficiency"]; level > 0 && speed > 1 {
+		speed += float64(level*level + 1)
+	}
+	return speed
+}
+
+// canReach checks if a block is near enough to the player to be used.
+func (p *Player) canReach(pos Position) bool {
+	dx, dy, dz := float64(p.x)-float64(pos.X)-0.5, float64(p.y)-float64(pos.Y)-0.5, float64(p.z)-float64(pos.Z)-0.5
+	return dx*dx+dy*dy+dz*dz <= maxUseDistance*maxUseDistance
+}
+
+// canInteract checks if the player can use a block: it must be alive, not a spectator,
+// near enough and in a chunk that is already loaded.
+func (p *Player) canInteract(pos Position) bool {
+	if p.IsDead() || p.GameMode() == GameModeSpectator || !p.canReach(pos) {
+		return false
+	}
+	return p.World().loadedChunk(pos.X>>4, pos.Z>>4) != nil
+}
+
+// BreakBlock destroys a block like a player does, if drops is true its item and the items
+// of its container are dropped (with the doTileDrops game rule). It returns false if the
+// block can't be broken.
+func (w *World) BreakBlock(pos Position, drops bool) bool {
+	state := w.GetBlock(pos.X, pos.Y, pos.Z)
+	if state == blockAir || isFluid(state) || state.Hardness() < 0 {
+		return false
+	}
+
+	w.destroyBlock(pos, state, drops, 1)
+	return true
+}
+
+// destroyBlock replaces a block with air, if drops is true the items of its container are
+// dropped and its item is dropped with the specified chance (with the doTileDrops game rule).
+func (w *World) destroyBlock(pos Position, state BlockState, drops bool, lootChance float64) {
+	items := w.removeContainer(pos)
+	w.SetBlock(pos.X, pos.Y, pos.Z, blockAir)
+	if !drops || !w.rules.Bool("doTileDrops") {
+		return
+	}
+	if rand.Float64() < lootChance {
+		items = append(items, blockLoot(state)...)
+	}
+	for _, item := range items {
+		w.DropItem(pos, item)
+	}
+}
+
+// blockLoot returns the items dropped by a block broken without tools.
+func blockLoot(state BlockState) []Slot {
+	name := state.Name()
+	if matchesAny(name, noDropBlocks) || state.Property("half") == "upper" {
+		return nil
+	}
+
+	count := 1
+	if state.Property("type") == "double" {
+		// Double slabs
+		count = 2
+	}
+	var names []string
+	switch {
+	case blockDrops[name] != "":
+		names = append(names, blockDrops[name])
+	case strings.HasPrefix(name, "minecraft:potted_"):
+		names = append(names, "minecraft:flower_pot", "minecraft:"+strings.TrimPrefix(name, "minecraft:potted_"))
+	case strings.Contains(name, "wall_"):
+		// Wall torches, signs, banners and heads
+		names = append(names, strings.Replace(name, "wall_", "", 1))
+	default:
+		names = append(names, name)
+	}
+
+	var items []Slot
+	for _, name := range names {
+		if item, err := NewItem(name, count); err == nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// removeContainer removes the container of a block that is being broken,
+// it returns the items that were inside it.
+func (w *World) removeContainer(pos Position) []Slot {
+	w.containers.mutex.Lock()
+	c := w.containers.containers[pos]
+	delete(w.containers.containers, pos)
+	w.containers.mutex.Unlock()
+
+	var items []Slot
+	if c != nil {
+		c.mutex.Lock()
+		for i, slot := range c.slots {
+			if !slot.IsEmpty() {
+				items = append(items, slot)
+			}
+			c.slots[i] = Slot{}
+		}
+		c.mutex.Unlock()
+		return items
+	}
+
+	// The container has never been opened, the items are in the block entity
+	if e := w.BlockEntity(pos.X, pos.Y, pos.Z); e != nil {
+		list, _ := e.Data["Items"].(NBTList)
+		for _, tag := range list {
+			if tag, ok := tag.(NBTCompound); ok {
+				_, stack := itemStackFromNBT(tag)
+				if item, err := stack.Slot(); err == nil && !item.IsEmpty() {
+					items = append(items, item)
+				}
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,129 @@
+package MinecraftLightServer
+
+import "testing"
+
+func TestBreakTicks(t *testing.T) {
+	stone, _ := ParseBlockState("minecraft:stone")
+	cobweb, _ := ParseBlockState("minecraft:cobweb")
+	torch, _ := ParseBlockState("minecraft:torch")
+	efficient := ItemStackFromSlot(testItem(t, "diamond_pickaxe", 1))
+	efficient.Enchant("efficiency", 5)
+	efficientPickaxe, _ := efficient.Slot()
+
+	tests := []struct {
+		name  string
+		state BlockState
+		item  Slot
+		want  int
+	}{
+		{"stone by hand", stone, Slot{}, 45},
+		{"stone with a diamond pickaxe", stone, testItem(t, "diamond_pickaxe", 1), 6},
+		{"stone with efficiency 5", stone, efficientPickaxe, 2},
+		{"stone with a block", stone, testItem(t, "dirt", 1), 45},
+		{"dirt by hand", blockDirt, Slot{}, 15},
+		{"cobweb with a sword", cobweb, testItem(t, "iron_sword", 1), 8},
+		{"cobweb with shears", cobweb, testItem(t, "shears", 1), 8},
+		{"torch", torch, Slot{}, 0},
+		{"bedrock", blockBedrock, testItem(t, "netherite_pickaxe", 1), -1},
+	}
+	for _, test := range tests {
+		if got := breakTicks(test.state, test.item); got != test.want {
+			t.Errorf("%s: %d ticks, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFinishDigging(t *testing.T) {
+	w := newTestWorld(t)
+	p := &Player{}
+	p.chunks.world = w
+	pos, other := Position{0, 3, 0}, Position{1, 3, 0}
+
+	if p.finishDigging(w, pos, blockDirt) {
+		t.Error("a block that hasn't been started has been broken")
+	}
+	p.startDigging(w, pos)
+	if p.finishDigging(w, pos, blockDirt) {
+		t.Error("a block has been broken immediately")
+	}
+
+	p.startDigging(w, pos)
+	for i := 0; i < 15; i++ {
+		w.tickTime()
+	}
+	if p.finishDigging(w, other, blockDirt) {
+		t.Error("another block has been broken")
+	}
+	p.startDigging(w, pos)
+	for i := 0; i < 15; i++ {
+		w.tickTime()
+	}
+	if !p.finishDigging(w, pos, blockDirt) {
+		t.Error("the block hasn't been broken after the breaking time")
+	}
+	if p.finishDigging(w, pos, blockDirt) {
+		t.Error("the same digging has broken two blocks")
+	}
+}
+
+func TestExplosionDropsContainerItems(t *testing.T) {
+	w := newTestWorld(t)
+	chest, _ := ParseBlockState("minecraft:chest")
+	pos := Position{0, 10, 0}
+	w.SetBlock(pos.X, pos.Y, pos.Z, chest)
+	c := w.blockContainer(pos)
+	c.slots[0] = testItem(t, "diamond", 3)
+
+	if err := w.Explode(pos, 4, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if w.GetBlock(pos.X, pos.Y, pos.Z) != blockAir {
+		t.Fatal("the chest hasn't been destroyed")
+	}
+	if _, ok := w.containers.containers[pos]; ok {
+		t.Error("the container of the chest is still in the world")
+	}
+	diamonds := 0
+	for _, item := range w.items.entities {
+		if itemRegistry.Name(item.item.ItemID) == "minecraft:diamond" {
+			diamonds += int(item.item.Count)
+		}
+	}
+	if diamonds != 3 {
+		t.Errorf("%d diamonds have been dropped, want 3", diamonds)
+	}
+}
+
+func TestMergeItems(t *testing.T) {
+	stone := func(x, z float64, count int) *droppedItem {
+		return &droppedItem{item: testItem(t, "stone", count), x: x, y: 4, z: z}
+	}
+	// The first two stacks are in different blocks, but near enough
+	entities := []*droppedItem{stone(0.9, 0.5, 10), stone(1.2, 0.5, 5), stone(3.5, 0.5, 1), stone(1.1, 0.6, 60)}
+	mergeItems(entities)
+	if entities[0].removed || int(entities[0].item.Count) != 15 || !entities[1].removed {
+		t.Error("the stacks next to each other haven't been merged")
+	}
+	if entities[2].removed || entities[3].removed {
+		t.Error("a far stack or a stack that doesn't fit has been merged")
+	}
+}
+
+func TestCreativeDropSpam(t *testing.T) {
+	p := &Player{}
+	p.chunks.world = newTestWorld(t)
+	for i := 0; i < 15; i++ {
+		p.throwCreative(testItem(t, "stone", 1))
+	}
+	if got := len(p.World().items.entities); got != maxDropSpam/dropSpamIncrement {
+		t.Fatalf("%d items thrown at once, want %d", got, maxDropSpam/dropSpamIncrement)
+	}
+	for i := 0; i < dropSpamIncrement; i++ {
+		p.tickDropSpam()
+	}
+	p.throwCreative(testItem(t, "stone", 1))
+	p.throwCreative(testItem(t, "stone", 1))
+	if got := len(p.World().items.entities); got != maxDropSpam/dropSpamIncrement+1 {
+		t.Errorf("%d items thrown after a second, want %d", got, maxDropSpam/dropSpamIncrement+1)
+	}
+}
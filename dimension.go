@@ -0,0 +1,112 @@
+package MinecraftLightServer
+
+// DimensionType defines how the client renders a world.
+type DimensionType struct {
+	Name               string  // namespaced name
+	Effects            string  // sky rendering (minecraft:overworld, minecraft:the_nether or minecraft:the_end)
+	Infiniburn         string  // tag of the blocks where fire burns forever
+	LogicalHeight      int     // maximum height of portals and chorus fruit teleports
+	AmbientLight       float32 // minimum light level, from 0 to 1
+	FixedTime          int64   // time of day that never changes, -1 if the time flows
+	CoordinateScale    float64 // multiplier of coordinates when traveling to this dimension
+	HasSkyLight        bool    // blocks receive sky light
+	HasCeiling         bool    // the world has a bedrock ceiling
+	Ultrawarm          bool    // water evaporates and lava flows faster
+	Natural            bool    // compasses and clocks work
+	PiglinSafe         bool    // piglins don't zombify
+	BedWorks           bool    // beds can be used, otherwise they explode
+	RespawnAnchorWorks bool    // respawn anchors can be used, otherwise they explode
+	HasRaids           bool    // raids can happen
+}
+
+// Vanilla dimension types.
+var (
+	// OverworldDimension is the dimension type of the overworld.
+	OverworldDimension = &DimensionType{
+		Name:            "minecraft:overworld",
+		Effects:         "minecraft:overworld",
+		Infiniburn:      "minecraft:infiniburn_overworld",
+		LogicalHeight:   chunkHeight,
+		FixedTime:       -1,
+		CoordinateScale: 1,
+		HasSkyLight:     true,
+		Natural:         true,
+		BedWorks:        true,
+		HasRaids:        true,
+	}
+
+	// NetherDimension is the dimension type of the nether.
+	NetherDimension = &DimensionType{
+		Name:               "minecraft:the_nether",
+		Effects:            "minecraft:the_nether",
+		Infiniburn:         "minecraft:infiniburn_nether",
+		LogicalHeight:      128,
+		AmbientLight:       0.1,
+		FixedTime:          18000,
+		CoordinateScale:    8,
+		HasCeiling:         true,
+		Ultrawarm:          true,
+		PiglinSafe:         true,
+		RespawnAnchorWorks: true,
+	}
+
+	// EndDimension is the dimension type of the end.
+	EndDimension = &DimensionType{
+		Name:            "minecraft:the_end",
+		Effects:         "minecraft:the_end",
+		Infiniburn:      "minecraft:infiniburn_end",
+		LogicalHeight:   chunkHeight,
+		FixedTime:       6000,
+		CoordinateScale: 1,
+		HasRaids:        true,
+	}
+)
+
+// nbt returns the dimension type as it's sent in Join Game and Respawn.
+func (d *DimensionType) nbt() NBTCompound {
+	compound := NBTCompound{
+		"effects":              d.Effects,
+		"infiniburn":           d.Infiniburn,
+		"logical_height":       int32(d.LogicalHeight),
+		"ambient_light":        d.AmbientLight,
+		"coordinate_scale":     d.CoordinateScale,
+		"has_skylight":         nbtBool(d.HasSkyLight),
+		"has_ceiling":          nbtBool(d.HasCeiling),
+		"ultrawarm":            nbtBool(d.Ultrawarm),
+		"natural":              nbtBool(d.Natural),
+		"piglin_safe":          nbtBool(d.PiglinSafe),
+		"bed_works":            nbtBool(d.BedWorks),
+		"respawn_anchor_works": nbtBool(d.RespawnAnchorWorks),
+		"has_raids":            nbtBool(d.HasRaids),
+	}
+	if d.FixedTime >= 0 {
+		compound["fixed_time"] = d.FixedTime
+	}
+	return compound
+}
+
+// dimensionCodec returns the dimension types and the biomes sent
+// to the client when it joins.
+func dimensionCodec(dimensions []*DimensionType) NBTCompound {
+	values := make(NBTList, 0, len(dimensions))
+	for id, dimension := range dimensions {
+		values = append(values, NBTCompound{
+			"name":    dimension.Name,
+			"id":      int32(id),
+			"element": dimension.nbt(),
+		})
+	}
+
+	return NBTCompound{
+		"minecraft:dimension_type": NBTCompound{"type": "minecraft:dimension_type", "value": values},
+		"minecraft:worldgen/biome": biomeRegistry.codecNBT(),
+	}
+}
+
+// nbtBool converts a bool to an NBT Byte.
+func nbtBool(b bool) int8 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,393 @@
+package MinecraftLightServer
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Dropped item settings, the same as vanilla.
+const (
+	itemEntityType     = 37    // entity type of dropped items
+	itemSlotMetadata   = 7     // index of the items in the entity metadata
+	itemSize           = 0.25  // width and height of dropped items
+	itemDespawnAge     = 6000  // ticks after which a dropped item disappears, 5 minutes
+	itemThrowDelay     = 40    // ticks before the items thrown by a player can be picked up
+	itemBlockDelay     = 10    // ticks before the items dropped by a block can be picked up
+	itemGroundFriction = 0.6   // horizontal speed multiplier of items on the ground
+	itemMergeDistance  = 0.5   // horizontal distance within which stacks of the same item merge
+	itemMinMove        = 0.001 // movement below which a dropped item isn't sent again
+	itemBlockSpread    = 0.25  // maximum distance from the block center of the items dropped by a block
+	itemBlockSpeed     = 0.1   // maximum horizontal speed of the items dropped by a block
+	itemBlockJump      = 0.2   // vertical speed of the items dropped by a block
+	itemThrowHeight    = 0.3   // distance below the eyes of the player where thrown items spawn
+	itemThrowSpeed     = 0.3   // speed of the items thrown by a player
+	itemThrowJump      = 0.1   // vertical speed added to the items thrown by a player
+	itemThrowSpread    = 0.02  // maximum random speed of the items thrown by a player
+	itemPickupRange    = 1     // distance from the sides of the player within which items are picked up
+	itemPickupHeight   = 0.5   // distance from the top and bottom of the player within which items are picked up
+	dropSpamIncrement  = 20    // added to the drop spam counter by each item thrown from the creative inventory
+	maxDropSpam        = 200   // items thrown from the creative inventory are ignored from this drop spam counter
+)
+
+// droppedItem is a stack of items lying in the world as an entity.
+type droppedItem struct {
+	id         int32   // entity ID
+	uuid       UUID    // entity UUID
+	item       Slot    // dropped items
+	x, y, z    float64 // position of the bottom center of the entity
+	vx, vy, vz float64 // speed in blocks per tick
+	age        int     // ticks since the items have been dropped
+	delay      int     // ticks before the items can be picked up
+	spawned    bool    // the items have just been dropped
+	moved      bool    // the items have moved during the tick
+	changed    bool    // the count of the items has changed during the tick
+	removed    bool    // the items have been picked up, merged or have disappeared
+	collector  int32   // entity ID of the player that has picked up the items, 0 if none
+	collected  int     // number of items picked up
+}
+
+// droppedItems are the dropped items of a world.
+type droppedItems struct {
+	entities []*droppedItem // items lying in the world
+	mutex    sync.Mutex     // dropped items mutex
+}
+
+// DropItem drops items from a block, they jump out of it in a random direction.
+func (w *World) DropItem(pos Position, item Slot) {
+	if item.IsEmpty() {
+		return
+	}
+	w.spawnItem(&droppedItem{
+		item:  item,
+		x:     float64(pos.X) + 0.5 + (rand.Float64()*2-1)*itemBlockSpread,
+		y:     float64(pos.Y) + 0.5 + (rand.Float64()*2-1)*itemBlockSpread - itemSize/2,
+		z:     float64(pos.Z) + 0.5 + (rand.Float64()*2-1)*itemBlockSpread,
+		vx:    (rand.Float64()*2 - 1) * itemBlockSpeed,
+		vy:    itemBlockJump,
+		vz:    (rand.Float64()*2 - 1) * itemBlockSpeed,
+		delay: itemBlockDelay,
+	})
+}
+
+// DropItem throws items from the eyes of the player in the direction it's looking.
+func (p *Player) DropItem(item Slot) {
+	if item.IsEmpty() {
+		return
+	}
+	yaw, pitch := float64(p.yawAbs)*math.Pi/180, float64(p.pitchAbs)*math.Pi/180
+	angle := rand.Float64() * 2 * math.Pi
+	spread := rand.Float64() * itemThrowSpread
+	p.World().spawnItem(&droppedItem{
+		item:  item,
+		x:     float64(p.x),
+		y:     float64(p.y) + playerEyeHeight - itemThrowHeight,
+		z:     float64(p.z),
+		vx:    -math.Sin(yaw)*math.Cos(pitch)*itemThrowSpeed + math.Cos(angle)*spread,
+		vy:    -math.Sin(pitch)*itemThrowSpeed + itemThrowJump,
+		vz:    math.Cos(yaw)*math.Cos(pitch)*itemThrowSpeed + math.Sin(angle)*spread,
+		delay: itemThrowDelay,
+	})
+}
+
+// throwCreative throws an item taken from the creative inventory. Like vanilla, the players
+// can throw 10 items at once and then one every 20 ticks, the other items are ignored.
+func (p *Player) throwCreative(item Slot) {
+	p.dropSpam.mutex.Lock()
+	allowed := p.dropSpam.count < maxDropSpam
+	if allowed {
+		p.dropSpam.count += dropSpamIncrement
+	}
+	p.dropSpam.mutex.Unlock()
+	if allowed {
+		p.DropItem(item)
+	}
+}
+
+// tickDropSpam lowers the drop spam counter of the player, once per tick.
+func (p *Player) tickDropSpam() {
+	p.dropSpam.mutex.Lock()
+	defer p.dropSpam.mutex.Unlock()
+	if p.dropSpam.count > 0 {
+		p.dropSpam.count--
+	}
+}
+
+// dropItems throws the items that have left the inventory of the player.
+func (p *Player) dropItems(items []Slot) {
+	for _, item := range items {
+		p.DropItem(item)
+	}
+}
+
+// spawnItem adds a dropped item to the world, it's sent to the players on the next tick.
+func (w *World) spawnItem(item *droppedItem) {
+	id := UUID(uuid.New())
+	item.id, item.uuid, item.spawned = entityIDFromUUID(id), id, true
+	w.items.mutex.Lock()
+	defer w.items.mutex.Unlock()
+	w.items.entities = append(w.items.entities, item)
+}
+
+// tickDroppedItems moves the dropped items, merges the stacks next to each other and
+// gives them to the players that touch them. Their new state is sent to the players on the next tick.
+func (w *World) tickDroppedItems(players []*Player) {
+	w.items.mutex.Lock()
+	entities := w.items.entities
+	w.items.entities = nil
+	w.items.mutex.Unlock()
+	if len(entities) == 0 {
+		return
+	}
+
+	for _, item := range entities {
+		if !item.spawned {
+			w.moveItem(item)
+			item.age++
+			if item.delay > 0 {
+				item.delay--
+			}
+			if item.age >= itemDespawnAge {
+				item.removed = true
+			}
+		}
+	}
+	mergeItems(entities)
+	for _, item := range entities {
+		if !item.removed && item.delay == 0 {
+			pickupItem(item, players)
+		}
+	}
+
+	remaining := make([]*droppedItem, 0, len(entities))
+	updates := make([]droppedItem, 0, len(entities))
+	for _, item := range entities {
+		if item.spawned || item.moved || item.changed || item.removed {
+			updates = append(updates, *item)
+		}
+		item.spawned, item.moved, item.changed, item.collector, item.collected = false, false, false, 0, 0
+		if !item.removed {
+			remaining = append(remaining, item)
+		}
+	}
+
+	w.items.mutex.Lock()
+	w.items.entities = append(w.items.entities, remaining...)
+	w.items.mutex.Unlock()
+
+	w.changes.mutex.Lock()
+	w.changes.items = append(w.changes.items, updates...)
+	w.changes.mutex.Unlock()
+}
+
+// moveItem advances a dropped item by a tick, it stops against solid blocks.
+func (w *World) moveItem(item *droppedItem) {
+	x, y, z := item.x, item.y, item.z
+	item.vy -= fallingBlockGravity
+	solid := func(x, y, z float64) bool {
+		state := w.GetBlock(int(math.Floor(x)), int(math.Floor(y)), int(math.Floor(z)))
+		return y >= 0 && heightmapMatches(motionBlocking, state) && !isFluid(state)
+	}
+
+	// Each axis is moved separately, like vanilla collisions
+	onGround := false
+	if solid(item.x, item.y+item.vy, item.z) {
+		if item.vy < 0 {
+			item.y, onGround = math.Floor(item.y+item.vy)+1, true
+		}
+		item.vy = 0
+	} else {
+		item.y += item.vy
+	}
+	if solid(item.x+item.vx, item.y, item.z) {
+		item.vx = 0
+	} else {
+		item.x += item.vx
+	}
+	if solid(item.x, item.y, item.z+item.vz) {
+		item.vz = 0
+	} else {
+		item.z += item.vz
+	}
+
+	item.vx, item.vy, item.vz = item.vx*fallingBlockDrag, item.vy*fallingBlockDrag, item.vz*fallingBlockDrag
+	if onGround {
+		item.vx, item.vz = item.vx*itemGroundFriction, item.vz*itemGroundFriction
+	}
+	if item.y < 0 {
+		item.removed = true
+	}
+	item.moved = math.Abs(item.x-x)+math.Abs(item.y-y)+math.Abs(item.z-z) > itemMinMove
+}
+
+// mergeItems joins the stacks of the same item that are next to each other,
+// when they fit in a single stack. The older stack takes the items of the other one.
+// Each stack is only compared with the ones in the same and in the adjacent columns of blocks.
+func mergeItems(entities []*droppedItem) {
+	columns := make(map[[2]int][]int)
+	column := func(item *droppedItem) [2]int {
+		return [2]int{int(math.Floor(item.x)), int(math.Floor(item.z))}
+	}
+	for i, item := range entities {
+		columns[column(item)] = append(columns[column(item)], i)
+	}
+
+	for i, first := range entities {
+		c := column(first)
+		for dx := -1; dx <= 1; dx++ {
+			for dz := -1; dz <= 1; dz++ {
+				for _, j := range columns[[2]int{c[0] + dx, c[1] + dz}] {
+					if j > i {
+						mergeItem(first, entities[j])
+					}
+				}
+			}
+		}
+	}
+}
+
+// mergeItem joins two stacks of the same item if they're near enough and they fit in a single stack.
+func mergeItem(first, second *droppedItem) {
+	if first.removed || second.removed || !first.item.stacksWith(second.item) ||
+		int(first.item.Count)+int(second.item.Count) > first.item.maxStack() ||
+		math.Abs(first.x-second.x) > itemMergeDistance || math.Abs(first.z-second.z) > itemMergeDistance ||
+		math.Abs(first.y-second.y) > itemSize {
+		return
+	}
+	kept, merged := first, second
+	if merged.age > kept.age {
+		kept, merged = merged, kept
+	}
+	kept.item = kept.item.withCount(int(kept.item.Count) + int(merged.item.Count))
+	if merged.delay > kept.delay {
+		kept.delay = merged.delay
+	}
+	kept.changed, merged.removed = true, true
+}
+
+// pickupItem gives a dropped item to the first player that touches it and has space for it,
+// the items that don't fit in the inventory stay on the ground.
+func pickupItem(item *droppedItem, players []*Player) {
+	for _, p := range players {
+		if p.isDeleted || p.GameMode() == GameModeSpectator || p.IsDead() {
+			continue
+		}
+		reach := playerWidth/2 + itemPickupRange + itemSize/2
+		if math.Abs(item.x-float64(p.x)) > reach || math.Abs(item.z-float64(p.z)) > reach ||
+			item.y+itemSize < float64(p.y)-itemPickupHeight || item.y > float64(p.y)+playerHeight+itemPickupHeight {
+			continue
+		}
+
+		p.inventory.mutex.Lock()
+		left := p.inventory.add(item.item)
+		p.inventory.mutex.Unlock()
+		collected := int(item.item.Count)
+		if !left.IsEmpty() {
+			collected -= int(left.Count)
+		}
+		if collected == 0 {
+			continue
+		}
+
+		item.collector, item.collected = p.int32FromUUID(), collected
+		item.item = left
+		if left.IsEmpty() {
+			item.removed = true
+		} else {
+			item.changed = true
+		}
+		return
+	}
+}
+
+// writeDroppedItems sends the dropped items that have been spawned, moved, picked up
+// or removed. The inventory is sent again to the player that has picked up items.
+func (p *Player) writeDroppedItems(items []droppedItem) error {
+	collected := false
+	for _, item := range items {
+		if item.collector == p.int32FromUUID() {
+			collected = true
+		}
+		if !p.hasChunk(int(math.Floor(item.x))>>4, int(math.Floor(item.z))>>4) {
+			continue
+		}
+
+		var packets []*Packet
+		if item.spawned {
+			packets = append(packets, item.spawnPackets()...)
+		}
+		if item.collected > 0 {
+			packets = append(packets, NewPacket(collectItemPacketID,
+				VarInt(item.id), VarInt(item.collector), VarInt(item.collected)))
+		}
+		switch {
+		case item.removed:
+			packets = append(packets, NewPacket(destroyEntityPacketID, VarInt(1), VarInt(item.id)))
+		case item.spawned:
+		default:
+			if item.changed {
+				packets = append(packets, item.metadataPacket())
+			}
+			if item.moved {
+				packets = append(packets, NewPacket(writeEntityTeleportPacketID,
+					VarInt(item.id), Double(item.x), Double(item.y), Double(item.z),
+					Angle(0), Angle(0), Boolean(item.vy == 0)))
+			}
+		}
+		for _, packet := range packets {
+			if err := packet.Pack(p.connection); err != nil {
+				return err
+			}
+		}
+	}
+
+	if collected {
+		return p.writeOpenWindowItems()
+	}
+	return nil
+}
+
+// spawnPackets returns the packets that show a dropped item to a player.
+func (item *droppedItem) spawnPackets() []*Packet {
+	return []*Packet{
+		NewPacket(spawnEntityPacketID,
+			VarInt(item.id), item.uuid, // entity id and uuid
+			VarInt(itemEntityType),                         // entity type
+			Double(item.x), Double(item.y), Double(item.z), // position
+			Angle(0), Angle(0), Int(1), // pitch, yaw and data
+			Short(item.vx*velocityUnit), Short(item.vy*velocityUnit), Short(item.vz*velocityUnit), // velocity
+		),
+		item.metadataPacket(),
+	}
+}
+
+// metadataPacket returns the Entity Metadata packet with the items of a dropped item.
+func (item *droppedItem) metadataPacket() *Packet {
+	metadata := NewPacket(writeEntityMetadataPacketID, VarInt(item.id))
+	_, _ = UnsignedByte(itemSlotMetadata).WriteTo(metadata) // field unique id
+	_, _ = VarInt(6).WriteTo(metadata)                      // slot
+	_, _ = item.item.WriteTo(metadata)                      // items
+	_, _ = UnsignedByte(0xFF).WriteTo(metadata)             // Terminate entity metadata array
+	return metadata
+}
+
+// writeChunkItems sends the dropped items lying in a chunk that the player has just loaded.
+func (p *Player) writeChunkItems(w *World, pos chunkPos) error {
+	w.items.mutex.Lock()
+	var packets []*Packet
+	for _, item := range w.items.entities {
+		if !item.spawned && int(math.Floor(item.x))>>4 == pos.x && int(math.Floor(item.z))>>4 == pos.z {
+			packets = append(packets, item.spawnPackets()...)
+		}
+	}
+	w.items.mutex.Unlock()
+
+	for _, packet := range packets {
+		if err := packet.Pack(p.connection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
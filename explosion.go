@@ -0,0 +1,241 @@
+package MinecraftLightServer
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// Explosion settings, the same as vanilla.
+const (
+	explosionRays      = 16    // rays along each side of the cube cast from the center
+	explosionStep      = 0.3   // distance between two points of a ray
+	explosionDecay     = 0.225 // intensity lost by a ray at each step
+	explosionRange     = 64    // distance of the players that receive the explosion
+	explosionFireRatio = 3     // one destroyed block out of three catches fire
+	playerEyeHeight    = 1.62  // height of the eyes of a standing player
+	playerWidth        = 0.6   // width of the player hitbox
+	playerHeight       = 1.8   // height of the player hitbox
+	exposureRayStep    = 0.1   // distance between two points of an exposure ray
+	maxExplosionPower  = 64    // the destroyed blocks must be within 127 blocks from the center
+)
+
+// blockFire is the fire placed by explosions.
+var blockFire, _ = ParseBlockState("minecraft:fire")
+
+// explosion is an explosion that happened during a tick.
+type explosion struct {
+	x, y, z   float64                // center of the explosion
+	power     float64                // radius of the explosion is about twice the power
+	destroyed []Position             // blocks destroyed by the explosion
+	knockback map[*Player][3]float64 // velocity given to the players hit by the explosion
+}
+
+// Explode creates an explosion at the center of a block. Explosions with fire set some
+// of the destroyed blocks on fire, the ones that don't break blocks only hit players.
+// A power of 4 is the explosion of TNT, the power must be greater than 0 and at most 64.
+func (w *World) Explode(pos Position, power float64, fire, breakBlocks bool) error {
+	if !(power > 0 && power <= maxExplosionPower) {
+		return errors.New("invalid explosion power: " + strconv.FormatFloat(power, 'g', -1, 64))
+	}
+	w.explode(float64(pos.X)+0.5, float64(pos.Y)+0.5, float64(pos.Z)+0.5, power, fire, breakBlocks)
+	return nil
+}
+
+// explode creates an explosion at a point, the players are hit before the blocks
+// are destroyed. Destroyed containers drop their items.
+func (w *World) explode(x, y, z, power float64, fire, breakBlocks bool) {
+	if !(power > 0) {
+		return
+	}
+	// The destroyed blocks are sent as offsets of a byte
+	power = math.Min(power, maxExplosionPower)
+	e := explosion{x: x, y: y, z: z, power: power}
+	w.hitPlayers(&e)
+	if breakBlocks {
+		e.destroyed = w.blastedBlocks(x, y, z, power)
+	}
+
+	for _, pos := range e.destroyed {
+		state := w.GetBlock(pos.X, pos.Y, pos.Z)
+		if state.Name() == "minecraft:tnt" {
+			w.SetBlock(pos.X, pos.Y, pos.Z, blockAir)
+			w.primeExplodedTNT(pos)
+			continue
+		}
+		// Like vanilla, bigger explosions drop less blocks
+		w.destroyBlock(pos, state, true, 1/power)
+	}
+	if fire {
+		for _, pos := range e.destroyed {
+			below := w.GetBlock(pos.X, pos.Y-1, pos.Z)
+			if rand.Intn(explosionFireRatio) == 0 && w.GetBlock(pos.X, pos.Y, pos.Z) == blockAir &&
+				heightmapMatches(motionBlocking, below) && !isFluid(below) {
+				w.SetBlock(pos.X, pos.Y, pos.Z, blockFire)
+			}
+		}
+	}
+
+	w.pushPrimedTNT(e)
+
+	w.changes.mutex.Lock()
+	w.changes.explosions = append(w.changes.explosions, e)
+	w.changes.mutex.Unlock()
+}
+
+// hitPlayers hurts the players hit by an explosion and remembers their knockback,
+// which is sent with the explosion.
+func (w *World) hitPlayers(e *explosion) {
+	for _, p := range w.players() {
+		x, y, z := float64(p.x), float64(p.y), float64(p.z)
+		impact := w.impact(*e, x, y, z, playerWidth, playerHeight)
+		if impact <= 0 {
+			continue
+		}
+		if e.knockback == nil {
+			e.knockback = make(map[*Player][3]float64)
+		}
+		motionX, motionY, motionZ := knockback(*e, x, y+playerEyeHeight, z, impact)
+		e.knockback[p] = [3]float64{motionX, motionY, motionZ}
+		p.damage(float32(int((impact*impact+impact)/2*7*e.power*2+1)), DamageExplosion, nil)
+	}
+}
+
+// blastedBlocks returns the blocks destroyed by an explosion: rays are cast from the center
+// towards the sides of a cube and they lose intensity with the distance and the blast
+// resistance of the blocks that they cross.
+func (w *World) blastedBlocks(x, y, z, power float64) []Position {
+	blasted := make(map[Position]bool)
+	for i := 0; i < explosionRays; i++ {
+		for j := 0; j < explosionRays; j++ {
+			for k := 0; k < explosionRays; k++ {
+				// Only the rays that start from the surface of the cube
+				if i != 0 && i != explosionRays-1 && j != 0 && j != explosionRays-1 && k != 0 && k != explosionRays-1 {
+					continue
+				}
+				dx := float64(i)/(explosionRays-1)*2 - 1
+				dy := float64(j)/(explosionRays-1)*2 - 1
+				dz := float64(k)/(explosionRays-1)*2 - 1
+				length := math.Sqrt(dx*dx + dy*dy + dz*dz)
+				dx, dy, dz = dx/length*explosionStep, dy/length*explosionStep, dz/length*explosionStep
+
+				px, py, pz := x, y, z
+				for intensity := power * (0.7 + rand.Float64()*0.6); intensity > 0; intensity -= explosionDecay {
+					pos := Position{int(math.Floor(px)), int(math.Floor(py)), int(math.Floor(pz))}
+					if pos.Y < 0 || pos.Y >= chunkHeight {
+						break
+					}
+					if state := w.GetBlock(pos.X, pos.Y, pos.Z); state != blockAir {
+						intensity -= (state.BlastResistance() + explosionStep) * explosionStep
+						if intensity > 0 {
+							blasted[pos] = true
+						}
+					}
+					px, py, pz = px+dx, py+dy, pz+dz
+				}
+			}
+		}
+	}
+
+	destroyed := make([]Position, 0, len(blasted))
+	for pos := range blasted {
+		destroyed = append(destroyed, pos)
+	}
+	return destroyed
+}
+
+// exposure returns the fraction of a box that can be seen from a point,
+// the rays cast towards it are stopped by solid blocks.
+func (w *World) exposure(x, y, z, minX, minY, minZ, maxX, maxY, maxZ float64) float64 {
+	stepX := 1 / ((maxX-minX)*2 + 1)
+	stepY := 1 / ((maxY-minY)*2 + 1)
+	stepZ := 1 / ((maxZ-minZ)*2 + 1)
+	offsetX := (1 - math.Floor(1/stepX)*stepX) / 2
+	offsetZ := (1 - math.Floor(1/stepZ)*stepZ) / 2
+
+	seen, total := 0, 0
+	for fx := 0.0; fx <= 1; fx += stepX {
+		for fy := 0.0; fy <= 1; fy += stepY {
+			for fz := 0.0; fz <= 1; fz += stepZ {
+				tx := minX + (maxX-minX)*fx + offsetX
+				ty := minY + (maxY-minY)*fy
+				tz := minZ + (maxZ-minZ)*fz + offsetZ
+				if !w.rayBlocked(tx, ty, tz, x, y, z) {
+					seen++
+				}
+				total++
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(seen) / float64(total)
+}
+
+// rayBlocked checks if a solid block is between two points.
+func (w *World) rayBlocked(fromX, fromY, fromZ, toX, toY, toZ float64) bool {
+	dx, dy, dz := toX-fromX, toY-fromY, toZ-fromZ
+	steps := int(math.Sqrt(dx*dx+dy*dy+dz*dz) / exposureRayStep)
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps+1)
+		x, y, z := int(math.Floor(fromX+dx*t)), int(math.Floor(fromY+dy*t)), int(math.Floor(fromZ+dz*t))
+		if y < 0 || y >= chunkHeight {
+			continue
+		}
+		if state := w.GetBlock(x, y, z); heightmapMatches(motionBlocking, state) && !isFluid(state) {
+			return true
+		}
+	}
+	return false
+}
+
+// impact returns how strongly an explosion hits a box with its feet at x, y, z,
+// from 0 (not hit) to 1.
+func (w *World) impact(e explosion, x, y, z, width, height float64) float64 {
+	dx, dy, dz := x-e.x, y-e.y, z-e.z
+	distance := math.Sqrt(dx*dx+dy*dy+dz*dz) / (e.power * 2)
+	if distance > 1 {
+		return 0
+	}
+	half := width / 2
+	return (1 - distance) * w.exposure(e.x, e.y, e.z, x-half, y, z-half, x+half, y+height, z+half)
+}
+
+// knockback returns the velocity in blocks per tick given by an explosion to a point.
+func knockback(e explosion, x, y, z, impact float64) (float64, float64, float64) {
+	dx, dy, dz := x-e.x, y-e.y, z-e.z
+	length := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	if length == 0 {
+		return 0, 0, 0
+	}
+	return dx / length * impact, dy / length * impact, dz / length * impact
+}
+
+// writeExplosions sends the explosions near the player with the knockback that it has received.
+func (p *Player) writeExplosions(explosions []explosion) error {
+	x, y, z := float64(p.x), float64(p.y), float64(p.z)
+	for _, e := range explosions {
+		dx, dy, dz := x-e.x, y-e.y, z-e.z
+		if dx*dx+dy*dy+dz*dz > explosionRange*explosionRange {
+			continue
+		}
+
+		motion := e.knockback[p]
+		packet := NewPacket(explosionPacketID,
+			Float(e.x), Float(e.y), Float(e.z), Float(e.power), Int(len(e.destroyed)))
+		for _, pos := range e.destroyed {
+			_, _ = Byte(pos.X - int(math.Floor(e.x))).WriteTo(packet)
+			_, _ = Byte(pos.Y - int(math.Floor(e.y))).WriteTo(packet)
+			_, _ = Byte(pos.Z - int(math.Floor(e.z))).WriteTo(packet)
+		}
+		_, _ = Float(motion[0]).WriteTo(packet)
+		_, _ = Float(motion[1]).WriteTo(packet)
+		_, _ = Float(motion[2]).WriteTo(packet)
+		if err := packet.Pack(p.connection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package MinecraftLightServer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExplodePower(t *testing.T) {
+	w := newTestWorld(t)
+	pos := Position{0, 3, 0}
+	for _, power := range []float64{0, -4, maxExplosionPower + 1, math.Inf(1), math.NaN()} {
+		if err := w.Explode(pos, power, false, true); err == nil {
+			t.Errorf("explosion of power %v accepted", power)
+		}
+	}
+	if w.GetBlock(pos.X, pos.Y, pos.Z) == blockAir || len(w.flushChanges().explosions) != 0 {
+		t.Error("an invalid explosion has happened")
+	}
+}
+
+func TestExplosionReach(t *testing.T) {
+	// Blocks that don't stop the rays, only their length limits the explosion
+	generator, err := NewFlatGenerator("255*minecraft:tnt;minecraft:plains")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWorld(generator)
+	x, y, z := 0.5, 128.5, 0.5
+	destroyed := w.blastedBlocks(x, y, z, maxExplosionPower)
+	if len(destroyed) == 0 {
+		t.Fatal("no blocks destroyed")
+	}
+	for _, pos := range destroyed {
+		dx, dy, dz := pos.X-int(math.Floor(x)), pos.Y-int(math.Floor(y)), pos.Z-int(math.Floor(z))
+		if dx < math.MinInt8 || dx > math.MaxInt8 || dy < math.MinInt8 || dy > math.MaxInt8 || dz < math.MinInt8 || dz > math.MaxInt8 {
+			t.Fatalf("block %v is too far from the center of the explosion", pos)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package MinecraftLightServer
+
+import (
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Falling block settings, the same as vanilla.
+const (
+	fallingBlockEntityType = 26   // entity type of falling blocks
+	fallingBlockDelay      = 2    // ticks before a gravity block starts to fall
+	fallingBlockGravity    = 0.04 // speed added every tick
+	fallingBlockDrag       = 0.98 // speed multiplier of every tick
+	fallingBlockMaxAge     = 600  // ticks after which a falling block disappears
+	velocityUnit           = 8000 // velocity in protocol units for a block per tick
+)
+
+// gravityBlocks are the blocks that fall when there's nothing below them.
+var gravityBlocks = map[string]bool{
+	"minecraft:sand":     true,
+	"minecraft:red_sand": true,
+	"minecraft:gravel":   true,
+}
+
+// fallThroughBlocks are the blocks, in addition to air and fluids, that falling blocks replace.
+var fallThroughBlocks = map[string]bool{
+	"minecraft:fire":      true,
+	"minecraft:soul_fire": true,
+	"minecraft:grass":     true,
+	"minecraft:fern":      true,
+	"minecraft:dead_bush": true,
+	"minecraft:snow":      true,
+}
+
+// fallingBlock is a block falling as an entity.
+type fallingBlock struct {
+	id      int32      // entity ID
+	uuid    UUID       // entity UUID
+	state   BlockState // falling block
+	x, y, z float64    // position of the bottom center of the entity
+	speed   float64    // vertical speed in blocks per tick, negative when falling
+	age     int        // ticks since the block started to fall
+	spawned bool       // the block has just started to fall
+	removed bool       // the block has landed or disappeared
+}
+
+// fallingBlocks are the falling blocks of a world.
+type fallingBlocks struct {
+	blocks []*fallingBlock // blocks that are falling
+	mutex  sync.Mutex      // falling blocks mutex
+}
+
+func init() {
+	for _, color := range colorValues {
+		gravityBlocks["minecraft:"+color+"_concrete_powder"] = true
+	}
+	for name := range gravityBlocks {
+		neighborUpdateHandlers[name] = gravityNeighborChanged
+		blockTickHandlers[name] = tickGravityBlock
+	}
+}
+
+// canFallThrough checks if a falling block can move into a block.
+func canFallThrough(state BlockState) bool {
+	return state == blockAir || isFluid(state) || fallThroughBlocks[state.Name()]
+}
+
+// gravityNeighborChanged schedules a gravity block to check if it has to fall,
+// concrete powder that touches water becomes concrete.
+func gravityNeighborChanged(w *World, pos Position, state BlockState) {
+	if name := state.Name(); strings.HasSuffix(name, "_concrete_powder") {
+		for _, dir := range allDirections[1:] {
+			neighbor := pos.offset(dir)
+			if waterFluid.contains(w.GetBlock(neighbor.X, neighbor.Y, neighbor.Z)) {
+				concrete, _ := ParseBlockState(name[:len(name)-len("_powder")])
+				w.SetBlock(pos.X, pos.Y, pos.Z, concrete)
+				return
+			}
+		}
+	}
+	w.ScheduleTick(pos, fallingBlockDelay)
+}
+
+// tickGravityBlock makes a gravity block fall if there's nothing below it.
+func tickGravityBlock(w *World, pos Position, state BlockState) {
+	if pos.Y <= 0 || !canFallThrough(w.GetBlock(pos.X, pos.Y-1, pos.Z)) {
+		return
+	}
+	w.SetBlock(pos.X, pos.Y, pos.Z, blockAir)
+	w.SpawnFallingBlock(pos, state)
+}
+
+// SpawnFallingBlock makes a block fall from a position, it's placed again where it lands.
+// The block at the position isn't changed.
+func (w *World) SpawnFallingBlock(pos Position, state BlockState) {
+	id := UUID(uuid.New())
+	w.falling.mutex.Lock()
+	defer w.falling.mutex.Unlock()
+	w.falling.blocks = append(w.falling.blocks, &fallingBlock{
+		id:      entityIDFromUUID(id),
+		uuid:    id,
+		state:   state,
+		x:       float64(pos.X) + 0.5,
+		y:       float64(pos.Y),
+		z:       float64(pos.Z) + 0.5,
+		spawned: true,
+	})
+}
+
+// tickFallingBlocks moves the falling blocks and places the ones that land.
+// Their new state is sent to the players on the next tick.
+func (w *World) tickFallingBlocks() {
+	w.falling.mutex.Lock()
+	blocks := w.falling.blocks
+	w.falling.blocks = nil
+	w.falling.mutex.Unlock()
+	if len(blocks) == 0 {
+		return
+	}
+
+	falling := make([]*fallingBlock, 0, len(blocks))
+	updates := make([]fallingBlock, 0, len(blocks))
+	for _, block := range blocks {
+		if !block.spawned {
+			w.moveFallingBlock(block)
+		}
+		updates = append(updates, *block)
+		block.spawned = false
+		if !block.removed {
+			falling = append(falling, block)
+		}
+	}
+
+	w.falling.mutex.Lock()
+	w.falling.blocks = append(w.falling.blocks, falling...)
+	w.falling.mutex.Unlock()
+
+	w.changes.mutex.Lock()
+	w.changes.falling = append(w.changes.falling, updates...)
+	w.changes.mutex.Unlock()
+}
+
+// moveFallingBlock advances a falling block by a tick, placing it
+// on the first block that it can't fall through. It's dropped as an item
+// when it can't be placed or when it falls for too long.
+func (w *World) moveFallingBlock(block *fallingBlock) {
+	block.age++
+	block.speed = (block.speed - fallingBlockGravity) * fallingBlockDrag
+	x, z := int(math.Floor(block.x)), int(math.Floor(block.z))
+	from, to := int(math.Floor(block.y)), int(math.Floor(block.y+block.speed))
+
+	// Check every block crossed during the tick
+	for y := from - 1; y >= to && y >= 0; y-- {
+		if canFallThrough(w.GetBlock(x, y, z)) {
+			continue
+		}
+		block.y, block.removed = float64(y+1), true
+		if canFallThrough(w.GetBlock(x, y+1, z)) {
+			w.SetBlock(x, y+1, z, block.state)
+		} else {
+			w.dropFallingBlock(Position{x, y + 1, z}, block.state)
+		}
+		return
+	}
+
+	block.y += block.speed
+	if block.y < 0 {
+		block.removed = true
+	} else if block.age > fallingBlockMaxAge {
+		block.removed = true
+		w.dropFallingBlock(Position{x, int(math.Floor(block.y)), z}, block.state)
+	}
+}
+
+// dropFallingBlock drops a falling block that can't be placed as an item
+// (with the doEntityDrops game rule).
+func (w *World) dropFallingBlock(pos Position, state BlockState) {
+	if !w.rules.Bool("doEntityDrops") {
+		return
+	}
+	if item, err := NewItem(state.Name(), 1); err == nil {
+		w.DropItem(pos, item)
+	}
+}
+
+// writeFallingBlocks sends the falling blocks that have started to fall, moved or landed.
+func (p *Player) writeFallingBlocks(blocks []fallingBlock) error {
+	for _, block := range blocks {
+		if !p.hasChunk(int(math.Floor(block.x))>>4, int(math.Floor(block.z))>>4) {
+			continue
+		}
+
+		var packet *Packet
+		switch {
+		case block.spawned:
+			packet = NewPacket(spawnEntityPacketID,
+				VarInt(block.id), block.uuid, // entity id and uuid
+				VarInt(fallingBlockEntityType),                    // entity type
+				Double(block.x), Double(block.y), Double(block.z), // position
+				Angle(0), Angle(0), Int(block.state), // pitch, yaw and block state
+				Short(0), Short(block.speed*velocityUnit), Short(0), // velocity
+			)
+		case block.removed:
+			packet = NewPacket(destroyEntityPacketID, VarInt(1), VarInt(block.id))
+		default:
+			packet = NewPacket(writeEntityTeleportPacketID,
+				VarInt(block.id), Double(block.x), Double(block.y), Double(block.z),
+				Angle(0), Angle(0), Boolean(false))
+		}
+		if err := packet.Pack(p.connection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
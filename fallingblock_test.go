@@ -0,0 +1,73 @@
+package MinecraftLightServer
+
+import "testing"
+
+// droppedCount returns the number of items of a type dropped in a world.
+func droppedCount(w *World, name string) int {
+	count := 0
+	for _, item := range w.items.entities {
+		if itemRegistry.Name(item.item.ItemID) == name {
+			count += int(item.item.Count)
+		}
+	}
+	return count
+}
+
+func TestFallingBlockDrops(t *testing.T) {
+	sand, _ := ParseBlockState("minecraft:sand")
+	torch, _ := ParseBlockState("minecraft:torch")
+
+	t.Run("landing", func(t *testing.T) {
+		w := newTestWorld(t)
+		w.SetBlock(0, 9, 0, blockStone)
+		w.SpawnFallingBlock(Position{0, 10, 0}, sand)
+		w.tickFallingBlocks()
+		w.tickFallingBlocks()
+		if w.GetBlock(0, 10, 0) != sand || droppedCount(w, "minecraft:sand") != 0 {
+			t.Error("the sand hasn't been placed where it has landed")
+		}
+	})
+
+	t.Run("occupied landing block", func(t *testing.T) {
+		w := newTestWorld(t)
+		w.SetBlock(0, 9, 0, blockStone)
+		w.SetBlock(0, 10, 0, torch)
+		w.SpawnFallingBlock(Position{0, 10, 0}, sand)
+		w.tickFallingBlocks()
+		w.tickFallingBlocks()
+		if w.GetBlock(0, 10, 0) != torch {
+			t.Error("the torch has been replaced")
+		}
+		if count := droppedCount(w, "minecraft:sand"); count != 1 {
+			t.Errorf("%d sand items have been dropped, want 1", count)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		w := newTestWorld(t)
+		w.SpawnFallingBlock(Position{0, 200, 0}, sand)
+		w.tickFallingBlocks()
+		w.falling.blocks[0].age = fallingBlockMaxAge
+		w.tickFallingBlocks()
+		if len(w.falling.blocks) != 0 {
+			t.Fatal("the falling block hasn't been removed")
+		}
+		if count := droppedCount(w, "minecraft:sand"); count != 1 {
+			t.Errorf("%d sand items have been dropped, want 1", count)
+		}
+	})
+
+	t.Run("without entity drops", func(t *testing.T) {
+		w := newTestWorld(t)
+		if err := w.rules.Set("doEntityDrops", "false"); err != nil {
+			t.Fatal(err)
+		}
+		w.SpawnFallingBlock(Position{0, 200, 0}, sand)
+		w.tickFallingBlocks()
+		w.falling.blocks[0].age = fallingBlockMaxAge
+		w.tickFallingBlocks()
+		if count := droppedCount(w, "minecraft:sand"); count != 0 {
+			t.Errorf("%d sand items have been dropped, want 0", count)
+		}
+	})
+}
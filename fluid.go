@@ -0,0 +1,278 @@
+package MinecraftLightServer
+
+import "strings"
+
+// Fluid levels, stored in the level property of water and lava.
+const (
+	fluidSource  = 0 // full block that doesn't change
+	fluidFalling = 8 // flowing down, it has the full amount
+	fluidAmount  = 8 // amount of fluid of a source
+	fluidLevels  = 16
+	noSlope      = 1000 // slope distance when there isn't a hole
+)
+
+// blockObsidian is created when water touches a lava source.
+const blockObsidian BlockState = 1434
+
+// fluid is a fluid type with its flowing rules.
+type fluid struct {
+	block         BlockState // source state, the other levels follow it
+	dropOff       int        // amount lost for each block of horizontal flow
+	slopeDistance int        // distance where holes are searched to choose the flow direction
+	delay         int        // ticks between two flow updates
+	infinite      bool       // two sources create a new source between them
+}
+
+// Fluid types, lava flows faster and farther in ultrawarm dimensions.
+var (
+	waterFluid         = &fluid{block: blockWater, dropOff: 1, slopeDistance: 4, delay: 5, infinite: true}
+	lavaFluid          = &fluid{block: blockLava, dropOff: 2, slopeDistance: 2, delay: 30}
+	ultrawarmLavaFluid = &fluid{block: blockLava, dropOff: 1, slopeDistance: 4, delay: 10}
+)
+
+func init() {
+	blockTickHandlers["minecraft:water"] = tickFluid
+	blockTickHandlers["minecraft:lava"] = tickFluid
+	neighborUpdateHandlers["minecraft:water"] = fluidNeighborChanged
+	neighborUpdateHandlers["minecraft:lava"] = fluidNeighborChanged
+}
+
+// fluidOf returns the fluid of a block state, nil if it isn't a fluid.
+func (w *World) fluidOf(state BlockState) *fluid {
+	switch {
+	case state >= blockWater && state < blockWater+fluidLevels:
+		return waterFluid
+	case state >= blockLava && state < blockLava+fluidLevels:
+		if w.dimension.Ultrawarm {
+			return ultrawarmLavaFluid
+		}
+		return lavaFluid
+	}
+	return nil
+}
+
+// level returns the level of a state of the fluid.
+func (f *fluid) level(state BlockState) int {
+	return int(state - f.block)
+}
+
+// amount returns the amount of fluid of a state, from 1 to 8.
+func (f *fluid) amount(state BlockState) int {
+	if level := f.level(state); level != fluidSource && level < fluidFalling {
+		return fluidAmount - level
+	}
+	return fluidAmount
+}
+
+// contains checks if a block state is the fluid.
+func (f *fluid) contains(state BlockState) bool {
+	return state >= f.block && state < f.block+fluidLevels
+}
+
+// isFluid checks if a block state is water or lava.
+func isFluid(state BlockState) bool {
+	return waterFluid.contains(state) || lavaFluid.contains(state)
+}
+
+// canHoldFluid checks if fluids can flow into a block, replacing it.
+func canHoldFluid(state BlockState) bool {
+	if state == blockAir {
+		return true
+	}
+	if isFluid(state) || heightmapMatches(motionBlocking, state) {
+		return false
+	}
+	name := state.Name()
+	return !strings.HasSuffix(name, "sign") && name != "minecraft:sugar_cane" &&
+		name != "minecraft:nether_portal" && name != "minecraft:ladder"
+}
+
+// fluidNeighborChanged schedules a fluid when a block next to it changes,
+// lava that touches water hardens immediately.
+func fluidNeighborChanged(w *World, pos Position, state BlockState) {
+	if !w.hardenLava(pos, state) {
+		w.ScheduleTick(pos, w.fluidOf(state).delay)
+	}
+}
+
+// hardenLava turns lava that touches water into obsidian (sources) or cobblestone,
+// it returns false if the block isn't lava or there isn't water around.
+func (w *World) hardenLava(pos Position, state BlockState) bool {
+	if !lavaFluid.contains(state) {
+		return false
+	}
+	for _, dir := range append([]Position{{0, 1, 0}}, horizontalDirections...) {
+		neighbor := pos.offset(dir)
+		if waterFluid.contains(w.GetBlock(neighbor.X, neighbor.Y, neighbor.Z)) {
+			hardened := blockCobblestone
+			if lavaFluid.level(state) == fluidSource {
+				hardened = blockObsidian
+			}
+			w.SetBlock(pos.X, pos.Y, pos.Z, hardened)
+			return true
+		}
+	}
+	return false
+}
+
+// tickFluid updates the level of a fluid using its neighbors and makes it spread.
+func tickFluid(w *World, pos Position, state BlockState) {
+	f := w.fluidOf(state)
+	if w.hardenLava(pos, state) {
+		return
+	}
+
+	// Sources never change, the other levels depend on the neighbors
+	if f.level(state) != fluidSource {
+		updated := w.newFluidState(f, pos)
+		if updated != state {
+			w.SetBlock(pos.X, pos.Y, pos.Z, updated)
+			if updated == blockAir {
+				return
+			}
+			state = updated
+		}
+	}
+	w.spreadFluid(f, pos, state)
+}
+
+// newFluidState calculates the state of a flowing fluid from its neighbors.
+func (w *World) newFluidState(f *fluid, pos Position) BlockState {
+	maxAmount, sources := 0, 0
+	for _, dir := range horizontalDirections {
+		neighbor := pos.offset(dir)
+		state := w.GetBlock(neighbor.X, neighbor.Y, neighbor.Z)
+		if !f.contains(state) {
+			continue
+		}
+		if f.level(state) == fluidSource {
+			sources++
+		}
+		if amount := f.amount(state); amount > maxAmount {
+			maxAmount = amount
+		}
+	}
+
+	// Two sources create a new one above solid ground or another source
+	if f.infinite && sources >= 2 {
+		below := w.GetBlock(pos.X, pos.Y-1, pos.Z)
+		if below == f.block || (heightmapMatches(motionBlocking, below) && !isFluid(below)) {
+			return f.block
+		}
+	}
+
+	if f.contains(w.GetBlock(pos.X, pos.Y+1, pos.Z)) {
+		return f.block + fluidFalling
+	}
+	if amount := maxAmount - f.dropOff; amount > 0 {
+		return f.block + BlockState(fluidAmount-amount)
+	}
+	return blockAir
+}
+
+// spreadFluid makes a fluid flow down or towards the nearest holes.
+func (w *World) spreadFluid(f *fluid, pos Position, state BlockState) {
+	if pos.Y > 0 {
+		below := w.GetBlock(pos.X, pos.Y-1, pos.Z)
+		switch {
+		case f != waterFluid && waterFluid.contains(below):
+			// Lava flowing down into water becomes stone
+			w.SetBlock(pos.X, pos.Y-1, pos.Z, blockStone)
+			return
+		case canHoldFluid(below):
+			// Fluids flowing down spread to the sides only in the middle of a pool
+			w.SetBlock(pos.X, pos.Y-1, pos.Z, f.block+fluidFalling)
+			if w.sourceNeighbors(f, pos) < 3 {
+				return
+			}
+		case f.contains(below) && f.level(state) != fluidSource:
+			return
+		}
+	}
+
+	amount := f.amount(state) - f.dropOff
+	if f.level(state) >= fluidFalling {
+		amount = fluidAmount - f.dropOff
+	}
+	if amount <= 0 {
+		return
+	}
+	for _, dir := range w.flowDirections(f, pos) {
+		target := pos.offset(dir)
+		if canHoldFluid(w.GetBlock(target.X, target.Y, target.Z)) {
+			w.SetBlock(target.X, target.Y, target.Z, f.block+BlockState(fluidAmount-amount))
+		}
+	}
+}
+
+// sourceNeighbors returns the number of horizontal neighbors that are sources of the fluid.
+func (w *World) sourceNeighbors(f *fluid, pos Position) int {
+	sources := 0
+	for _, dir := range horizontalDirections {
+		neighbor := pos.offset(dir)
+		if w.GetBlock(neighbor.X, neighbor.Y, neighbor.Z) == f.block {
+			sources++
+		}
+	}
+	return sources
+}
+
+// canPassThrough checks if a fluid can flow through a block while searching holes.
+func (w *World) canPassThrough(f *fluid, state BlockState) bool {
+	return canHoldFluid(state) || (f.contains(state) && f.level(state) != fluidSource)
+}
+
+// isHole checks if a fluid at a position can flow down.
+func (w *World) isHole(f *fluid, pos Position) bool {
+	below := w.GetBlock(pos.X, pos.Y-1, pos.Z)
+	return pos.Y > 0 && (canHoldFluid(below) || f.contains(below))
+}
+
+// flowDirections returns the horizontal directions towards the nearest holes
+// within the slope distance of the fluid, all the open directions if there aren't holes.
+func (w *World) flowDirections(f *fluid, pos Position) []Position {
+	var directions []Position
+	nearest := noSlope
+	for _, dir := range horizontalDirections {
+		target := pos.offset(dir)
+		if !w.canPassThrough(f, w.GetBlock(target.X, target.Y, target.Z)) {
+			continue
+		}
+
+		distance := 0
+		if !w.isHole(f, target) {
+			distance = w.slopeDistance(f, target, 1, dir)
+		}
+		if distance < nearest {
+			nearest, directions = distance, nil
+		}
+		if distance == nearest {
+			directions = append(directions, dir)
+		}
+	}
+	return directions
+}
+
+// slopeDistance returns the distance of the nearest hole from a position,
+// without going back in the direction where the fluid comes from.
+func (w *World) slopeDistance(f *fluid, pos Position, depth int, from Position) int {
+	nearest := noSlope
+	for _, dir := range horizontalDirections {
+		if dir.X == -from.X && dir.Z == -from.Z {
+			continue
+		}
+		target := pos.offset(dir)
+		if !w.canPassThrough(f, w.GetBlock(target.X, target.Y, target.Z)) {
+			continue
+		}
+		if w.isHole(f, target) {
+			return depth
+		}
+		if depth < f.slopeDistance {
+			if distance := w.slopeDistance(f, target, depth+1, dir); distance < nearest {
+				nearest = distance
+			}
+		}
+	}
+	return nearest
+}
@@ -0,0 +1,78 @@
+package MinecraftLightServer
+
+import "testing"
+
+// newTestWorld creates a world with the default superflat layers,
+// it stops the test if the world can't be created.
+func newTestWorld(t *testing.T) *World {
+	t.Helper()
+	generator, err := NewFlatGenerator(defaultFlatLayers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewWorld(generator)
+}
+
+func TestSpreadFluid(t *testing.T) {
+	tests := []struct {
+		name  string
+		f     *fluid
+		level int // level of the spreading fluid
+		want  int // level of the fluid on the sides, -1 if it doesn't spread
+	}{
+		{"water source", waterFluid, fluidSource, 1},
+		{"flowing water", waterFluid, 3, 4},
+		{"last water level", waterFluid, 7, -1},
+		{"falling water", waterFluid, fluidFalling, 1},
+		{"lava source", lavaFluid, fluidSource, 2},
+		{"flowing lava", lavaFluid, 2, 4},
+		{"last lava level", lavaFluid, 6, -1},
+		{"falling lava", lavaFluid, fluidFalling, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := newTestWorld(t)
+			pos := Position{0, 10, 0}
+			w.SetBlock(pos.X, pos.Y-1, pos.Z, blockStone)
+			for _, dir := range horizontalDirections {
+				side := pos.offset(dir)
+				w.SetBlock(side.X, side.Y-1, side.Z, blockStone)
+			}
+
+			w.spreadFluid(test.f, pos, test.f.block+BlockState(test.level))
+			for _, dir := range horizontalDirections {
+				side := pos.offset(dir)
+				want := blockAir
+				if test.want >= 0 {
+					want = test.f.block + BlockState(test.want)
+				}
+				if got := w.GetBlock(side.X, side.Y, side.Z); got != want {
+					t.Errorf("the block at %v is %d, want %d", side, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTickScheduledAfterJump(t *testing.T) {
+	w := newTestWorld(t)
+	pos := Position{0, 10, 0}
+	w.SetBlock(pos.X, pos.Y-1, pos.Z, blockStone)
+	for _, dir := range horizontalDirections {
+		side := pos.offset(dir)
+		w.SetBlock(side.X, side.Y-1, side.Z, blockStone)
+	}
+	w.SetBlock(pos.X, pos.Y, pos.Z, blockWater)
+	w.ScheduleTick(pos, waterFluid.delay)
+
+	// The age of the world skips the tick when the water should spread
+	w.clock.mutex.Lock()
+	w.clock.age += 100
+	w.clock.mutex.Unlock()
+	w.tickScheduled()
+	side := pos.offset(horizontalDirections[0])
+	if got := w.GetBlock(side.X, side.Y, side.Z); got != blockWater+1 {
+		t.Errorf("the block next to the source is %d, want %d", got, blockWater+1)
+	}
+}
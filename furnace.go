@@ -0,0 +1,194 @@
+package MinecraftLightServer
+
+import "strconv"
+
+// Furnace slots.
+const (
+	furnaceIngredientSlot = 0 // item that is cooked
+	furnaceFuelSlot       = 1 // item that is burned
+	furnaceResultSlot     = 2 // cooked items
+)
+
+// Furnace window properties, sent to the players that opened it.
+const (
+	furnaceBurnTimeProperty     = iota // fire icon, ticks left before the fuel runs out
+	furnaceBurnDurationProperty        // ticks of the fuel that is burning
+	furnaceCookTimeProperty            // progress arrow, ticks spent cooking
+	furnaceCookTotalProperty           // ticks needed to cook the ingredient
+)
+
+// furnaceFuels are the ticks that the fuel items burn for.
+var furnaceFuels = func() map[string]int {
+	fuels := map[string]int{
+		"minecraft:lava_bucket": 20000, "minecraft:coal_block": 16000, "minecraft:dried_kelp_block": 4001,
+		"minecraft:blaze_rod": 2400, "minecraft:coal": 1600, "minecraft:charcoal": 1600,
+		"minecraft:crafting_table": 300, "minecraft:chest": 300, "minecraft:bookshelf": 300,
+		"minecraft:stick": 100, "minecraft:bamboo": 50,
+	}
+	// The nether wood doesn't burn
+	wooden := map[string]int{
+		"_log": 300, "_wood": 300, "_planks": 300, "_fence": 300, "_fence_gate": 300,
+		"_stairs": 300, "_slab": 150, "_door": 200, "_trapdoor": 300, "_sapling": 100,
+	}
+	for _, wood := range woodValues {
+		for suffix, ticks := range wooden {
+			fuels["minecraft:"+wood+suffix] = ticks
+		}
+		fuels["minecraft:stripped_"+wood+"_log"] = 300
+		fuels["minecraft:stripped_"+wood+"_wood"] = 300
+	}
+	return fuels
+}()
+
+// furnaceFuelRemainders are the items left in the fuel slot after burning a fuel.
+var furnaceFuelRemainders = map[string]string{
+	"minecraft:lava_bucket": "minecraft:bucket",
+}
+
+// furnace is the fuel and the cooking progress of a furnace container.
+type furnace struct {
+	burnTime     int // ticks left before the fuel runs out
+	burnDuration int // ticks of the fuel that is burning
+	cookTime     int // ticks spent cooking the ingredient
+	cookTotal    int // ticks needed to cook the ingredient
+}
+
+// fuelTicks returns the ticks that an item burns for in a furnace, 0 if it isn't a fuel.
+func fuelTicks(item Slot) int {
+	if item.IsEmpty() {
+		return 0
+	}
+	return furnaceFuels[itemRegistry.Name(item.ItemID)]
+}
+
+// smeltingRecipe returns the smelting recipe of an ingredient, nil if it can't be cooked.
+func smeltingRecipe(item Slot) *Recipe {
+	if item.IsEmpty() {
+		return nil
+	}
+	for _, recipe := range Recipes() {
+		if recipe.Type == RecipeSmelting && recipe.Ingredients[0].matches(item) {
+			return recipe
+		}
+	}
+	return nil
+}
+
+// tickFurnace burns the fuel and cooks the ingredient of a furnace container for a tick,
+// it returns true if the slots have changed. The container must be locked.
+func (c *Container) tickFurnace() bool {
+	f := c.furnace
+	if f.burnTime > 0 {
+		f.burnTime--
+	}
+
+	recipe := smeltingRecipe(c.slots[furnaceIngredientSlot])
+	result := c.slots[furnaceResultSlot]
+	canSmelt := recipe != nil && (result.IsEmpty() ||
+		result.stacksWith(recipe.Result) && int(result.Count)+int(recipe.Result.Count) <= result.maxStack())
+
+	changed := false
+	if f.burnTime == 0 && canSmelt {
+		fuel := c.slots[furnaceFuelSlot]
+		if ticks := fuelTicks(fuel); ticks > 0 {
+			f.burnTime, f.burnDuration = ticks, ticks
+			c.slots[furnaceFuelSlot] = fuel.withCount(int(fuel.Count) - 1)
+			if remainder, ok := furnaceFuelRemainders[itemRegistry.Name(fuel.ItemID)]; ok && fuel.Count == 1 {
+				c.slots[furnaceFuelSlot], _ = NewItem(remainder, 1)
+			}
+			changed = true
+		}
+	}
+
+	switch {
+	case f.burnTime > 0 && canSmelt:
+		f.cookTotal = recipe.CookingTime
+		f.cookTime++
+		if f.cookTime >= f.cookTotal {
+			f.cookTime = 0
+			ingredient := c.slots[furnaceIngredientSlot]
+			c.slots[furnaceIngredientSlot] = ingredient.withCount(int(ingredient.Count) - 1)
+			if result.IsEmpty() {
+				c.slots[furnaceResultSlot] = recipe.Result
+			} else {
+				c.slots[furnaceResultSlot] = result.withCount(int(result.Count) + int(recipe.Result.Count))
+			}
+			changed = true
+		}
+	case f.burnTime > 0:
+		f.cookTime = 0
+	case f.cookTime > 0:
+		// Without fuel the progress goes back slowly
+		f.cookTime -= 2
+		if f.cookTime < 0 {
+			f.cookTime = 0
+		}
+	}
+	return changed
+}
+
+// properties returns the window properties of the furnace.
+func (f *furnace) properties() [4]int {
+	return [4]int{f.burnTime, f.burnDuration, f.cookTime, f.cookTotal}
+}
+
+// tickFurnaces burns the fuel and cooks the items of the furnaces, the ones that
+// haven't been opened since the world was loaded start cooking when they're used.
+// A furnace is lit while it burns a fuel.
+func (w *World) tickFurnaces() {
+	w.containers.mutex.Lock()
+	furnaces := make(map[Position]*Container)
+	for pos, c := range w.containers.containers {
+		if c.furnace != nil {
+			furnaces[pos] = c
+		}
+	}
+	w.containers.mutex.Unlock()
+
+	for pos, c := range furnaces {
+		c.mutex.Lock()
+		before := c.furnace.properties()
+		changed := c.tickFurnace()
+		after := c.furnace.properties()
+		slots := append([]Slot(nil), c.slots...)
+		viewers := c.viewersLocked()
+		c.mutex.Unlock()
+
+		if lit := after[furnaceBurnTimeProperty] > 0; lit != (before[furnaceBurnTimeProperty] > 0) {
+			state := w.GetBlock(pos.X, pos.Y, pos.Z)
+			if state.Name() == "minecraft:furnace" {
+				w.SetBlock(pos.X, pos.Y, pos.Z, state.With("lit", strconv.FormatBool(lit)))
+			}
+		}
+		for p, window := range viewers {
+			for property := range after {
+				if after[property] != before[property] {
+					_ = p.writeWindowProperty(window, property, after[property])
+				}
+			}
+			if changed {
+				for slot, item := range slots {
+					_ = p.writeSetSlot(window, slot, item)
+				}
+			}
+		}
+	}
+}
+
+// writeFurnaceProperties sends the fuel and the cooking progress of a furnace window.
+func (p *Player) writeFurnaceProperties(window int, c *Container) error {
+	c.mutex.Lock()
+	properties := c.furnace.properties()
+	c.mutex.Unlock()
+	for property, value := range properties {
+		if err := p.writeWindowProperty(window, property, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeWindowProperty sends a value shown by a window, like the progress of a furnace.
+func (p *Player) writeWindowProperty(window, property, value int) error {
+	return NewPacket(windowPropertyPacketID, UnsignedByte(window), Short(property), Short(value)).Pack(p.connection)
+}
@@ -0,0 +1,66 @@
+package MinecraftLightServer
+
+import "testing"
+
+// runFurnaceTicks cooks the items of the furnaces of a world for a number of ticks.
+func runFurnaceTicks(w *World, ticks int) {
+	for i := 0; i < ticks; i++ {
+		w.tickFurnaces()
+	}
+}
+
+func TestFurnaceSmelting(t *testing.T) {
+	w := newTestWorld(t)
+	pos := Position{0, 10, 0}
+	w.SetBlock(pos.X, pos.Y, pos.Z, testBlock(t, "minecraft:furnace"))
+	c := w.blockContainer(pos)
+	c.SetSlot(furnaceIngredientSlot, testItem(t, "cobblestone", 3))
+	c.SetSlot(furnaceFuelSlot, testItem(t, "stick", 3))
+
+	runFurnaceTicks(w, 1)
+	if c.Slot(furnaceFuelSlot).Count != 2 || w.GetBlock(pos.X, pos.Y, pos.Z).Property("lit") != "true" {
+		t.Fatal("the furnace hasn't started burning the fuel")
+	}
+	runFurnaceTicks(w, defaultCookTime-1)
+	if result := c.Slot(furnaceResultSlot); !result.Equals(testItem(t, "stone", 1)) {
+		t.Fatalf("the result is %v, want 1 stone", result)
+	}
+
+	// A stick burns for 100 ticks, the third one cooks half of the next item
+	runFurnaceTicks(w, defaultCookTime/2+25)
+	if !c.Slot(furnaceFuelSlot).IsEmpty() || c.Slot(furnaceIngredientSlot).Count != 2 {
+		t.Fatal("the furnace cooked without fuel")
+	}
+	if w.GetBlock(pos.X, pos.Y, pos.Z).Property("lit") != "false" {
+		t.Error("the furnace is still lit without fuel")
+	}
+	if progress := c.furnace.cookTime; progress != defaultCookTime/2-50 {
+		t.Errorf("the cooking progress is %d, want %d", progress, defaultCookTime/2-50)
+	}
+
+	// The lava bucket leaves an empty bucket
+	c.SetSlot(furnaceFuelSlot, testItem(t, "lava_bucket", 1))
+	runFurnaceTicks(w, defaultCookTime)
+	if fuel := c.Slot(furnaceFuelSlot); !fuel.Equals(testItem(t, "bucket", 1)) {
+		t.Errorf("the fuel slot contains %v, want a bucket", fuel)
+	}
+	if c.Slot(furnaceResultSlot).Count != 2 {
+		t.Errorf("the result is %v, want 2 stone", c.Slot(furnaceResultSlot))
+	}
+}
+
+func TestFurnaceFullResult(t *testing.T) {
+	w := newTestWorld(t)
+	pos := Position{0, 10, 0}
+	w.SetBlock(pos.X, pos.Y, pos.Z, testBlock(t, "minecraft:furnace"))
+	c := w.blockContainer(pos)
+	c.SetSlot(furnaceIngredientSlot, testItem(t, "cobblestone", 1))
+	c.SetSlot(furnaceFuelSlot, testItem(t, "coal", 1))
+	c.SetSlot(furnaceResultSlot, testItem(t, "stone", 64))
+
+	// The fuel isn't burned if the result doesn't fit
+	runFurnaceTicks(w, defaultCookTime)
+	if c.Slot(furnaceFuelSlot).Count != 1 || c.Slot(furnaceIngredientSlot).Count != 1 {
+		t.Error("the furnace cooked with a full result slot")
+	}
+}
@@ -0,0 +1,55 @@
+package MinecraftLightServer
+
+import "errors"
+
+// GameMode is the game mode of a player.
+type GameMode int
+
+// Game modes, with their protocol IDs.
+const (
+	GameModeSurvival  GameMode = iota // players can be hurt and have to gather items
+	GameModeCreative                  // players can fly and take any item
+	GameModeAdventure                 // players can't break blocks
+	GameModeSpectator                 // players fly through blocks
+)
+
+// gameStateGameMode is the Change Game State reason that changes the game mode.
+const gameStateGameMode = 3
+
+// String returns the name of the game mode.
+func (m GameMode) String() string {
+	switch m {
+	case GameModeCreative:
+		return "creative"
+	case GameModeAdventure:
+		return "adventure"
+	case GameModeSpectator:
+		return "spectator"
+	}
+	return "survival"
+}
+
+// ParseGameMode returns the game mode with the specified name.
+func ParseGameMode(name string) (GameMode, error) {
+	for mode := GameModeSurvival; mode <= GameModeSpectator; mode++ {
+		if mode.String() == name {
+			return mode, nil
+		}
+	}
+	return GameModeSurvival, errors.New("unknown game mode: " + name)
+}
+
+// GameMode returns the game mode of the player.
+func (p *Player) GameMode() GameMode {
+	p.gameMode.mutex.RLock()
+	defer p.gameMode.mutex.RUnlock()
+	return p.gameMode.mode
+}
+
+// SetGameMode changes the game mode of the player and sends it to its client.
+func (p *Player) SetGameMode(mode GameMode) error {
+	p.gameMode.mutex.Lock()
+	p.gameMode.mode = mode
+	p.gameMode.mutex.Unlock()
+	return p.writeChangeGameState(gameStateGameMode, float32(mode))
+}
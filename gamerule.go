@@ -0,0 +1,270 @@
+package MinecraftLightServer
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// GameRuleType is the type of the value of a game rule.
+type GameRuleType int
+
+// Game rule types.
+const (
+	BoolGameRule GameRuleType = iota // true or false
+	IntGameRule                      // integer number
+)
+
+// GameRule is the definition of a game rule.
+type GameRule struct {
+	Name    string       // name used by the gamerule command and in level.dat, like "keepInventory"
+	Type    GameRuleType // type of the value
+	Default int          // default value, 0 or 1 for bool rules
+}
+
+// Entity Status values that toggle the reduced debug info of a player.
+const (
+	entityStatusReducedDebugInfo = 22
+	entityStatusFullDebugInfo    = 23
+)
+
+// gameStateImmediateRespawn is the Change Game State reason that toggles the respawn screen.
+const gameStateImmediateRespawn = 11
+
+// gameRuleDefinitions are the known game rules by name.
+var gameRuleDefinitions = struct {
+	rules map[string]GameRule
+	mutex sync.RWMutex
+}{rules: make(map[string]GameRule)}
+
+// builtinGameRules are the vanilla game rules with their default values.
+var builtinGameRules = []GameRule{
+	{"announceAdvancements", BoolGameRule, 1},
+	{"commandBlockOutput", BoolGameRule, 1},
+	{"disableElytraMovementCheck", BoolGameRule, 0},
+	{"disableRaids", BoolGameRule, 0},
+	{"doDaylightCycle", BoolGameRule, 1},
+	{"doEntityDrops", BoolGameRule, 1},
+	{"doFireTick", BoolGameRule, 1},
+	{"doImmediateRespawn", BoolGameRule, 0},
+	{"doInsomnia", BoolGameRule, 1},
+	{"doLimitedCrafting", BoolGameRule, 0},
+	{"doMobLoot", BoolGameRule, 1},
+	{"doMobSpawning", BoolGameRule, 1},
+	{"doPatrolSpawning", BoolGameRule, 1},
+	{"doTileDrops", BoolGameRule, 1},
+	{"doTraderSpawning", BoolGameRule, 1},
+	{"doWeatherCycle", BoolGameRule, 1},
+	{"drowningDamage", BoolGameRule, 1},
+	{"fallDamage", BoolGameRule, 1},
+	{"fireDamage", BoolGameRule, 1},
+	{"forgiveDeadPlayers", BoolGameRule, 1},
+	{"keepInventory", BoolGameRule, 0},
+	{"logAdminCommands", BoolGameRule, 1},
+	{"maxCommandChainLength", IntGameRule, 65536},
+	{"maxEntityCramming", IntGameRule, 24},
+	{"mobGriefing", BoolGameRule, 1},
+	{"naturalRegeneration", BoolGameRule, 1},
+	{"randomTickSpeed", IntGameRule, 3},
+	{"reducedDebugInfo", BoolGameRule, 0},
+	{"sendCommandFeedback", BoolGameRule, 1},
+	{"showDeathMessages", BoolGameRule, 1},
+	{"spawnRadius", IntGameRule, 10},
+	{"spectatorsGenerateChunks", BoolGameRule, 1},
+	{"universalAnger", BoolGameRule, 0},
+}
+
+func init() {
+	for _, rule := range builtinGameRules {
+		gameRuleDefinitions.rules[rule.Name] = rule
+	}
+}
+
+// RegisterGameRule adds a custom game rule, available in all the worlds.
+func RegisterGameRule(rule GameRule) error {
+	gameRuleDefinitions.mutex.Lock()
+	defer gameRuleDefinitions.mutex.Unlock()
+	if _, ok := gameRuleDefinitions.rules[rule.Name]; ok {
+		return errors.New("game rule already registered: " + rule.Name)
+	}
+	gameRuleDefinitions.rules[rule.Name] = rule
+	return nil
+}
+
+// gameRuleDefinition returns the definition of a game rule.
+func gameRuleDefinition(name string) (GameRule, bool) {
+	gameRuleDefinitions.mutex.RLock()
+	defer gameRuleDefinitions.mutex.RUnlock()
+	rule, ok := gameRuleDefinitions.rules[name]
+	return rule, ok
+}
+
+// GameRuleNames returns the names of all the game rules, sorted.
+func GameRuleNames() []string {
+	gameRuleDefinitions.mutex.RLock()
+	defer gameRuleDefinitions.mutex.RUnlock()
+	names := make([]string, 0, len(gameRuleDefinitions.rules))
+	for name := range gameRuleDefinitions.rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GameRules are the game rule values of a world.
+type GameRules struct {
+	values    map[string]int      // values different from the default
+	listeners map[string][]func() // functions called when a rule changes
+	world     *World              // world of the rules, notified of changes
+	mutex     sync.RWMutex        // game rules mutex
+}
+
+// newGameRules creates the default game rules of a world.
+func newGameRules(w *World) *GameRules {
+	return &GameRules{values: make(map[string]int), listeners: make(map[string][]func()), world: w}
+}
+
+// value returns the value of a game rule, the default one if it hasn't been changed.
+func (g *GameRules) value(name string) int {
+	g.mutex.RLock()
+	value, ok := g.values[name]
+	g.mutex.RUnlock()
+	if !ok {
+		rule, _ := gameRuleDefinition(name)
+		value = rule.Default
+	}
+	return value
+}
+
+// Bool returns the value of a bool game rule, false if it doesn't exist.
+func (g *GameRules) Bool(name string) bool {
+	return g.value(name) != 0
+}
+
+// Int returns the value of an int game rule, 0 if it doesn't exist.
+func (g *GameRules) Int(name string) int {
+	return g.value(name)
+}
+
+// SetBool changes the value of a bool game rule.
+func (g *GameRules) SetBool(name string, value bool) error {
+	rule, ok := gameRuleDefinition(name)
+	if !ok || rule.Type != BoolGameRule {
+		return errors.New("unknown bool game rule: " + name)
+	}
+	number := 0
+	if value {
+		number = 1
+	}
+	g.set(name, number)
+	return nil
+}
+
+// SetInt changes the value of an int game rule.
+func (g *GameRules) SetInt(name string, value int) error {
+	rule, ok := gameRuleDefinition(name)
+	if !ok || rule.Type != IntGameRule {
+		return errors.New("unknown int game rule: " + name)
+	}
+	g.set(name, value)
+	return nil
+}
+
+// Get returns the value of a game rule as text, as shown by the gamerule command.
+func (g *GameRules) Get(name string) (string, error) {
+	rule, ok := gameRuleDefinition(name)
+	if !ok {
+		return "", errors.New("unknown game rule: " + name)
+	}
+	if rule.Type == BoolGameRule {
+		return strconv.FormatBool(g.Bool(name)), nil
+	}
+	return strconv.Itoa(g.Int(name)), nil
+}
+
+// Set parses the text value of a game rule and changes it.
+func (g *GameRules) Set(name, value string) error {
+	rule, ok := gameRuleDefinition(name)
+	if !ok {
+		return errors.New("unknown game rule: " + name)
+	}
+	if rule.Type == BoolGameRule {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("invalid bool value: " + value)
+		}
+		return g.SetBool(name, enabled)
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.New("invalid int value: " + value)
+	}
+	return g.SetInt(name, number)
+}
+
+// set changes the value of a game rule and notifies its listeners if it's different.
+func (g *GameRules) set(name string, value int) {
+	if g.value(name) == value {
+		return
+	}
+
+	g.mutex.Lock()
+	g.values[name] = value
+	listeners := g.listeners[name]
+	g.mutex.Unlock()
+
+	for _, listener := range listeners {
+		listener()
+	}
+	if g.world != nil {
+		g.world.changes.mutex.Lock()
+		g.world.changes.gameRules = append(g.world.changes.gameRules, name)
+		g.world.changes.mutex.Unlock()
+	}
+}
+
+// OnChange adds a function that's called every time a game rule changes value.
+func (g *GameRules) OnChange(name string, listener func()) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.listeners[name] = append(g.listeners[name], listener)
+}
+
+// nbt returns the game rules as they're stored in level.dat, with text values.
+func (g *GameRules) nbt() NBTCompound {
+	compound := make(NBTCompound)
+	for _, name := range GameRuleNames() {
+		compound[name], _ = g.Get(name)
+	}
+	return compound
+}
+
+// loadNBT changes the game rules using the text values stored in level.dat,
+// unknown rules are ignored.
+func (g *GameRules) loadNBT(compound NBTCompound) {
+	for name, value := range compound {
+		if value, ok := value.(string); ok {
+			_ = g.Set(name, value)
+		}
+	}
+}
+
+// writeGameRule sends a changed game rule to the client if it affects it.
+func (p *Player) writeGameRule(w *World, name string) error {
+	switch name {
+	case "reducedDebugInfo":
+		status := entityStatusFullDebugInfo
+		if w.rules.Bool(name) {
+			status = entityStatusReducedDebugInfo
+		}
+		return NewPacket(entityStatusPacketID, Int(p.int32FromUUID()), Byte(status)).Pack(p.connection)
+	case "doImmediateRespawn":
+		value := float32(0)
+		if w.rules.Bool(name) {
+			value = 1
+		}
+		return p.writeChangeGameState(gameStateImmediateRespawn, value)
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package MinecraftLightServer
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// defaultFlatLayers is the layer string of the vanilla "Classic Flat" preset.
+const defaultFlatLayers = "minecraft:bedrock,2*minecraft:dirt,minecraft:grass_block;minecraft:plains"
+
+// ChunkGenerator creates the content of the chunks that aren't stored in a World.
+type ChunkGenerator interface {
+	// Generate returns a new chunk at the specified chunk coordinates.
+	Generate(cx, cz int) *Chunk
+}
+
+// VoidGenerator generates chunks made only of air.
+type VoidGenerator struct{}
+
+// Generate returns an empty chunk.
+func (VoidGenerator) Generate(cx, cz int) *Chunk {
+	c := NewChunk(cx, cz)
+	if id, ok := BiomeID("minecraft:the_void"); ok {
+		c.FillBiome(id)
+	}
+	return c
+}
+
+// FlatGenerator generates superflat chunks made of horizontal layers.
+type FlatGenerator struct {
+	layers []BlockState // block of each layer, starting from y = 0
+	biome  int          // biome ID of the chunks
+}
+
+// NewFlatGenerator creates a superflat generator using a layer string
+// like vanilla's, for example "minecraft:bedrock,2*minecraft:dirt,minecraft:grass_block;minecraft:plains".
+// Layers are separated by a comma and listed from the bottom, an optional
+// count (N*) repeats the layer and the biome follows the semicolon.
+func NewFlatGenerator(layers string) (*FlatGenerator, error) {
+	g := &FlatGenerator{biome: defaultBiome}
+
+	// Split layers and biome
+	parts := strings.SplitN(layers, ";", 2)
+	if len(parts) == 2 && parts[1] != "" {
+		biome, ok := BiomeID(strings.TrimSpace(parts[1]))
+		if !ok {
+			return nil, errors.New("unknown biome: " + parts[1])
+		}
+		g.biome = biome
+	}
+
+	for _, layer := range strings.Split(parts[0], ",") {
+		layer = strings.TrimSpace(layer)
+		if layer == "" {
+			continue
+		}
+
+		// Parse optional layer height
+		count := 1
+		if i := strings.Index(layer, "*"); i != -1 {
+			var err error
+			if count, err = strconv.Atoi(layer[:i]); err != nil || count < 1 {
+				return nil, errors.New("invalid layer height: " + layer)
+			}
+			layer = layer[i+1:]
+		}
+
+		state, err := ParseBlockState(layer)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < count; i++ {
+			g.layers = append(g.layers, state)
+		}
+	}
+
+	if len(g.layers) > chunkHeight {
+		return nil, errors.New("too many layers")
+	}
+	return g, nil
+}
+
+// Generate returns a chunk filled with the generator layers.
+func (g *FlatGenerator) Generate(cx, cz int) *Chunk {
+	c := NewChunk(cx, cz)
+	c.FillBiome(g.biome)
+	for y, state := range g.layers {
+		c.Fill(y, y, state)
+	}
+	return c
+}
+
+// Noise generator settings.
+const (
+	seaLevel      = 62   // water level of the noise generator
+	caveMinHeight = 5    // lowest block that caves can carve
+	treeMargin    = 2    // distance of trees from chunk borders
+	caveThreshold = 0.07 // maximum noise distance from zero of cave tunnels
+)
+
+// terrainBiome defines how the surface of a noise generator biome looks.
+type terrainBiome struct {
+	name       string       // namespaced biome name
+	top        BlockState   // surface block
+	filler     BlockState   // blocks under the surface
+	treeChance float64      // probability of a tree for each column
+	trunk      BlockState   // tree trunk block
+	leaves     BlockState   // tree leaves block
+	plants     []BlockState // small plants that grow on the surface
+	plantRate  float64      // probability of a plant for each column
+}
+
+// Biomes used by the noise generator.
+var (
+	biomeOcean       = &terrainBiome{name: "minecraft:ocean", top: blockGravel, filler: blockGravel}
+	biomeBeach       = &terrainBiome{name: "minecraft:beach", top: blockSand, filler: blockSand}
+	biomePlains      = &terrainBiome{name: "minecraft:plains", top: blockGrass, filler: blockDirt, treeChance: 0.002, trunk: blockOakLog, leaves: blockOakLeaves, plants: []BlockState{blockTallGrass, blockTallGrass, blockDandelion, blockPoppy}, plantRate: 0.15}
+	biomeForest      = &terrainBiome{name: "minecraft:forest", top: blockGrass, filler: blockDirt, treeChance: 0.05, trunk: blockOakLog, leaves: blockOakLeaves, plants: []BlockState{blockTallGrass}, plantRate: 0.05}
+	biomeBirchForest = &terrainBiome{name: "minecraft:birch_forest", top: blockGrass, filler: blockDirt, treeChance: 0.05, trunk: blockBirchLog, leaves: blockBirchLeaves, plants: []BlockState{blockTallGrass, blockPoppy}, plantRate: 0.05}
+	biomeTaiga       = &terrainBiome{name: "minecraft:taiga", top: blockGrass, filler: blockDirt, treeChance: 0.04, trunk: blockSpruceLog, leaves: blockSpruceLeaves, plants: []BlockState{blockTallGrass}, plantRate: 0.04}
+	biomeDesert      = &terrainBiome{name: "minecraft:desert", top: blockSand, filler: blockSandstone, plants: []BlockState{blockDeadBush, blockCactus}, plantRate: 0.01}
+	biomeSnowy       = &terrainBiome{name: "minecraft:snowy_tundra", top: blockGrass, filler: blockDirt, treeChance: 0.003, trunk: blockSpruceLog, leaves: blockSpruceLeaves}
+	biomeMountains   = &terrainBiome{name: "minecraft:mountains", top: blockStone, filler: blockStone}
+)
+
+// NoiseGenerator generates natural terrain using Perlin noise heightmaps,
+// with biomes, caves and trees.
+type NoiseGenerator struct {
+	seed        int64        // world seed
+	continent   *perlinNoise // large scale height
+	detail      *perlinNoise // small scale height
+	temperature *perlinNoise // biome temperature
+	humidity    *perlinNoise // biome humidity
+	caveA       *perlinNoise // first cave tunnels noise
+	caveB       *perlinNoise // second cave tunnels noise
+}
+
+// NewNoiseGenerator creates a new terrain generator using seed.
+// The same seed always generates the same world.
+func NewNoiseGenerator(seed int64) *NoiseGenerator {
+	return &NoiseGenerator{
+		seed:        seed,
+		continent:   newPerlinNoise(seed),
+		detail:      newPerlinNoise(seed + 1),
+		temperature: newPerlinNoise(seed + 2),
+		humidity:    newPerlinNoise(seed + 3),
+		caveA:       newPerlinNoise(seed + 4),
+		caveB:       newPerlinNoise(seed + 5),
+	}
+}
+
+// columnHeight returns the surface height and the continent value of a column.
+func (g *NoiseGenerator) columnHeight(x, z int) (int, float64) {
+	continent := g.continent.octaves2(float64(x)/256, float64(z)/256, 4)
+	detail := g.detail.octaves2(float64(x)/64, float64(z)/64, 3)
+
+	// Continent defines oceans and mountains, detail the hills
+	height := float64(seaLevel+4) + continent*30 + detail*8
+	if continent > 0.25 {
+		height += (continent - 0.25) * 160 * (detail + 1) / 2
+	}
+	if height > chunkHeight-16 {
+		height = chunkHeight - 16
+	}
+	return int(height), continent
+}
+
+// columnBiome returns the biome of a column.
+func (g *NoiseGenerator) columnBiome(x, z, height int, continent float64) *terrainBiome {
+	temperature := g.temperature.octaves2(float64(x)/512, float64(z)/512, 2)
+	humidity := g.humidity.octaves2(float64(x)/512, float64(z)/512, 2)
+
+	switch {
+	case height < seaLevel-1:
+		return biomeOcean
+	case height <= seaLevel+1:
+		return biomeBeach
+	case continent > 0.35:
+		return biomeMountains
+	case temperature < -0.3:
+		return biomeSnowy
+	case temperature > 0.25 && humidity < 0:
+		return biomeDesert
+	case temperature < -0.1:
+		return biomeTaiga
+	case humidity > 0.25:
+		return biomeBirchForest
+	case humidity > 0:
+		return biomeForest
+	default:
+		return biomePlains
+	}
+}
+
+// isCave checks if a block must be carved by caves.
+func (g *NoiseGenerator) isCave(x, y, z int) bool {
+	fx, fy, fz := float64(x)/32, float64(y)/20, float64(z)/32
+	a := g.caveA.noise3(fx, fy, fz)
+	b := g.caveB.noise3(fx, fy, fz)
+	return a*a+b*b < caveThreshold*caveThreshold
+}
+
+// Generate returns a new natural terrain chunk.
+func (g *NoiseGenerator) Generate(cx, cz int) *Chunk {
+	c := NewChunk(cx, cz)
+	random := rand.New(rand.NewSource(g.seed ^ int64(cx)*341873128712 ^ int64(cz)*132897987541))
+
+	var heights [16][16]int
+	var biomes [16][16]*terrainBiome
+	for z := 0; z < 16; z++ {
+		for x := 0; x < 16; x++ {
+			worldX, worldZ := cx*16+x, cz*16+z
+			height, continent := g.columnHeight(worldX, worldZ)
+			biome := g.columnBiome(worldX, worldZ, height, continent)
+			heights[x][z], biomes[x][z] = height, biome
+
+			for y := 0; y <= height; y++ {
+				state := blockStone
+				switch {
+				case y == 0:
+					state = blockBedrock
+				case y == height:
+					state = biome.top
+				case y > height-4:
+					state = biome.filler
+				case y > caveMinHeight && g.isCave(worldX, y, worldZ):
+					state = blockAir
+				default:
+					state = g.ore(random, y)
+				}
+				c.setBlock(x, y, z, state)
+			}
+
+			// Fill oceans with water
+			for y := height + 1; y <= seaLevel; y++ {
+				if y == seaLevel && biome == biomeSnowy {
+					c.setBlock(x, y, z, blockIce)
+				} else {
+					c.setBlock(x, y, z, blockWater)
+				}
+			}
+
+			// Mountain tops and snowy plains are covered by snow
+			if height > seaLevel && (biome == biomeSnowy || (biome == biomeMountains && height > 110)) {
+				c.setBlock(x, height+1, z, blockSnow)
+			}
+		}
+	}
+
+	// Each biome cell uses the biome of its center column
+	for z := 0; z < 16; z += 4 {
+		for x := 0; x < 16; x += 4 {
+			if id, ok := BiomeID(biomes[x+2][z+2].name); ok {
+				c.SetColumnBiome(x, z, id)
+			}
+		}
+	}
+
+	// Decorate chunk surface
+	set := func(x, y, z int, state BlockState) { c.setBlock(x, y, z, state) }
+	for z := treeMargin; z < 16-treeMargin; z++ {
+		for x := treeMargin; x < 16-treeMargin; x++ {
+			height, biome := heights[x][z], biomes[x][z]
+			if height <= seaLevel || c.getBlock(x, height+1, z) != blockAir {
+				continue
+			}
+
+			if biome.top == blockGrass && random.Float64() < biome.treeChance {
+				if biome.trunk == blockSpruceLog {
+					spruceTree(set, random, x, height+1, z, biome.trunk, biome.leaves)
+				} else {
+					tree(set, random, x, height+1, z, biome.trunk, biome.leaves)
+				}
+			} else if len(biome.plants) > 0 && random.Float64() < biome.plantRate {
+				plant := biome.plants[random.Intn(len(biome.plants))]
+				c.setBlock(x, height+1, z, plant)
+			}
+		}
+	}
+
+	return c
+}
+
+// ore returns the block of an underground position, stone or an ore.
+func (g *NoiseGenerator) ore(random *rand.Rand, y int) BlockState {
+	r := random.Float64()
+	switch {
+	case y < 16 && r < 0.001:
+		return blockDiamondOre
+	case y < 32 && r < 0.002:
+		return blockGoldOre
+	case y < 64 && r < 0.008:
+		return blockIronOre
+	case r < 0.012:
+		return blockCoalOre
+	}
+	return blockStone
+}
+
+// blockSetter places a block, it lets chunk generation and the world share the same structures.
+type blockSetter func(x, y, z int, state BlockState)
+
+// tree places an oak-like tree with the trunk starting at x, y, z.
+func tree(set blockSetter, random *rand.Rand, x, y, z int, trunk, leaves BlockState) {
+	height := 4 + random.Intn(3)
+	top := y + height - 1
+
+	// Leaves: two large layers and two small layers
+	for ly := top - 2; ly <= top+1; ly++ {
+		radius := 2
+		if ly >= top {
+			radius = 1
+		}
+		for dz := -radius; dz <= radius; dz++ {
+			for dx := -radius; dx <= radius; dx++ {
+				// Random corners
+				if (dx == -radius || dx == radius) && (dz == -radius || dz == radius) &&
+					(ly == top+1 || random.Intn(2) == 0) {
+					continue
+				}
+				set(x+dx, ly, z+dz, leaves)
+			}
+		}
+	}
+
+	// Trunk
+	set(x, y-1, z, blockDirt)
+	for ly := y; ly <= top; ly++ {
+		set(x, ly, z, trunk)
+	}
+}
+
+// spruceTree places a conic tree with the trunk starting at x, y, z.
+func spruceTree(set blockSetter, random *rand.Rand, x, y, z int, trunk, leaves BlockState) {
+	height := 6 + random.Intn(3)
+	top := y + height - 1
+
+	// Leaves: alternating rings that get smaller going up
+	for ly := y + 2; ly <= top+1; ly++ {
+		radius := (top - ly + 2) / 2 % 3
+		if (top-ly)%2 == 1 && radius > 1 {
+			radius = 1
+		}
+		for dz := -radius; dz <= radius; dz++ {
+			for dx := -radius; dx <= radius; dx++ {
+				if radius > 1 && (dx == -radius || dx == radius) && (dz == -radius || dz == radius) {
+					continue
+				}
+				set(x+dx, ly, z+dz, leaves)
+			}
+		}
+	}
+
+	// Trunk
+	set(x, y-1, z, blockDirt)
+	for ly := y; ly <= top; ly++ {
+		set(x, ly, z, trunk)
+	}
+}
@@ -0,0 +1,507 @@
+package MinecraftLightServer
+
+import (
+	"math"
+	"strings"
+	"sync"
+)
+
+// Health and hunger settings, the same as vanilla.
+const (
+	maxHealth            = 20   // health of a player that hasn't been hurt, 10 hearts
+	maxFood              = 20   // food level of a player that isn't hungry
+	defaultSaturation    = 5    // saturation of a player that has just spawned
+	maxExhaustion        = 40   // exhaustion that a player can accumulate
+	exhaustionPerFood    = 4    // exhaustion that consumes a point of saturation or food
+	regenerationFood     = 18   // food level needed to regenerate health
+	regenerationDelay    = 80   // ticks between two health points regenerated or lost to starvation
+	fastRegenDelay       = 10   // ticks between two regenerations with full food and saturation
+	regenExhaustion      = 6    // exhaustion of a health point regenerated
+	peacefulHealDelay    = 20   // ticks between two health points regenerated in peaceful mode
+	peacefulFoodDelay    = 10   // ticks between two food points restored in peaceful mode
+	invulnerabilityTicks = 10   // ticks after a hit in which only stronger hits hurt
+	safeFallDistance     = 3    // blocks a player can fall without being hurt
+	highFallDistance     = 5    // fall distance of the "fell from a high place" death message
+	voidY                = -64  // height below which players are hurt by the void
+	voidDamage           = 4    // damage of the void for each tick
+	damageExhaustion     = 0.1  // exhaustion of a hit
+	sprintExhaustion     = 0.1  // exhaustion of a block travelled while sprinting
+	jumpExhaustion       = 0.05 // exhaustion of a jump
+	sprintJumpExhaustion = 0.2  // exhaustion of a jump while sprinting
+	attackReach          = 6    // distance within which a player can attack another one
+	attackKnockback      = 0.4  // speed given to a player that is attacked
+	fistDamage           = 1    // damage of an attack without weapons
+)
+
+// Entity Status values of the player animations.
+const (
+	entityStatusHurt  = 2 // the player has been hurt
+	entityStatusDeath = 3 // the player has died
+)
+
+// Client Status actions.
+const (
+	clientStatusRespawn = 0 // the player has clicked the respawn button
+)
+
+// Entity Action IDs that change the hunger of the players.
+const (
+	entityActionStartSprinting = 3 // the player has started sprinting
+	entityActionStopSprinting  = 4 // the player has stopped sprinting
+)
+
+// Interact Entity types.
+const (
+	interactAttack = 1 // left click
+)
+
+// combatEventDeath is the Combat Event that opens the death screen.
+const combatEventDeath = 2
+
+// DamageSource is what hurts a player, it chooses the death message.
+type DamageSource int
+
+// Damage sources.
+const (
+	DamageGeneric    DamageSource = iota // damage done by plugins
+	DamageFall                           // the player has landed from high
+	DamageVoid                           // the player has fallen below the world
+	DamageAttack                         // the player has been hit by another player
+	DamageExplosion                      // the player has been hit by an explosion
+	DamageStarvation                     // the player has an empty food bar
+)
+
+// weaponDamages are the attack damages of the weapons by material, for swords and axes.
+var weaponDamages = map[string][2]float32{
+	"wooden":    {4, 7},
+	"stone":     {5, 9},
+	"iron":      {6, 9},
+	"golden":    {4, 7},
+	"diamond":   {7, 9},
+	"netherite": {8, 10},
+}
+
+// health is the health and hunger of a player.
+type health struct {
+	health       float32      // health points, 2 for each heart
+	food         int          // food level, 2 for each drumstick
+	saturation   float32      // food consumed before the food level decreases
+	exhaustion   float32      // consumes saturation when it reaches 4
+	foodTimer    int          // ticks since the last health regenerated or lost to starvation
+	invulnerable int          // ticks before the player can be hurt again by weaker hits
+	lastDamage   float32      // damage of the last hit, only stronger hits hurt while invulnerable
+	fallDistance float64      // blocks fallen since the player has left the ground
+	lastFall     float64      // blocks fallen before the last fall damage
+	sprinting    bool         // the player is sprinting
+	lastSource   DamageSource // source of the last damage
+	attacker     string       // name of the last player that has hit the player
+	dead         bool         // the player is in the death screen
+	hurt         bool         // the player has been hurt during the tick
+	changed      bool         // health or food have changed and must be sent again
+	mutex        sync.Mutex   // health mutex
+}
+
+// resetHealth restores the health and the food of the player, when it joins or respawns.
+func (p *Player) resetHealth() {
+	p.health.mutex.Lock()
+	defer p.health.mutex.Unlock()
+	h := &p.health
+	h.health, h.food, h.saturation, h.exhaustion = maxHealth, maxFood, defaultSaturation, 0
+	h.foodTimer, h.invulnerable, h.lastDamage, h.fallDistance = 0, 0, 0, 0
+	h.dead, h.hurt, h.changed = false, false, true
+}
+
+// Health returns the health points of the player, 2 for each heart.
+func (p *Player) Health() float32 {
+	p.health.mutex.Lock()
+	defer p.health.mutex.Unlock()
+	return p.health.health
+}
+
+// SetHealth changes the health of the player, 0 kills it.
+func (p *Player) SetHealth(health float32) {
+	p.health.mutex.Lock()
+	defer p.health.mutex.Unlock()
+	if p.health.dead {
+		return
+	}
+	p.health.health = float32(math.Max(0, math.Min(maxHealth, float64(health))))
+	p.health.lastSource, p.health.changed = DamageGeneric, true
+}
+
+// Food returns the food level and the saturation of the player.
+func (p *Player) Food() (food int, saturation float32) {
+	p.health.mutex.Lock()
+	defer p.health.mutex.Unlock()
+	return p.health.food, p.health.saturation
+}
+
+// SetFood changes the food level and the saturation of the player,
+// the saturation can't be higher than the food level.
+func (p *Player) SetFood(food int, saturation float32) {
+	p.health.mutex.Lock()
+	defer p.health.mutex.Unlock()
+	if food < 0 {
+		food = 0
+	} else if food > maxFood {
+		food = maxFood
+	}
+	p.health.food = food
+	p.health.saturation = float32(math.Max(0, math.Min(float64(food), float64(saturation))))
+	p.health.changed = true
+}
+
+// IsDead checks if the player is dead and hasn't respawned yet.
+func (p *Player) IsDead() bool {
+	p.health.mutex.Lock()
+	defer p.health.mutex.Unlock()
+	return p.health.dead
+}
+
+// Damage hurts the player, it returns false if the player can't be hurt: players in creative
+// and spectator mode are only hurt by the void, and after a hit only stronger hits hurt for half
+// a second. The player dies on the next tick if its health reaches 0.
+func (p *Player) Damage(amount float32, source DamageSource) bool {
+	return p.damage(amount, source, nil)
+}
+
+// damage hurts the player, attacker is the player that has hit it, if there's one.
+func (p *Player) damage(amount float32, source DamageSource, attacker *Player) bool {
+	mode := p.GameMode()
+	if amount <= 0 || (source != DamageVoid && (mode == GameModeCreative || mode == GameModeSpectator)) {
+		return false
+	}
+	if source == DamageFall && !p.World().rules.Bool("fallDamage") {
+		return false
+	}
+
+	p.health.mutex.Lock()
+	defer p.health.mutex.Unlock()
+	h := &p.health
+	if h.dead || h.health <= 0 {
+		return false
+	}
+	if h.invulnerable > 0 {
+		if amount <= h.lastDamage {
+			return false
+		}
+		amount, h.lastDamage = amount-h.lastDamage, amount
+	} else {
+		h.lastDamage, h.invulnerable = amount, invulnerabilityTicks
+	}
+
+	h.health = float32(math.Max(0, float64(h.health-amount)))
+	h.exhaustion = float32(math.Min(maxExhaustion, float64(h.exhaustion+damageExhaustion)))
+	h.lastSource, h.hurt, h.changed = source, true, true
+	h.attacker = ""
+	if attacker != nil {
+		h.attacker = string(attacker.username)
+	}
+	return true
+}
+
+// exhaust adds exhaustion to the player, it consumes saturation and food.
+func (p *Player) exhaust(exhaustion float32) {
+	p.health.mutex.Lock()
+	defer p.health.mutex.Unlock()
+	p.health.exhaustion = float32(math.Min(maxExhaustion, float64(p.health.exhaustion+exhaustion)))
+}
+
+// setSprinting changes if the player is sprinting, sprinting players get hungry faster.
+func (p *Player) setSprinting(sprinting bool) {
+	p.health.mutex.Lock()
+	defer p.health.mutex.Unlock()
+	p.health.sprinting = sprinting
+}
+
+// moved updates the fall distance and the exhaustion of the player after it has moved,
+// from is the position before the movement. Players are hurt when they land after a fall,
+// dead players are ignored.
+func (p *Player) moved(fromX, fromY, fromZ float64, wasOnGround bool) {
+	if p.IsDead() {
+		return
+	}
+	x, y, z := float64(p.x), float64(p.y), float64(p.z)
+	mode := p.GameMode()
+	inFluid := isFluid(p.World().GetBlock(int(math.Floor(x)), int(math.Floor(y)), int(math.Floor(z))))
+
+	p.health.mutex.Lock()
+	h := &p.health
+	fallen := 0.0
+	switch {
+	case mode == GameModeCreative || mode == GameModeSpectator || inFluid:
+		// Flying players and the ones in water don't fall
+		h.fallDistance = 0
+	case bool(p.onGround):
+		fallen, h.fallDistance = h.fallDistance, 0
+	case y < fromY:
+		h.fallDistance += fromY - y
+	}
+
+	exhaustion := 0.0
+	if h.sprinting {
+		dx, dz := x-fromX, z-fromZ
+		exhaustion += math.Sqrt(dx*dx+dz*dz) * sprintExhaustion
+	}
+	if wasOnGround && !bool(p.onGround) && y > fromY {
+		if h.sprinting {
+			exhaustion += sprintJumpExhaustion
+		} else {
+			exhaustion += jumpExhaustion
+		}
+	}
+	if mode == GameModeSurvival || mode == GameModeAdventure {
+		h.exhaustion = float32(math.Min(maxExhaustion, float64(h.exhaustion)+exhaustion))
+	}
+	p.health.mutex.Unlock()
+
+	if fallen > safeFallDistance && p.damage(float32(math.Ceil(fallen-safeFallDistance)), DamageFall, nil) {
+		p.health.mutex.Lock()
+		p.health.lastFall = fallen
+		p.health.mutex.Unlock()
+	}
+}
+
+// attack makes the player hit another player, with the damage of the weapon that it holds.
+func (p *Player) attack(target *Player) bool {
+	if target == nil || target == p || target.World() != p.World() || p.GameMode() == GameModeSpectator || p.IsDead() {
+		return false
+	}
+	dx, dy, dz := float64(target.x-p.x), float64(target.y-p.y), float64(target.z-p.z)
+	if dx*dx+dy*dy+dz*dz > attackReach*attackReach {
+		return false
+	}
+	if !target.damage(attackDamage(p.inventory.HeldItem()), DamageAttack, p) {
+		return false
+	}
+
+	// The target is pushed in the direction the attacker is looking
+	yaw := float64(p.yawAbs) * math.Pi / 180
+	vx, vy, vz := -math.Sin(yaw)*attackKnockback, attackKnockback, math.Cos(yaw)*attackKnockback
+	_ = NewPacket(entityVelocityPacketID, VarInt(target.int32FromUUID()),
+		Short(vx*velocityUnit), Short(vy*velocityUnit), Short(vz*velocityUnit)).Pack(target.connection)
+	return true
+}
+
+// attackDamage returns the damage of an attack done with an item.
+func attackDamage(item Slot) float32 {
+	name := strings.TrimPrefix(itemRegistry.Name(item.ItemID), "minecraft:")
+	if item.IsEmpty() || !strings.Contains(name, "_") {
+		return fistDamage
+	}
+	i := strings.LastIndexByte(name, '_')
+	damages, ok := weaponDamages[name[:i]]
+	switch {
+	case name == "trident":
+		return 9
+	case ok && name[i+1:] == "sword":
+		return damages[0]
+	case ok && name[i+1:] == "axe":
+		return damages[1]
+	}
+	return fistDamage
+}
+
+// tickHealth regenerates the health of the player, updates its hunger, hurts it in the void
+// and kills it when its health reaches 0. Changes are sent to the player and the hits to
+// the other players.
+func (p *Player) tickHealth(s *Server) error {
+	w := p.World()
+	if p.y < voidY {
+		p.damage(voidDamage, DamageVoid, nil)
+	}
+	difficulty, regeneration := w.Difficulty(), w.rules.Bool("naturalRegeneration")
+	age := w.Age()
+
+	p.health.mutex.Lock()
+	h := &p.health
+	if h.dead {
+		p.health.mutex.Unlock()
+		return nil
+	}
+	if h.invulnerable > 0 {
+		h.invulnerable--
+	}
+	starving := h.tickHunger(difficulty, regeneration)
+
+	// Players in peaceful mode regenerate their health and food
+	if difficulty == DifficultyPeaceful && regeneration && h.health > 0 {
+		if h.health < maxHealth && age%peacefulHealDelay == 0 {
+			h.heal(1)
+		}
+		if h.food < maxFood && age%peacefulFoodDelay == 0 {
+			h.food++
+			h.changed = true
+		}
+	}
+	dead, hurt, changed := h.health <= 0, h.hurt, h.changed
+	h.hurt, h.changed = false, false
+	p.health.mutex.Unlock()
+
+	if starving && p.damage(1, DamageStarvation, nil) {
+		p.health.mutex.Lock()
+		dead, hurt, changed = p.health.health <= 0, true, true
+		p.health.hurt, p.health.changed = false, false
+		p.health.mutex.Unlock()
+	}
+	if hurt {
+		s.broadcastEntityStatus(p, entityStatusHurt)
+	}
+	if dead {
+		return p.die(s)
+	}
+	if changed {
+		return p.writeUpdateHealth()
+	}
+	return nil
+}
+
+// tickHunger consumes the saturation and the food with the exhaustion and regenerates the
+// health when the player isn't hungry, like vanilla. It returns true if the player must be
+// hurt by starvation. The health mutex must be locked.
+func (h *health) tickHunger(difficulty Difficulty, regeneration bool) (starving bool) {
+	if h.exhaustion >= exhaustionPerFood {
+		h.exhaustion -= exhaustionPerFood
+		if h.saturation > 0 {
+			h.saturation = float32(math.Max(0, float64(h.saturation-1)))
+			h.changed = true
+		} else if difficulty != DifficultyPeaceful && h.food > 0 {
+			h.food--
+			h.changed = true
+		}
+	}
+
+	canHeal := h.health > 0 && h.health < maxHealth
+	switch {
+	case regeneration && canHeal && h.saturation > 0 && h.food >= maxFood:
+		h.foodTimer++
+		if h.foodTimer >= fastRegenDelay {
+			amount := float32(math.Min(float64(h.saturation), regenExhaustion))
+			h.heal(amount / regenExhaustion)
+			h.exhaustion = float32(math.Min(maxExhaustion, float64(h.exhaustion+amount)))
+			h.foodTimer = 0
+		}
+	case regeneration && canHeal && h.food >= regenerationFood:
+		h.foodTimer++
+		if h.foodTimer >= regenerationDelay {
+			h.heal(1)
+			h.exhaustion = float32(math.Min(maxExhaustion, float64(h.exhaustion+regenExhaustion)))
+			h.foodTimer = 0
+		}
+	case h.food <= 0:
+		h.foodTimer++
+		if h.foodTimer >= regenerationDelay {
+			h.foodTimer = 0
+			return h.health > maxHealth/2 || difficulty == DifficultyHard ||
+				(h.health > 1 && difficulty == DifficultyNormal)
+		}
+	default:
+		h.foodTimer = 0
+	}
+	return false
+}
+
+// heal adds health points, up to the maximum. The health mutex must be locked.
+func (h *health) heal(amount float32) {
+	h.health = float32(math.Min(maxHealth, float64(h.health+amount)))
+	h.changed = true
+}
+
+// deathMessage returns the message shown when the player dies.
+func (p *Player) deathMessage() string {
+	p.health.mutex.Lock()
+	defer p.health.mutex.Unlock()
+	name := string(p.username)
+	switch p.health.lastSource {
+	case DamageFall:
+		if p.health.lastFall > highFallDistance {
+			return name + " fell from a high place"
+		}
+		return name + " hit the ground too hard"
+	case DamageVoid:
+		return name + " fell out of the world"
+	case DamageAttack:
+		return name + " was slain by " + p.health.attacker
+	case DamageExplosion:
+		return name + " blew up"
+	case DamageStarvation:
+		return name + " starved to death"
+	}
+	return name + " died"
+}
+
+// die kills the player: its items are dropped unless the keepInventory game rule is on,
+// the death message is sent to every player and the client shows the death screen.
+func (p *Player) die(s *Server) error {
+	p.health.mutex.Lock()
+	p.health.dead, p.health.health = true, 0
+	p.health.mutex.Unlock()
+
+	if err := p.CloseWindow(); err != nil {
+		return err
+	}
+	w := p.World()
+	if !w.rules.Bool("keepInventory") {
+		for _, item := range p.inventory.clear() {
+			w.DropItem(p.blockPosition(), item)
+		}
+	}
+
+	message := p.deathMessage()
+	if !w.rules.Bool("showDeathMessages") {
+		message = ""
+	} else {
+		s.broadcastSystemMessage(message)
+	}
+	s.broadcastEntityStatus(p, entityStatusDeath)
+	if err := p.writeUpdateHealth(); err != nil {
+		return err
+	}
+	// The client respawns immediately without the death screen with doImmediateRespawn
+	return NewPacket(combatEventPacketID,
+		VarInt(combatEventDeath),
+		VarInt(p.int32FromUUID()), // player that has died
+		Int(-1),                   // killer entity
+		String(textComponent(message)),
+	).Pack(p.connection)
+}
+
+// performRespawn brings a dead player back to life at its respawn point or around the world spawn.
+func (p *Player) performRespawn(s *Server) error {
+	if !p.IsDead() {
+		return nil
+	}
+	p.resetHealth()
+	w, pos := p.respawnLocation(s)
+
+	// The death screen is closed by Respawn, SwitchWorld sends it only when the world changes
+	if w == p.World() {
+		if err := p.writeRespawn(w); err != nil {
+			return err
+		}
+	}
+	if err := p.SwitchWorld(w, pos); err != nil {
+		return err
+	}
+	if err := p.writeWindowItems(); err != nil {
+		return err
+	}
+	if err := p.SetHeldSlot(p.inventory.HeldSlot()); err != nil {
+		return err
+	}
+	if err := p.writeUpdateHealth(); err != nil {
+		return err
+	}
+	s.broadcastRespawn(p)
+	return nil
+}
+
+// writeUpdateHealth sends the health and the food of the player to its client.
+func (p *Player) writeUpdateHealth() error {
+	p.health.mutex.Lock()
+	packet := NewPacket(updateHealthPacketID,
+		Float(p.health.health), VarInt(p.health.food), Float(p.health.saturation))
+	p.health.changed = false
+	p.health.mutex.Unlock()
+	return packet.Pack(p.connection)
+}
@@ -0,0 +1,76 @@
+package MinecraftLightServer
+
+import "strings"
+
+// Heightmap types.
+const (
+	motionBlocking = iota // highest block that blocks motion or contains a fluid
+	worldSurface          // highest block that isn't air
+	heightmapTypes        // number of heightmap types
+)
+
+// heightmapBits is the number of bits of each heightmap entry (values from 0 to 256).
+const heightmapBits = 9
+
+// heightmapNames are the NBT names of the heightmap types.
+var heightmapNames = [heightmapTypes]string{"MOTION_BLOCKING", "WORLD_SURFACE"}
+
+// passableBlocks are the blocks without collisions, the names without
+// namespace match every block that contains them.
+var passableBlocks = append([]string{"minecraft:air", "minecraft:cave_air", "minecraft:void_air", "torch",
+	"minecraft:fire", "minecraft:soul_fire", "rail", "minecraft:cobweb", "minecraft:redstone_wire", "_sign", "_banner",
+	"minecraft:lever", "_pressure_plate", "_button", "minecraft:tripwire", "minecraft:tripwire_hook",
+	"minecraft:nether_portal", "minecraft:end_portal", "minecraft:structure_void"}, plantBlocks...)
+
+// blocksMotion checks if a block stops entities, it isn't in the vanilla
+// reports so it's found from the block name.
+func blocksMotion(name string) bool {
+	// Potted plants contain the names of the plants
+	return strings.Contains(name, "potted_") || !matchesAny(name, passableBlocks)
+}
+
+// heightmapMatches checks if a block is counted by a heightmap type.
+func heightmapMatches(heightmap int, state BlockState) bool {
+	if heightmap == worldSurface {
+		return state != blockAir
+	}
+	// Fluids are counted, a single snow layer isn't
+	return isFluid(state) || (blockRegistry.BlocksMotion(state) && state != blockSnow)
+}
+
+// updateHeightmaps updates the heightmaps of a column after a block change,
+// without locking.
+func (c *Chunk) updateHeightmaps(x, y, z int, state BlockState) {
+	column := z<<4 | x
+	for heightmap := range c.heightmaps {
+		height := c.heightmaps[heightmap][column]
+		if heightmapMatches(heightmap, state) {
+			if y+1 > height {
+				c.heightmaps[heightmap][column] = y + 1
+			}
+		} else if y+1 == height {
+			// The highest block has been removed, search the next one
+			for height = y; height > 0 && !heightmapMatches(heightmap, c.getBlock(x, height-1, z)); height-- {
+			}
+			c.heightmaps[heightmap][column] = height
+		}
+	}
+}
+
+// Height returns the heightmap value of a column at chunk relative coordinates x, z:
+// the y coordinate above the highest block that blocks motion or contains a fluid.
+func (c *Chunk) Height(x, z int) int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.heightmaps[motionBlocking][z<<4|x]
+}
+
+// heightmapsNBT returns the heightmaps encoded as packed long arrays,
+// the same in the chunk data packet and in the region files.
+func (c *Chunk) heightmapsNBT() NBTCompound {
+	heightmaps := make(NBTCompound)
+	for heightmap, name := range heightmapNames {
+		heightmaps[name] = packLongs(c.heightmaps[heightmap][:], heightmapBits)
+	}
+	return heightmaps
+}
@@ -0,0 +1,288 @@
+package MinecraftLightServer
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Slots of the player window.
+const (
+	inventorySize      = 46 // slots of the player window
+	craftingResultSlot = 0  // result of the 2x2 crafting grid
+	craftingGridSlot   = 1  // first slot of the 2x2 crafting grid
+	craftingGridSize   = 4  // slots of the 2x2 crafting grid
+	armorSlot          = 5  // first armor slot, from the helmet to the boots
+	mainInventorySlot  = 9  // first slot of the main inventory
+	hotbarSlot         = 36 // first slot of the hotbar
+	hotbarSize         = 9  // slots of the hotbar
+	offhandSlot        = 45 // item held in the second hand
+)
+
+// Window settings.
+const (
+	maxStackSize   = 64   // items in a full stack
+	playerWindowID = 0    // window of the player inventory
+	cursorWindowID = -1   // window used to set the item held by the cursor
+	cursorSlot     = -1   // slot used to set the item held by the cursor
+	outsideSlot    = -999 // slot of the clicks outside the window
+	offhandButton  = 40   // button of the swap mode that uses the second hand
+)
+
+// Click Window modes.
+const (
+	clickPickup    = iota // left or right click
+	clickQuickMove        // shift click
+	clickSwap             // number key or second hand key
+	clickClone            // middle click in creative mode
+	clickThrow            // drop key
+	clickDrag             // items spread by dragging the mouse
+	clickPickupAll        // double click
+)
+
+// Drag kinds, they choose how the items are spread.
+const (
+	dragSplit = iota // the items are split evenly
+	dragOne          // one item in each slot
+	dragFill         // full stacks in creative mode
+)
+
+// Inventory is the inventory of a player with the item held by the mouse cursor.
+type Inventory struct {
+	slots  [inventorySize]Slot // items numbered as the slots of the player window
+	cursor Slot                // item held by the cursor
+	held   int                 // selected hotbar slot, from 0 to 8
+	drag   struct {            // items being spread by dragging the mouse
+		active bool  // a drag has started
+		kind   int   // how the items are spread
+		slots  []int // slots crossed by the cursor
+	}
+	mutex sync.Mutex // inventory mutex
+}
+
+// maxStack returns how many items of the slot can be stacked.
+func (s Slot) maxStack() int {
+	return itemRegistry.MaxStack(s.ItemID)
+}
+
+// stacksWith checks if two slots contain the same item with the same data.
+func (s Slot) stacksWith(other Slot) bool {
+	return !s.IsEmpty() && !other.IsEmpty() && s.ItemID == other.ItemID && reflect.DeepEqual(s.NBT, other.NBT)
+}
+
+// withCount returns the items of the slot with another count, it's empty if count is 0.
+func (s Slot) withCount(count int) Slot {
+	if count <= 0 || s.IsEmpty() {
+		return Slot{}
+	}
+	s.Count = int8(count)
+	return s
+}
+
+// Equals checks if two slots contain the same number of the same item.
+func (s Slot) Equals(other Slot) bool {
+	if s.IsEmpty() || other.IsEmpty() {
+		return s.IsEmpty() == other.IsEmpty()
+	}
+	return s.stacksWith(other) && s.Count == other.Count
+}
+
+// isValid checks if a slot sent by a client contains an existing item with a valid count.
+func (s Slot) isValid() bool {
+	return !s.Present || (itemRegistry.Name(s.ItemID) != "" && s.Count > 0 && int(s.Count) <= s.maxStack())
+}
+
+// Slot returns the item in a slot of the player window.
+func (inv *Inventory) Slot(slot int) Slot {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+	if slot < 0 || slot >= inventorySize {
+		return Slot{}
+	}
+	return inv.slots[slot]
+}
+
+// HeldSlot returns the selected hotbar slot, from 0 to 8.
+func (inv *Inventory) HeldSlot() int {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+	return inv.held
+}
+
+// HeldItem returns the item in the main hand.
+func (inv *Inventory) HeldItem() Slot {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+	return inv.slots[hotbarSlot+inv.held]
+}
+
+// add puts items in the inventory, filling the stacks of the same item before the
+// empty slots, and returns the items that don't fit. It doesn't lock the inventory.
+func (inv *Inventory) add(item Slot) Slot {
+	order := []int{hotbarSlot + inv.held, offhandSlot}
+	for slot := hotbarSlot; slot < offhandSlot; slot++ {
+		order = append(order, slot)
+	}
+	for slot := mainInventorySlot; slot < hotbarSlot; slot++ {
+		order = append(order, slot)
+	}
+
+	for _, slot := range order {
+		item = mergeInto(&inv.slots[slot], item)
+	}
+	for _, slot := range order[2:] {
+		if item.IsEmpty() {
+			break
+		}
+		if inv.slots[slot].IsEmpty() {
+			inv.slots[slot], item = item, Slot{}
+		}
+	}
+	return item
+}
+
+// close puts the items of the crafting grid back in the inventory when the player window
+// is closed, it returns the items held by the cursor and the ones that don't fit.
+func (inv *Inventory) close() []Slot {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+	inv.drag.active = false
+
+	var dropped []Slot
+	if !inv.cursor.IsEmpty() {
+		dropped = append(dropped, inv.cursor)
+		inv.cursor = Slot{}
+	}
+	for slot := craftingGridSlot; slot < craftingGridSlot+craftingGridSize; slot++ {
+		item := inv.slots[slot]
+		inv.slots[slot] = Slot{}
+		if left := inv.add(item); !left.IsEmpty() {
+			dropped = append(dropped, left)
+		}
+	}
+	inv.slots[craftingResultSlot] = Slot{}
+	return dropped
+}
+
+// clear empties the inventory and the cursor, it returns the items that were inside.
+func (inv *Inventory) clear() []Slot {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+	var items []Slot
+	for slot := craftingGridSlot; slot < inventorySize; slot++ {
+		if !inv.slots[slot].IsEmpty() {
+			items = append(items, inv.slots[slot])
+		}
+	}
+	if !inv.cursor.IsEmpty() {
+		items = append(items, inv.cursor)
+	}
+	inv.slots, inv.cursor = [inventorySize]Slot{}, Slot{}
+	return items
+}
+
+// setCreative changes a slot with an item chosen in the creative inventory,
+// it returns false if the slot or the item aren't valid.
+func (inv *Inventory) setCreative(slot int, item Slot) bool {
+	if slot < craftingGridSlot || slot >= inventorySize || !item.isValid() {
+		return false
+	}
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+	inv.slots[slot] = item
+	return true
+}
+
+// setHeld selects a hotbar slot, it returns false if the slot doesn't exist.
+func (inv *Inventory) setHeld(slot int) bool {
+	if slot < 0 || slot >= hotbarSize {
+		return false
+	}
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+	inv.held = slot
+	return true
+}
+
+// dropHeld removes the items thrown from the main hand, the whole stack if all is true.
+func (inv *Inventory) dropHeld(all bool) Slot {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+	slot := &inv.slots[hotbarSlot+inv.held]
+	count := 1
+	if all {
+		count = int(slot.Count)
+	}
+	dropped := slot.withCount(count)
+	*slot = slot.withCount(int(slot.Count) - count)
+	return dropped
+}
+
+// clickInventory runs a Click Window action on the player window, see windowView.click.
+func (p *Player) clickInventory(slot, button, mode int) (expected Slot, dropped []Slot, ok bool) {
+	p.inventory.mutex.Lock()
+	defer p.inventory.mutex.Unlock()
+	return p.windowView(nil).click(slot, button, mode, p.GameMode() == GameModeCreative)
+}
+
+// windowView returns the view of the window of a container, or of the player window
+// if the container is nil. It doesn't lock the container nor the inventory.
+func (p *Player) windowView(c *Container) *windowView {
+	v := p.inventory.playerView()
+	if c != nil {
+		v = c.containerView(&p.inventory)
+	}
+	if v.grid != nil {
+		v.grid.allowed = p.canCraft
+	}
+	return v
+}
+
+// Inventory returns the inventory of the player.
+func (p *Player) Inventory() *Inventory {
+	return &p.inventory
+}
+
+// SetInventorySlot changes a slot of the player window and sends it to the client.
+func (p *Player) SetInventorySlot(slot int, item Slot) error {
+	if slot < 0 || slot >= inventorySize {
+		return nil
+	}
+	p.inventory.mutex.Lock()
+	p.inventory.slots[slot] = item
+	p.inventory.mutex.Unlock()
+	return p.writeSetSlot(playerWindowID, slot, item)
+}
+
+// SetHeldSlot selects a hotbar slot, from 0 to 8, and sends it to the client.
+func (p *Player) SetHeldSlot(slot int) error {
+	if !p.inventory.setHeld(slot) {
+		return nil
+	}
+	return NewPacket(heldItemChangePacketID, Byte(slot)).Pack(p.connection)
+}
+
+// writeWindowItems sends all the slots of the player window and the item held by the cursor.
+func (p *Player) writeWindowItems() error {
+	p.inventory.mutex.Lock()
+	slots, cursor := p.inventory.slots, p.inventory.cursor
+	p.inventory.mutex.Unlock()
+
+	packet := NewPacket(windowItemsPacketID, UnsignedByte(playerWindowID), Short(len(slots)))
+	for _, slot := range slots {
+		_, _ = slot.WriteTo(packet)
+	}
+	if err := packet.Pack(p.connection); err != nil {
+		return err
+	}
+	return p.writeSetSlot(cursorWindowID, cursorSlot, cursor)
+}
+
+// writeSetSlot sends an item of a window slot.
+func (p *Player) writeSetSlot(window, slot int, item Slot) error {
+	return NewPacket(setSlotPacketID, Byte(window), Short(slot), item).Pack(p.connection)
+}
+
+// writeWindowConfirmation tells the client if a click has been accepted.
+func (p *Player) writeWindowConfirmation(window, action int, accepted bool) error {
+	return NewPacket(windowConfirmationPacketID, Byte(window), Short(action), Boolean(accepted)).Pack(p.connection)
+}
@@ -0,0 +1,27 @@
+package MinecraftLightServer
+
+import "testing"
+
+func TestSlotIsValid(t *testing.T) {
+	stone := testItem(t, "stone", 1)
+	tests := []struct {
+		name string
+		slot Slot
+		want bool
+	}{
+		{"empty slot", Slot{}, true},
+		{"one item", stone, true},
+		{"full stack", stone.withCount(maxStackSize), true},
+		{"too many items", Slot{Present: true, ItemID: stone.ItemID, Count: maxStackSize + 1}, false},
+		{"no items", Slot{Present: true, ItemID: stone.ItemID}, false},
+		{"negative count", Slot{Present: true, ItemID: stone.ItemID, Count: -1}, false},
+		{"negative ID", Slot{Present: true, ItemID: -1, Count: 1}, false},
+		{"unknown ID", Slot{Present: true, ItemID: 1 << 20, Count: 1}, false},
+	}
+
+	for _, test := range tests {
+		if got := test.slot.isValid(); got != test.want {
+			t.Errorf("%s: isValid() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package MinecraftLightServer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// itemRegistry is the registry used to convert item names to IDs and back.
+var itemRegistry = newItemRegistry(func() map[string]int32 {
+	items := make(map[string]int32, len(builtinItems))
+	for id, name := range builtinItems {
+		items[namespaced(name)] = int32(id)
+	}
+	return items
+}())
+
+// ItemRegistry maps namespaced item names to the item IDs of the protocol and back,
+// with the properties of each item.
+type ItemRegistry struct {
+	items map[string]*itemType // items by name
+	ids   map[int32]*itemType  // items by ID
+}
+
+// itemType is an item of the registry.
+type itemType struct {
+	name       string // namespaced name
+	id         int32  // protocol ID
+	maxStack   int    // items in a full stack
+	durability int    // uses before the item breaks, 0 if it doesn't break
+}
+
+// Item properties that aren't in the vanilla reports, they're found from the item names.
+var (
+	// toolDurabilities are the durabilities of the tools of each material.
+	toolDurabilities = map[string]int{"wooden": 59, "stone": 131, "iron": 250, "golden": 32, "diamond": 1561, "netherite": 2031}
+	// toolTypes are the tools made of each material.
+	toolTypes = []string{"sword", "shovel", "pickaxe", "axe", "hoe"}
+	// armorDurabilities are the durability multipliers of the armor of each material.
+	armorDurabilities = map[string]int{"leather": 5, "chainmail": 15, "iron": 15, "golden": 7, "diamond": 33, "netherite": 37}
+	// armorPieces are the base durabilities of the armor pieces.
+	armorPieces = map[string]int{"helmet": 11, "chestplate": 16, "leggings": 15, "boots": 13}
+	// itemDurabilities are the durabilities of the other items that break.
+	itemDurabilities = map[string]int{
+		"minecraft:turtle_helmet": 275, "minecraft:bow": 384, "minecraft:crossbow": 326, "minecraft:fishing_rod": 64,
+		"minecraft:flint_and_steel": 64, "minecraft:shears": 238, "minecraft:shield": 336, "minecraft:trident": 250,
+		"minecraft:elytra": 432, "minecraft:carrot_on_a_stick": 25, "minecraft:warped_fungus_on_a_stick": 100,
+	}
+	// unstackableItems are the items that don't break and can't be stacked.
+	unstackableItems = []string{"banner_pattern", "_bucket", "_boat", "minecart", "_bed", "_horse_armor", "music_disc_", "shulker_box",
+		"potion", "_stew", "beetroot_soup", "minecraft:saddle", "minecraft:cake", "minecraft:enchanted_book",
+		"minecraft:writable_book", "minecraft:written_book", "minecraft:totem_of_undying", "minecraft:knowledge_book",
+		"minecraft:debug_stick"}
+	// smallStackItems are the items stacked up to 16.
+	smallStackItems = []string{"_sign", "_banner", "minecraft:ender_pearl", "minecraft:snowball", "minecraft:egg",
+		"minecraft:bucket", "minecraft:honey_bottle", "minecraft:armor_stand"}
+	// itemBlocks are the blocks placed by items with a different name.
+	itemBlocks = map[string]string{
+		"minecraft:wheat_seeds": "minecraft:wheat", "minecraft:redstone": "minecraft:redstone_wire",
+		"minecraft:string": "minecraft:tripwire", "minecraft:carrot": "minecraft:carrots",
+		"minecraft:potato": "minecraft:potatoes", "minecraft:beetroot_seeds": "minecraft:beetroots",
+		"minecraft:sweet_berries": "minecraft:sweet_berry_bush", "minecraft:cocoa_beans": "minecraft:cocoa",
+		"minecraft:melon_seeds": "minecraft:melon_stem", "minecraft:pumpkin_seeds": "minecraft:pumpkin_stem",
+	}
+)
+
+// newItemRegistry creates a registry from the IDs of the items.
+func newItemRegistry(items map[string]int32) *ItemRegistry {
+	r := &ItemRegistry{items: make(map[string]*itemType, len(items)), ids: make(map[int32]*itemType, len(items))}
+	for name, id := range items {
+		item := &itemType{name: name, id: id, maxStack: maxStackSize, durability: itemDurability(name)}
+		if item.durability > 0 || matchesAny(name, unstackableItems) {
+			item.maxStack = 1
+		} else if matchesAny(name, smallStackItems) {
+			item.maxStack = 16
+		}
+		r.items[name] = item
+		r.ids[id] = item
+	}
+	return r
+}
+
+// itemDurability returns the durability of an item, 0 if it doesn't break.
+func itemDurability(name string) int {
+	if durability, ok := itemDurabilities[name]; ok {
+		return durability
+	}
+	material := strings.TrimPrefix(name, "minecraft:")
+	if i := strings.LastIndexByte(material, '_'); i >= 0 {
+		material, piece := material[:i], material[i+1:]
+		for _, tool := range toolTypes {
+			if piece == tool {
+				return toolDurabilities[material]
+			}
+		}
+		if base, ok := armorPieces[piece]; ok {
+			return base * armorDurabilities[material]
+		}
+	}
+	return 0
+}
+
+// matchesAny checks if a name is one of the names or contains one of the name parts,
+// the ones without namespace.
+func matchesAny(name string, parts []string) bool {
+	for _, part := range parts {
+		if name == part || (!strings.Contains(part, ":") && strings.Contains(name, part)) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadItemRegistry reads the items from the registries.json data report generated by the
+// vanilla server (java -cp server.jar net.minecraft.data.Main --reports).
+func LoadItemRegistry(r io.Reader) (*ItemRegistry, error) {
+	var report map[string]struct {
+		Entries map[string]struct {
+			ProtocolID int32 `json:"protocol_id"`
+		} `json:"entries"`
+	}
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, err
+	}
+	registry, ok := report["minecraft:item"]
+	if !ok {
+		return nil, errors.New("missing item registry")
+	}
+
+	items := make(map[string]int32, len(registry.Entries))
+	for name, entry := range registry.Entries {
+		items[name] = entry.ProtocolID
+	}
+	return newItemRegistry(items), nil
+}
+
+// SetItemRegistry changes the registry used by the server, for example with
+// one loaded from the full vanilla report. It must be called before starting the server.
+func SetItemRegistry(r *ItemRegistry) {
+	itemRegistry = r
+}
+
+// ID returns the ID of an item, ok is false if the item doesn't exist.
+func (r *ItemRegistry) ID(name string) (id int32, ok bool) {
+	item, ok := r.items[namespaced(name)]
+	if !ok {
+		return 0, false
+	}
+	return item.id, true
+}
+
+// Name returns the namespaced name of an item, an empty string if the ID doesn't exist.
+func (r *ItemRegistry) Name(id int32) string {
+	if item, ok := r.ids[id]; ok {
+		return item.name
+	}
+	return ""
+}
+
+// MaxStack returns how many items with an ID can be stacked, 64 if the ID doesn't exist.
+func (r *ItemRegistry) MaxStack(id int32) int {
+	if item, ok := r.ids[id]; ok {
+		return item.maxStack
+	}
+	return maxStackSize
+}
+
+// Durability returns how many times an item can be used before breaking,
+// 0 if it doesn't break.
+func (r *ItemRegistry) Durability(id int32) int {
+	if item, ok := r.ids[id]; ok {
+		return item.durability
+	}
+	return 0
+}
+
+// Block returns the default state of the block placed by an item,
+// ok is false if the item isn't a block.
+func (r *ItemRegistry) Block(id int32) (state BlockState, ok bool) {
+	item, ok := r.ids[id]
+	if !ok || item.id == 0 {
+		return blockAir, false
+	}
+	name := item.name
+	if block, ok := itemBlocks[name]; ok {
+		name = block
+	}
+	return blockRegistry.DefaultState(name)
+}
+
+// NewItem returns a stack of an item with the specified count,
+// from 1 to the max stack size of the item.
+func NewItem(name string, count int) (Slot, error) {
+	id, ok := itemRegistry.ID(name)
+	if !ok {
+		return Slot{}, errors.New("unknown item: " + name)
+	}
+	if count < 1 || count > itemRegistry.MaxStack(id) {
+		return Slot{}, errors.New("invalid count of " + name + ": " + strconv.Itoa(count))
+	}
+	return Slot{Present: true, ItemID: id, Count: int8(count)}, nil
+}
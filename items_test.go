@@ -0,0 +1,93 @@
+package MinecraftLightServer
+
+import "testing"
+
+func TestBuiltinItems(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         int32
+		maxStack   int
+		durability int
+	}{
+		{"minecraft:air", 0, maxStackSize, 0},
+		{"minecraft:stone_bricks", 240, maxStackSize, 0},
+		{"minecraft:white_shulker_box", 432, 1, 0},
+		{"minecraft:oak_door", 558, maxStackSize, 0},
+		{"minecraft:coal", 576, maxStackSize, 0},
+		{"minecraft:diamond_sword", 603, 1, 1561},
+		{"minecraft:stick", 613, maxStackSize, 0},
+		{"minecraft:wheat_seeds", 619, maxStackSize, 0},
+		{"minecraft:netherite_boots", 645, 1, 481},
+		{"minecraft:oak_sign", 652, 16, 0},
+		{"minecraft:water_bucket", 661, 1, 0},
+		{"minecraft:redstone", 665, maxStackSize, 0},
+		{"minecraft:ender_pearl", 744, 16, 0},
+		{"minecraft:zombified_piglin_spawn_egg", 822, maxStackSize, 0},
+		{"minecraft:trident", 922, 1, 250},
+		{"minecraft:respawn_anchor", 975, maxStackSize, 0},
+	}
+
+	if len(builtinItems) != 976 {
+		t.Errorf("%d built-in items, want 976", len(builtinItems))
+	}
+	for _, test := range tests {
+		id, ok := itemRegistry.ID(test.name)
+		if !ok || id != test.id {
+			t.Errorf("%s: ID %d, want %d", test.name, id, test.id)
+			continue
+		}
+		if got := itemRegistry.MaxStack(id); got != test.maxStack {
+			t.Errorf("%s: stacks up to %d, want %d", test.name, got, test.maxStack)
+		}
+		if got := itemRegistry.Durability(id); got != test.durability {
+			t.Errorf("%s: durability %d, want %d", test.name, got, test.durability)
+		}
+	}
+}
+
+func TestNewItemCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		valid bool
+	}{
+		{"stone", 1, true},
+		{"stone", maxStackSize, true},
+		{"stone", 0, false},
+		{"stone", -1, false},
+		{"stone", maxStackSize + 1, false},
+		{"ender_pearl", 16, true},
+		{"ender_pearl", 17, false},
+		{"diamond_sword", 2, false},
+		{"minecraft:unknown", 1, false},
+	}
+	for _, test := range tests {
+		item, err := NewItem(test.name, test.count)
+		if (err == nil) != test.valid {
+			t.Errorf("%d %s: error %v, want valid %t", test.count, test.name, err, test.valid)
+		} else if err == nil && int(item.Count) != test.count {
+			t.Errorf("%d %s: the count is %d", test.count, test.name, item.Count)
+		}
+	}
+}
+
+func TestBlockLoot(t *testing.T) {
+	tests := map[string]string{
+		"minecraft:coal_ore":     "minecraft:coal",
+		"minecraft:diamond_ore":  "minecraft:diamond",
+		"minecraft:redstone_ore": "minecraft:redstone",
+		"minecraft:bookshelf":    "minecraft:book",
+		"minecraft:stone":        "minecraft:cobblestone",
+	}
+	for block, item := range tests {
+		state, ok := blockRegistry.DefaultState(block)
+		if !ok {
+			t.Errorf("%s isn't a built-in block", block)
+			continue
+		}
+		loot := blockLoot(state)
+		if len(loot) != 1 || itemRegistry.Name(loot[0].ItemID) != item {
+			t.Errorf("%s drops %v, want %s", block, loot, item)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package MinecraftLightServer
+
+import "errors"
+
+// ItemStack is a stack of items with their data, named as in the vanilla data.
+// It's converted to a Slot to be sent to the clients.
+type ItemStack struct {
+	Name  string      // namespaced item name
+	Count int         // items in the stack
+	Tag   NBTCompound // item data, like the display name and the enchantments, nil if there isn't any
+}
+
+// NewItemStack creates a stack of items without data.
+func NewItemStack(name string, count int) *ItemStack {
+	return &ItemStack{Name: namespaced(name), Count: count}
+}
+
+// ItemStackFromSlot converts a slot to a stack of items, the name is empty if the item doesn't exist.
+func ItemStackFromSlot(slot Slot) *ItemStack {
+	if slot.IsEmpty() {
+		return &ItemStack{}
+	}
+	return &ItemStack{Name: itemRegistry.Name(slot.ItemID), Count: int(slot.Count), Tag: slot.NBT}
+}
+
+// Slot converts the stack of items to a slot.
+func (s *ItemStack) Slot() (Slot, error) {
+	if s.IsEmpty() {
+		return Slot{}, nil
+	}
+	id, ok := itemRegistry.ID(s.Name)
+	if !ok {
+		return Slot{}, errors.New("unknown item: " + s.Name)
+	}
+	if s.Count > itemRegistry.MaxStack(id) {
+		return Slot{}, errors.New("too many items in a stack of " + s.Name)
+	}
+	return Slot{Present: true, ItemID: id, Count: int8(s.Count), NBT: s.Tag}, nil
+}
+
+// IsEmpty checks if there aren't items in the stack.
+func (s *ItemStack) IsEmpty() bool {
+	return s.Name == "" || s.Name == "minecraft:air" || s.Count <= 0
+}
+
+// MaxStack returns how many items of this type can be stacked.
+func (s *ItemStack) MaxStack() int {
+	id, _ := itemRegistry.ID(s.Name)
+	return itemRegistry.MaxStack(id)
+}
+
+// tag returns the data of the items, creating it if it's missing.
+func (s *ItemStack) tag() NBTCompound {
+	if s.Tag == nil {
+		s.Tag = make(NBTCompound)
+	}
+	return s.Tag
+}
+
+// display returns the display compound of the data, with the name and the lore.
+func (s *ItemStack) display() NBTCompound {
+	display, ok := s.tag()["display"].(NBTCompound)
+	if !ok {
+		display = make(NBTCompound)
+		s.Tag["display"] = display
+	}
+	return display
+}
+
+// DisplayName returns the custom name of the items, empty if they don't have one.
+func (s *ItemStack) DisplayName() string {
+	display, _ := s.Tag["display"].(NBTCompound)
+	name, _ := display["Name"].(string)
+	return plainText(name)
+}
+
+// SetDisplayName changes the name shown instead of the item name, an empty name removes it.
+func (s *ItemStack) SetDisplayName(name string) {
+	if name == "" {
+		delete(s.display(), "Name")
+		return
+	}
+	s.display()["Name"] = textComponent(name)
+}
+
+// Lore returns the lines of text shown below the name of the items.
+func (s *ItemStack) Lore() []string {
+	display, _ := s.Tag["display"].(NBTCompound)
+	list, _ := display["Lore"].(NBTList)
+	lines := make([]string, 0, len(list))
+	for _, line := range list {
+		component, _ := line.(string)
+		lines = append(lines, plainText(component))
+	}
+	return lines
+}
+
+// SetLore changes the lines of text shown below the name of the items.
+func (s *ItemStack) SetLore(lines ...string) {
+	if len(lines) == 0 {
+		delete(s.display(), "Lore")
+		return
+	}
+	list := make(NBTList, 0, len(lines))
+	for _, line := range lines {
+		list = append(list, textComponent(line))
+	}
+	s.display()["Lore"] = list
+}
+
+// Enchantments returns the levels of the enchantments of the items, by namespaced name.
+func (s *ItemStack) Enchantments() map[string]int {
+	list, _ := s.Tag["Enchantments"].(NBTList)
+	enchantments := make(map[string]int, len(list))
+	for _, tag := range list {
+		enchantment, _ := tag.(NBTCompound)
+		id, _ := enchantment["id"].(string)
+		level, _ := enchantment["lvl"].(int16)
+		if id != "" {
+			enchantments[id] = int(level)
+		}
+	}
+	return enchantments
+}
+
+// Enchant adds an enchantment to the items or changes its level, level 0 removes it.
+func (s *ItemStack) Enchant(name string, level int) {
+	name = namespaced(name)
+	list, _ := s.tag()["Enchantments"].(NBTList)
+	updated := make(NBTList, 0, len(list)+1)
+	for _, tag := range list {
+		if enchantment, ok := tag.(NBTCompound); !ok || enchantment["id"] != name {
+			updated = append(updated, tag)
+		}
+	}
+	if level > 0 {
+		updated = append(updated, NBTCompound{"id": name, "lvl": int16(level)})
+	}
+	if len(updated) == 0 {
+		delete(s.Tag, "Enchantments")
+		return
+	}
+	s.Tag["Enchantments"] = updated
+}
+
+// Damage returns how many times the item has been used, it breaks when it reaches its durability.
+func (s *ItemStack) Damage() int {
+	damage, _ := s.Tag["Damage"].(int32)
+	return int(damage)
+}
+
+// SetDamage changes how many times the item has been used.
+func (s *ItemStack) SetDamage(damage int) {
+	s.tag()["Damage"] = int32(damage)
+}
+
+// MaxDamage returns how many times the item can be used before breaking, 0 if it doesn't break.
+func (s *ItemStack) MaxDamage() int {
+	id, ok := itemRegistry.ID(s.Name)
+	if !ok {
+		return 0
+	}
+	return itemRegistry.Durability(id)
+}
+
+// CustomData returns a tag added to the item data by a plugin, nil if it's missing.
+func (s *ItemStack) CustomData(name string) interface{} {
+	return s.Tag[name]
+}
+
+// SetCustomData adds a tag to the item data, a nil value removes it. The clients ignore
+// the tags they don't know, so they can be used to mark the items of plugin menus.
+func (s *ItemStack) SetCustomData(name string, value interface{}) {
+	if value == nil {
+		delete(s.Tag, name)
+		return
+	}
+	s.tag()[name] = value
+}
+
+// nbt returns the stack of items in the format of the Items lists of the block entities.
+func (s *ItemStack) nbt(slot int) NBTCompound {
+	item := NBTCompound{"Slot": int8(slot), "id": s.Name, "Count": int8(s.Count)}
+	if len(s.Tag) > 0 {
+		item["tag"] = s.Tag
+	}
+	return item
+}
+
+// itemStackFromNBT reads a stack of items from an Items list of a block entity.
+func itemStackFromNBT(item NBTCompound) (slot int, stack *ItemStack) {
+	s, _ := item["Slot"].(int8)
+	name, _ := item["id"].(string)
+	count, _ := item["Count"].(int8)
+	tag, _ := item["tag"].(NBTCompound)
+	return int(uint8(s)), &ItemStack{Name: namespaced(name), Count: int(count), Tag: tag}
+}
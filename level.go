@@ -0,0 +1,179 @@
+package MinecraftLightServer
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// Level data format versions of Minecraft 1.16.5.
+const (
+	levelDataVersion = 2586  // DataVersion of the saved data
+	levelVersion     = 19133 // version of the level.dat format (Anvil)
+)
+
+// levelFileName is the file of a world directory with the world settings.
+const levelFileName = "level.dat"
+
+// SaveLevel writes the world settings in the level.dat format:
+// name, spawn point, time, weather, world border, difficulty and game rules.
+func (w *World) SaveLevel(out io.Writer) error {
+	data := NBTCompound{
+		"LevelName":   w.Name(),
+		"DataVersion": int32(levelDataVersion),
+		"version":     int32(levelVersion),
+		"GameRules":   w.rules.nbt(),
+		"Difficulty":  int8(w.Difficulty()),
+	}
+
+	spawn := w.Spawn()
+	data["SpawnX"], data["SpawnY"], data["SpawnZ"] = int32(spawn.X), int32(spawn.Y), int32(spawn.Z)
+	data["SpawnAngle"] = float32(0)
+
+	w.clock.mutex.RLock()
+	data["Time"], data["DayTime"] = w.clock.age, w.clock.time
+	w.clock.mutex.RUnlock()
+
+	w.weather.mutex.RLock()
+	data["raining"], data["thundering"] = nbtBool(w.weather.raining), nbtBool(w.weather.thundering)
+	data["rainTime"], data["thunderTime"] = int32(w.weather.rainTime), int32(w.weather.thunderTime)
+	data["clearWeatherTime"] = int32(w.weather.clearTime)
+	w.weather.mutex.RUnlock()
+
+	b := w.border
+	b.mutex.RLock()
+	data["BorderCenterX"], data["BorderCenterZ"] = b.centerX, b.centerZ
+	data["BorderSize"], data["BorderSizeLerpTarget"] = b.diameter, b.target
+	data["BorderSizeLerpTime"] = int64(b.resizeTime())
+	data["BorderWarningTime"], data["BorderWarningBlocks"] = float64(b.warningTime), float64(b.warningBlocks)
+	data["BorderDamagePerBlock"], data["BorderSafeZone"] = b.damagePerBlock, b.damageBuffer
+	b.mutex.RUnlock()
+
+	gz := gzip.NewWriter(out)
+	if _, err := (NBTCompound{"Data": data}).WriteTo(gz); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// SaveLevelFile writes the world settings to a level.dat file.
+func (w *World) SaveLevelFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := w.SaveLevel(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadLevel reads the world settings saved in the level.dat format,
+// the missing ones keep their current value.
+func (w *World) LoadLevel(in io.Reader) error {
+	// level.dat is usually compressed, check the gzip header
+	buffered := bufio.NewReader(in)
+	if header, err := buffered.Peek(2); err == nil && header[0] == 0x1f && header[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		in = gz
+	} else {
+		in = buffered
+	}
+
+	root, err := ReadNBT(in)
+	if err != nil {
+		return err
+	}
+	data, ok := root["Data"].(NBTCompound)
+	if !ok {
+		return errors.New("level data without Data compound")
+	}
+
+	if rules, ok := data["GameRules"].(NBTCompound); ok {
+		w.rules.loadNBT(rules)
+	}
+
+	if difficulty, ok := data["Difficulty"].(int8); ok && difficulty >= 0 && Difficulty(difficulty) <= DifficultyHard {
+		w.SetDifficulty(Difficulty(difficulty))
+	}
+
+	x, okX := nbtNumber(data["SpawnX"])
+	y, okY := nbtNumber(data["SpawnY"])
+	z, okZ := nbtNumber(data["SpawnZ"])
+	if okX && okY && okZ {
+		w.SetSpawn(Position{x, y, z})
+	}
+
+	w.clock.mutex.Lock()
+	if age, ok := data["Time"].(int64); ok {
+		w.clock.age = age
+	}
+	if dayTime, ok := data["DayTime"].(int64); ok {
+		w.clock.time = dayTime
+	}
+	w.clock.mutex.Unlock()
+	w.timeChanged()
+
+	w.weather.mutex.Lock()
+	if raining, ok := data["raining"].(int8); ok {
+		w.weather.raining = raining != 0
+	}
+	if thundering, ok := data["thundering"].(int8); ok {
+		w.weather.thundering = thundering != 0
+	}
+	if ticks, ok := nbtNumber(data["rainTime"]); ok {
+		w.weather.rainTime = ticks
+	}
+	if ticks, ok := nbtNumber(data["thunderTime"]); ok {
+		w.weather.thunderTime = ticks
+	}
+	if ticks, ok := nbtNumber(data["clearWeatherTime"]); ok {
+		w.weather.clearTime = ticks
+	}
+	w.weather.mutex.Unlock()
+
+	w.loadBorder(data)
+	return nil
+}
+
+// loadBorder changes the world border using the settings stored in level.dat.
+func (w *World) loadBorder(data NBTCompound) {
+	b := w.border
+	if x, ok := data["BorderCenterX"].(float64); ok {
+		z, _ := data["BorderCenterZ"].(float64)
+		b.SetCenter(x, z)
+	}
+	if size, ok := data["BorderSize"].(float64); ok {
+		b.SetDiameter(size)
+		if target, ok := data["BorderSizeLerpTarget"].(float64); ok && target != size {
+			ms, _ := data["BorderSizeLerpTime"].(int64)
+			b.ResizeTo(target, time.Duration(ms)*time.Millisecond)
+		}
+	}
+	if warningTime, ok := data["BorderWarningTime"].(float64); ok {
+		warningBlocks, _ := data["BorderWarningBlocks"].(float64)
+		b.SetWarning(int(warningTime), int(warningBlocks))
+	}
+	if damage, ok := data["BorderDamagePerBlock"].(float64); ok {
+		buffer, _ := data["BorderSafeZone"].(float64)
+		b.SetDamage(damage, buffer)
+	}
+}
+
+// LoadLevelFile reads the world settings from a level.dat file.
+func (w *World) LoadLevelFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return w.LoadLevel(file)
+}
@@ -0,0 +1,45 @@
+package MinecraftLightServer
+
+import "testing"
+
+func TestServerSavesLevel(t *testing.T) {
+	dir := t.TempDir()
+	generator, _ := NewFlatGenerator(defaultFlatLayers)
+	w := testOpenWorld(t, dir, generator)
+	s := NewServer()
+	if err := s.AddWorld("saved", w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.GameRules().SetBool("keepInventory", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.GameRules().SetInt("randomTickSpeed", 10); err != nil {
+		t.Fatal(err)
+	}
+	w.SetTime(6000)
+	w.SetDifficulty(DifficultyHard)
+	w.SetSpawn(Position{10, 20, 30})
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The world of a new server is loaded from the same directory
+	loaded := testOpenWorld(t, dir, generator)
+	s = NewServer()
+	if err := s.AddWorld("saved", loaded); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if !loaded.GameRules().Bool("keepInventory") || loaded.GameRules().Int("randomTickSpeed") != 10 {
+		t.Error("the game rules haven't been loaded")
+	}
+	if loaded.Time() != 6000 {
+		t.Errorf("the time is %d, want 6000", loaded.Time())
+	}
+	if loaded.Difficulty() != DifficultyHard {
+		t.Errorf("the difficulty is %v, want hard", loaded.Difficulty())
+	}
+	if spawn := loaded.Spawn(); spawn != (Position{10, 20, 30}) {
+		t.Errorf("the spawn point is %v", spawn)
+	}
+}
@@ -0,0 +1,380 @@
+package MinecraftLightServer
+
+// Light levels.
+const (
+	maxLight     = 15   // maximum light level
+	nibbleLength = 2048 // bytes of a section light array (4 bits per block)
+)
+
+// lightKind is the type of light, sky light or block light.
+type lightKind int
+
+// Light kinds.
+const (
+	skyLight lightKind = iota
+	blockLight
+)
+
+// nibbleArray stores a 4 bits light level for each block of a section.
+type nibbleArray [nibbleLength]byte
+
+// get returns the light level at a section index.
+func (n *nibbleArray) get(index int) int {
+	if index&1 == 0 {
+		return int(n[index>>1] & 0x0F)
+	}
+	return int(n[index>>1] >> 4)
+}
+
+// set changes the light level at a section index.
+func (n *nibbleArray) set(index, level int) {
+	if index&1 == 0 {
+		n[index>>1] = n[index>>1]&0xF0 | byte(level)
+	} else {
+		n[index>>1] = n[index>>1]&0x0F | byte(level)<<4
+	}
+}
+
+// isEmpty checks if every light level of the array is zero.
+func (n *nibbleArray) isEmpty() bool {
+	for _, b := range n {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// transparentBlocks are the blocks that don't reduce the light passing through them.
+// The names without namespace match every block that contains them, slabs and stairs are opaque.
+var transparentBlocks = append([]string{"minecraft:air", "minecraft:cave_air", "minecraft:void_air", "glass",
+	"_pane", "minecraft:iron_bars", "minecraft:chain", "torch", "minecraft:fire", "minecraft:soul_fire",
+	"minecraft:end_rod", "minecraft:lantern", "minecraft:soul_lantern", "minecraft:campfire", "minecraft:soul_campfire",
+	"rail", "_bed", "_sign", "_banner", "_head", "_skull", "_door", "_trapdoor", "_fence", "_wall", "_carpet", "minecraft:ladder",
+	"minecraft:scaffolding", "minecraft:lever", "_pressure_plate", "_button", "minecraft:tripwire", "minecraft:tripwire_hook",
+	"minecraft:redstone_wire", "minecraft:repeater", "minecraft:comparator", "minecraft:snow", "minecraft:cactus",
+	"minecraft:cake", "minecraft:chest", "minecraft:trapped_chest", "minecraft:ender_chest", "minecraft:piston_head",
+	"minecraft:moving_piston", "minecraft:nether_portal", "minecraft:end_portal", "minecraft:flower_pot", "potted_",
+	"minecraft:hopper", "anvil", "minecraft:brewing_stand", "minecraft:enchanting_table", "minecraft:bell",
+	"minecraft:lectern", "minecraft:turtle_egg"}, plantBlocks...)
+
+// translucentBlocks are the blocks that reduce by 1 the light passing through them.
+var translucentBlocks = []string{"minecraft:water", "minecraft:lava", "_leaves", "minecraft:ice", "minecraft:frosted_ice",
+	"minecraft:cobweb", "seagrass", "minecraft:kelp", "minecraft:kelp_plant", "minecraft:bubble_column", "minecraft:slime_block"}
+
+// lightEmissions are the light levels emitted by the blocks,
+// the ones with a lit property emit light only when they're lit.
+var lightEmissions = map[string]int{
+	"minecraft:lava": 15, "minecraft:fire": 15, "minecraft:soul_fire": 10, "minecraft:torch": 14,
+	"minecraft:wall_torch": 14, "minecraft:soul_torch": 10, "minecraft:soul_wall_torch": 10,
+	"minecraft:redstone_torch": 7, "minecraft:redstone_wall_torch": 7, "minecraft:redstone_ore": 9,
+	"minecraft:redstone_lamp": 15, "minecraft:furnace": 13, "minecraft:blast_furnace": 13, "minecraft:smoker": 13,
+	"minecraft:campfire": 15, "minecraft:soul_campfire": 10, "minecraft:lantern": 15, "minecraft:soul_lantern": 10,
+	"minecraft:glowstone": 15, "minecraft:jack_o_lantern": 15, "minecraft:sea_lantern": 15, "minecraft:shroomlight": 15,
+	"minecraft:beacon": 15, "minecraft:conduit": 15, "minecraft:end_gateway": 15, "minecraft:end_portal": 15,
+	"minecraft:end_rod": 14, "minecraft:nether_portal": 11, "minecraft:crying_obsidian": 10,
+	"minecraft:enchanting_table": 7, "minecraft:ender_chest": 7, "minecraft:magma_block": 3,
+	"minecraft:brown_mushroom": 1, "minecraft:brewing_stand": 1, "minecraft:dragon_egg": 1, "minecraft:end_portal_frame": 1,
+}
+
+// lightProperties returns how much a block reduces the light passing through it and the light level
+// that it emits, they aren't in the vanilla reports so they're found from the block name.
+func lightProperties(name string) (opacity, emission int) {
+	opacity = maxLight
+	switch {
+	case matchesAny(name, translucentBlocks):
+		opacity = 1
+	case matchesAny(name, transparentBlocks):
+		opacity = 0
+	}
+	return opacity, lightEmissions[name]
+}
+
+// blockOpacity returns how much a block reduces the light passing through it.
+func blockOpacity(state BlockState) int {
+	return blockRegistry.Opacity(state)
+}
+
+// blockEmission returns the light level emitted by a block.
+func blockEmission(state BlockState) int {
+	return blockRegistry.Emission(state)
+}
+
+// getLight returns the light level at chunk relative coordinates, without locking.
+func (c *Chunk) getLight(kind lightKind, x, y, z int) int {
+	array := c.lightArray(kind)[y>>4]
+	if array == nil {
+		return 0
+	}
+	return array.get(sectionIndex(x, y&15, z))
+}
+
+// setLight changes the light level at chunk relative coordinates, without locking.
+func (c *Chunk) setLight(kind lightKind, x, y, z, level int) {
+	arrays := c.lightArray(kind)
+	if arrays[y>>4] == nil {
+		if level == 0 {
+			return
+		}
+		arrays[y>>4] = new(nibbleArray)
+	}
+	arrays[y>>4].set(sectionIndex(x, y&15, z), level)
+}
+
+// lightArray returns the section light arrays of a light kind.
+func (c *Chunk) lightArray(kind lightKind) *[chunkSections]*nibbleArray {
+	if kind == skyLight {
+		return &c.skyLight
+	}
+	return &c.blockLight
+}
+
+// lightNode is a block in a light propagation queue.
+type lightNode struct {
+	x, y, z int // world coordinates
+	level   int // light level of the block
+}
+
+// lightDirections are the offsets of the six neighbours of a block.
+var lightDirections = [6][3]int{
+	{0, -1, 0}, {0, 1, 0}, {-1, 0, 0}, {1, 0, 0}, {0, 0, -1}, {0, 0, 1},
+}
+
+// lightEngine propagates light between the loaded chunks of a World.
+// Only one light engine at a time can run on a World.
+type lightEngine struct {
+	world  *World              // world to light
+	chunks map[chunkPos]*Chunk // loaded chunks cache
+}
+
+// newLightEngine creates a light engine for w.
+func newLightEngine(w *World) *lightEngine {
+	return &lightEngine{world: w, chunks: make(map[chunkPos]*Chunk)}
+}
+
+// chunk returns the loaded chunk that contains world coordinates x, z or nil.
+func (e *lightEngine) chunk(x, z int) *Chunk {
+	pos := chunkPos{x >> 4, z >> 4}
+	c, ok := e.chunks[pos]
+	if !ok {
+		c = e.world.loadedChunk(pos.x, pos.z)
+		e.chunks[pos] = c
+	}
+	return c
+}
+
+// light returns the light level at world coordinates, -1 if the chunk isn't loaded.
+func (e *lightEngine) light(kind lightKind, x, y, z int) int {
+	c := e.chunk(x, z)
+	if c == nil || y < 0 || y >= chunkHeight {
+		return -1
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.getLight(kind, x&15, y, z&15)
+}
+
+// setLight changes the light level at world coordinates and
+// marks the chunk light as changed.
+func (e *lightEngine) setLight(kind lightKind, x, y, z, level int) {
+	c := e.chunk(x, z)
+	c.mutex.Lock()
+	c.setLight(kind, x&15, y, z&15, level)
+	c.mutex.Unlock()
+	e.world.lightChanged(c.X, c.Z)
+}
+
+// block returns the block state at world coordinates of a loaded chunk.
+func (e *lightEngine) block(x, y, z int) BlockState {
+	return e.chunk(x, z).GetBlock(x&15, y, z&15)
+}
+
+// propagate spreads the light of the nodes in queue to their neighbours.
+func (e *lightEngine) propagate(kind lightKind, queue []lightNode) {
+	for i := 0; i < len(queue); i++ {
+		node := queue[i]
+		level := e.light(kind, node.x, node.y, node.z)
+		if level <= 1 {
+			continue
+		}
+
+		for d, dir := range lightDirections {
+			x, y, z := node.x+dir[0], node.y+dir[1], node.z+dir[2]
+			current := e.light(kind, x, y, z)
+			if current < 0 {
+				// Unloaded chunk or outside of the world
+				continue
+			}
+
+			opacity := blockOpacity(e.block(x, y, z))
+			if opacity >= maxLight {
+				continue
+			}
+
+			// Sky light goes down without decreasing through transparent blocks
+			newLevel := level - 1
+			if opacity > 1 {
+				newLevel = level - opacity
+			}
+			if kind == skyLight && d == 0 && level == maxLight && opacity == 0 {
+				newLevel = maxLight
+			}
+
+			if newLevel > current {
+				e.setLight(kind, x, y, z, newLevel)
+				queue = append(queue, lightNode{x, y, z, newLevel})
+			}
+		}
+	}
+}
+
+// unpropagate removes the light spread by the nodes in queue, which already
+// have a light level of zero, and returns the nodes that must propagate again
+// their light to fill the darkened area.
+func (e *lightEngine) unpropagate(kind lightKind, queue []lightNode) []lightNode {
+	var relight []lightNode
+
+	for i := 0; i < len(queue); i++ {
+		node := queue[i]
+		for d, dir := range lightDirections {
+			x, y, z := node.x+dir[0], node.y+dir[1], node.z+dir[2]
+			level := e.light(kind, x, y, z)
+			if level <= 0 {
+				continue
+			}
+
+			if level < node.level || (kind == skyLight && d == 0 && node.level == maxLight && level == maxLight) {
+				// Light came from the removed node
+				e.setLight(kind, x, y, z, 0)
+				queue = append(queue, lightNode{x, y, z, level})
+
+				// Light sources must shine again
+				if kind == blockLight {
+					if emission := blockEmission(e.block(x, y, z)); emission > 0 {
+						e.setLight(kind, x, y, z, emission)
+						relight = append(relight, lightNode{x, y, z, emission})
+					}
+				}
+			} else {
+				// Light from another source
+				relight = append(relight, lightNode{x, y, z, level})
+			}
+		}
+	}
+
+	return relight
+}
+
+// lightChunk calculates the light of a new chunk and spreads it to
+// the loaded neighbour chunks.
+func (e *lightEngine) lightChunk(c *Chunk) {
+	var skyQueue, blockQueue []lightNode
+	baseX, baseZ := c.X*16, c.Z*16
+
+	c.mutex.Lock()
+	// Sky light enters from the top of each column
+	var tops [16][16]int // lowest block with full sky light of each column
+	maxTop := 0
+	for z := 0; z < 16 && e.world.dimension.HasSkyLight; z++ {
+		for x := 0; x < 16; x++ {
+			y := chunkHeight - 1
+			for ; y >= 0 && blockOpacity(c.getBlock(x, y, z)) == 0; y-- {
+				c.setLight(skyLight, x, y, z, maxLight)
+			}
+			tops[x][z] = y + 1
+			if y+1 > maxTop {
+				maxTop = y + 1
+			}
+		}
+	}
+
+	// Spread only the sky light that can go under the nearby columns
+	for z := 0; z < 16 && e.world.dimension.HasSkyLight; z++ {
+		for x := 0; x < 16; x++ {
+			highest := tops[x][z]
+			for _, dir := range lightDirections[2:] {
+				nx, nz := x+dir[0], z+dir[2]
+				if nx < 0 || nx > 15 || nz < 0 || nz > 15 {
+					highest = maxTop
+				} else if tops[nx][nz] > highest {
+					highest = tops[nx][nz]
+				}
+			}
+			for y := tops[x][z]; y <= highest && y < chunkHeight; y++ {
+				skyQueue = append(skyQueue, lightNode{baseX + x, y, baseZ + z, maxLight})
+			}
+		}
+	}
+
+	// Light sources
+	for i, section := range c.sections {
+		if section == nil {
+			continue
+		}
+		for index, state := range section.blocks {
+			if emission := blockEmission(state); emission > 0 {
+				x, y, z := index&15, i*16+index>>8, index>>4&15
+				c.setLight(blockLight, x, y, z, emission)
+				blockQueue = append(blockQueue, lightNode{baseX + x, y, baseZ + z, emission})
+			}
+		}
+	}
+	c.mutex.Unlock()
+	e.world.lightChanged(c.X, c.Z)
+
+	// Light of the neighbour chunks enters from the borders
+	// (first column of each side and direction of the side)
+	for _, side := range [4][4]int{{-1, 0, 0, 1}, {16, 0, 0, 1}, {0, -1, 1, 0}, {0, 16, 1, 0}} {
+		if e.chunk(baseX+side[0], baseZ+side[1]) == nil {
+			continue
+		}
+
+		for i := 0; i < 16; i++ {
+			x, z := baseX+side[0]+i*side[2], baseZ+side[1]+i*side[3]
+			for y := 0; y < chunkHeight; y++ {
+				if level := e.light(skyLight, x, y, z); level > 1 {
+					skyQueue = append(skyQueue, lightNode{x, y, z, level})
+				}
+				if level := e.light(blockLight, x, y, z); level > 1 {
+					blockQueue = append(blockQueue, lightNode{x, y, z, level})
+				}
+			}
+		}
+	}
+
+	e.propagate(skyLight, skyQueue)
+	e.propagate(blockLight, blockQueue)
+}
+
+// updateBlock recalculates the light around a block that has changed.
+func (e *lightEngine) updateBlock(x, y, z int) {
+	state := e.block(x, y, z)
+
+	for _, kind := range []lightKind{skyLight, blockLight} {
+		// Remove the light of the old block
+		old := e.light(kind, x, y, z)
+		e.setLight(kind, x, y, z, 0)
+		relight := e.unpropagate(kind, []lightNode{{x, y, z, old}})
+
+		// Light of the new block
+		if kind == blockLight {
+			if emission := blockEmission(state); emission > 0 {
+				e.setLight(kind, x, y, z, emission)
+				relight = append(relight, lightNode{x, y, z, emission})
+			}
+		} else if y == chunkHeight-1 && blockOpacity(state) == 0 && e.world.dimension.HasSkyLight {
+			e.setLight(kind, x, y, z, maxLight)
+			relight = append(relight, lightNode{x, y, z, maxLight})
+		}
+
+		// Neighbours light enters the block
+		for _, dir := range lightDirections {
+			nx, ny, nz := x+dir[0], y+dir[1], z+dir[2]
+			if level := e.light(kind, nx, ny, nz); level > 0 {
+				relight = append(relight, lightNode{nx, ny, nz, level})
+			}
+		}
+
+		e.propagate(kind, relight)
+	}
+}
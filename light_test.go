@@ -0,0 +1,69 @@
+package MinecraftLightServer
+
+import "testing"
+
+func TestBlockLightProperties(t *testing.T) {
+	tests := []struct {
+		state    string
+		opacity  int
+		emission int
+		solid    bool // counted by the motion blocking heightmap
+	}{
+		{"minecraft:stone", maxLight, 0, true},
+		{"minecraft:glass", 0, 0, true},
+		{"minecraft:oak_leaves", 1, 0, true},
+		{"minecraft:water", 1, 0, true},
+		{"minecraft:lava", 1, 15, true},
+		{"minecraft:torch", 0, 14, false},
+		{"minecraft:furnace", maxLight, 0, true},
+		{"minecraft:furnace[lit=true]", maxLight, 13, true},
+		{"minecraft:redstone_ore", maxLight, 0, true},
+		{"minecraft:redstone_ore[lit=true]", maxLight, 9, true},
+		{"minecraft:redstone_wall_torch", 0, 7, false},
+		{"minecraft:redstone_wall_torch[lit=false]", 0, 0, false},
+		{"minecraft:redstone_lamp[lit=true]", maxLight, 15, true},
+		{"minecraft:jack_o_lantern", maxLight, 15, true},
+		{"minecraft:wheat", 0, 0, false},
+		{"minecraft:beetroots", 0, 0, false},
+		{"minecraft:oak_sapling", 0, 0, false},
+		{"minecraft:snow", 0, 0, false},
+		{"minecraft:snow[layers=2]", 0, 0, true},
+		{"minecraft:oak_door", 0, 0, true},
+		{"minecraft:spruce_trapdoor", 0, 0, true},
+		{"minecraft:oak_trapdoor[open=true,half=top]", 0, 0, true},
+		{"minecraft:oak_stairs", maxLight, 0, true},
+	}
+
+	for _, test := range tests {
+		state := testBlock(t, test.state)
+		if opacity := blockOpacity(state); opacity != test.opacity {
+			t.Errorf("%s: opacity %d, want %d", test.state, opacity, test.opacity)
+		}
+		if emission := blockEmission(state); emission != test.emission {
+			t.Errorf("%s: emission %d, want %d", test.state, emission, test.emission)
+		}
+		if solid := heightmapMatches(motionBlocking, state); solid != test.solid {
+			t.Errorf("%s: counted by the heightmap = %v, want %v", test.state, solid, test.solid)
+		}
+	}
+}
+
+func TestBlockLightNames(t *testing.T) {
+	// Blocks of the full vanilla report that aren't built in
+	for _, name := range []string{"minecraft:potted_oak_sapling", "minecraft:crimson_stem", "minecraft:grass_block"} {
+		if !blocksMotion(name) {
+			t.Errorf("%s doesn't stop entities", name)
+		}
+	}
+	for _, name := range []string{"minecraft:crimson_roots", "minecraft:weeping_vines", "minecraft:birch_wall_sign"} {
+		if blocksMotion(name) {
+			t.Errorf("%s stops entities", name)
+		}
+	}
+	if opacity, emission := lightProperties("minecraft:sea_lantern"); opacity != maxLight || emission != 15 {
+		t.Errorf("sea lantern: opacity %d and emission %d", opacity, emission)
+	}
+	if opacity, _ := lightProperties("minecraft:dried_kelp_block"); opacity != maxLight {
+		t.Errorf("dried kelp block: opacity %d", opacity)
+	}
+}
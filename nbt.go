@@ -0,0 +1,351 @@
+package MinecraftLightServer
+
+import (
+	"errors"
+	"io"
+	"sort"
+)
+
+// NBT tag types.
+const (
+	nbtEnd       = 0
+	nbtByte      = 1
+	nbtShort     = 2
+	nbtInt       = 3
+	nbtLong      = 4
+	nbtFloat     = 5
+	nbtDouble    = 6
+	nbtByteArray = 7
+	nbtString    = 8
+	nbtList      = 9
+	nbtCompound  = 10
+	nbtIntArray  = 11
+	nbtLongArray = 12
+)
+
+// NBT (Named Binary Tag) values, they're mapped to these Go types:
+// int8 (Byte), int16 (Short), int32 (Int), int64 (Long), float32 (Float),
+// float64 (Double), []byte (Byte Array), string (String), NBTList (List),
+// NBTCompound (Compound), []int32 (Int Array) and []int64 (Long Array).
+type (
+	// NBTCompound is a set of named tags.
+	NBTCompound map[string]interface{}
+	// NBTList is a list of unnamed tags of the same type.
+	NBTList []interface{}
+)
+
+// WriteTo encodes an NBTCompound as a root tag with an empty name.
+func (c NBTCompound) WriteTo(w io.Writer) (n int64, err error) {
+	return c.writeNamed(w, "")
+}
+
+// writeNamed encodes an NBTCompound as a root tag with the specified name,
+// some file formats require it.
+func (c NBTCompound) writeNamed(w io.Writer, name string) (n int64, err error) {
+	e := &nbtEncoder{w: w}
+	e.byte(nbtCompound)
+	e.string(name)
+	e.compound(c)
+	return e.n, e.err
+}
+
+// nbtEncoder writes NBT tags, it stops writing at the first error.
+type nbtEncoder struct {
+	w   io.Writer // destination of the tags
+	n   int64     // bytes written
+	err error     // first error
+}
+
+// write writes the bytes of a tag payload.
+func (e *nbtEncoder) write(b []byte) {
+	if e.err == nil {
+		var nn int
+		nn, e.err = e.w.Write(b)
+		e.n += int64(nn)
+	}
+}
+
+// writeTo writes a protocol type.
+func (e *nbtEncoder) writeTo(v io.WriterTo) {
+	if e.err == nil {
+		var nn int64
+		nn, e.err = v.WriteTo(e.w)
+		e.n += nn
+	}
+}
+
+// byte writes a single byte.
+func (e *nbtEncoder) byte(b byte) {
+	e.write([]byte{b})
+}
+
+// string writes a string prefixed by its length.
+func (e *nbtEncoder) string(s string) {
+	e.writeTo(UnsignedShort(len(s)))
+	e.write([]byte(s))
+}
+
+// compound writes the tags of a compound (sorted by name) and the end tag.
+func (e *nbtEncoder) compound(c NBTCompound) {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		tagType, ok := nbtType(c[name])
+		if !ok {
+			e.fail(name)
+			return
+		}
+		e.byte(tagType)
+		e.string(name)
+		e.payload(c[name])
+	}
+	e.byte(nbtEnd)
+}
+
+// payload writes the content of a tag without its name.
+func (e *nbtEncoder) payload(v interface{}) {
+	switch v := v.(type) {
+	case int8:
+		e.byte(byte(v))
+	case int16:
+		e.writeTo(Short(v))
+	case int32:
+		e.writeTo(Int(v))
+	case int64:
+		e.writeTo(Long(v))
+	case float32:
+		e.writeTo(Float(v))
+	case float64:
+		e.writeTo(Double(v))
+	case []byte:
+		e.writeTo(Int(len(v)))
+		e.write(v)
+	case string:
+		e.string(v)
+	case NBTList:
+		// Empty lists use the end tag as type
+		elementType := byte(nbtEnd)
+		if len(v) > 0 {
+			elementType, _ = nbtType(v[0])
+		}
+		e.byte(elementType)
+		e.writeTo(Int(len(v)))
+		for _, element := range v {
+			if t, ok := nbtType(element); !ok || t != elementType {
+				e.fail("list element")
+				return
+			}
+			e.payload(element)
+		}
+	case NBTCompound:
+		e.compound(v)
+	case []int32:
+		e.writeTo(Int(len(v)))
+		for _, i := range v {
+			e.writeTo(Int(i))
+		}
+	case []int64:
+		e.writeTo(Int(len(v)))
+		for _, l := range v {
+			e.writeTo(Long(l))
+		}
+	}
+}
+
+// fail stops the encoder because of an unsupported value.
+func (e *nbtEncoder) fail(name string) {
+	if e.err == nil {
+		e.err = errors.New("unsupported NBT value: " + name)
+	}
+}
+
+// nbtType returns the tag type of a Go value.
+func nbtType(v interface{}) (byte, bool) {
+	switch v.(type) {
+	case int8:
+		return nbtByte, true
+	case int16:
+		return nbtShort, true
+	case int32:
+		return nbtInt, true
+	case int64:
+		return nbtLong, true
+	case float32:
+		return nbtFloat, true
+	case float64:
+		return nbtDouble, true
+	case []byte:
+		return nbtByteArray, true
+	case string:
+		return nbtString, true
+	case NBTList:
+		return nbtList, true
+	case NBTCompound:
+		return nbtCompound, true
+	case []int32:
+		return nbtIntArray, true
+	case []int64:
+		return nbtLongArray, true
+	}
+	return nbtEnd, false
+}
+
+// ReadNBT decodes a root compound tag, ignoring its name.
+func ReadNBT(r io.Reader) (NBTCompound, error) {
+	d := &nbtDecoder{r: r}
+	if tagType := d.byte(); d.err == nil && tagType != nbtCompound {
+		return nil, errors.New("NBT root is not a compound")
+	}
+	d.string()
+	c := d.compound(0)
+	return c, d.err
+}
+
+// Maximum nesting of NBT lists and compounds, as in vanilla.
+const nbtMaxDepth = 512
+
+// nbtDecoder reads NBT tags, it stops reading at the first error.
+type nbtDecoder struct {
+	r   io.Reader // source of the tags
+	err error     // first error
+}
+
+// read reads len(b) bytes of a tag payload.
+func (d *nbtDecoder) read(b []byte) {
+	if d.err == nil {
+		_, d.err = io.ReadFull(d.r, b)
+	}
+}
+
+// readFrom reads a protocol type.
+func (d *nbtDecoder) readFrom(v io.ReaderFrom) {
+	if d.err == nil {
+		_, d.err = v.ReadFrom(d.r)
+	}
+}
+
+// byte reads a single byte.
+func (d *nbtDecoder) byte() byte {
+	var b [1]byte
+	d.read(b[:])
+	return b[0]
+}
+
+// length reads the length of an array or a list.
+func (d *nbtDecoder) length() int {
+	var length Int
+	d.readFrom(&length)
+	if length < 0 && d.err == nil {
+		d.err = errors.New("negative NBT length")
+	}
+	return int(length)
+}
+
+// string reads a string prefixed by its length.
+func (d *nbtDecoder) string() string {
+	var length UnsignedShort
+	d.readFrom(&length)
+	b := make([]byte, length)
+	d.read(b)
+	return string(b)
+}
+
+// compound reads the tags of a compound until the end tag.
+func (d *nbtDecoder) compound(depth int) NBTCompound {
+	c := make(NBTCompound)
+	for d.err == nil {
+		tagType := d.byte()
+		if tagType == nbtEnd {
+			break
+		}
+		name := d.string()
+		c[name] = d.payload(tagType, depth+1)
+	}
+	return c
+}
+
+// payload reads the content of a tag of the specified type.
+func (d *nbtDecoder) payload(tagType byte, depth int) interface{} {
+	if depth > nbtMaxDepth {
+		d.err = errors.New("NBT too deep")
+	}
+	if d.err != nil {
+		return nil
+	}
+
+	switch tagType {
+	case nbtByte:
+		return int8(d.byte())
+	case nbtShort:
+		var v Short
+		d.readFrom(&v)
+		return int16(v)
+	case nbtInt:
+		var v Int
+		d.readFrom(&v)
+		return int32(v)
+	case nbtLong:
+		var v Long
+		d.readFrom(&v)
+		return int64(v)
+	case nbtFloat:
+		var v Float
+		d.readFrom(&v)
+		return float32(v)
+	case nbtDouble:
+		var v Double
+		d.readFrom(&v)
+		return float64(v)
+	case nbtByteArray:
+		b := make([]byte, 0)
+		// Read in small blocks, the length could be corrupted
+		for remaining := d.length(); remaining > 0 && d.err == nil; {
+			size := remaining
+			if size > 4096 {
+				size = 4096
+			}
+			block := make([]byte, size)
+			d.read(block)
+			b = append(b, block...)
+			remaining -= len(block)
+		}
+		return b
+	case nbtString:
+		return d.string()
+	case nbtList:
+		elementType := d.byte()
+		length := d.length()
+		list := make(NBTList, 0)
+		for i := 0; i < length && d.err == nil; i++ {
+			list = append(list, d.payload(elementType, depth+1))
+		}
+		return list
+	case nbtCompound:
+		return d.compound(depth)
+	case nbtIntArray:
+		length := d.length()
+		array := make([]int32, 0)
+		for i := 0; i < length && d.err == nil; i++ {
+			var v Int
+			d.readFrom(&v)
+			array = append(array, int32(v))
+		}
+		return array
+	case nbtLongArray:
+		length := d.length()
+		array := make([]int64, 0)
+		for i := 0; i < length && d.err == nil; i++ {
+			var v Long
+			d.readFrom(&v)
+			array = append(array, int64(v))
+		}
+		return array
+	}
+
+	d.err = errors.New("unknown NBT tag type")
+	return nil
+}
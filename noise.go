@@ -0,0 +1,100 @@
+package MinecraftLightServer
+
+import (
+	"math"
+	"math/rand"
+)
+
+// perlinNoise is a seeded improved Perlin noise generator.
+type perlinNoise struct {
+	permutation [512]int // doubled permutation table
+}
+
+// newPerlinNoise creates a new noise generator using seed.
+func newPerlinNoise(seed int64) *perlinNoise {
+	n := new(perlinNoise)
+	random := rand.New(rand.NewSource(seed))
+
+	p := random.Perm(256)
+	for i := 0; i < 512; i++ {
+		n.permutation[i] = p[i&255]
+	}
+	return n
+}
+
+// fade is the Perlin smoothing curve 6t^5 - 15t^4 + 10t^3.
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+// lerp linearly interpolates between a and b.
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// grad returns the dot product between a pseudorandom gradient and the distance vector.
+func grad(hash int, x, y, z float64) float64 {
+	h := hash & 15
+	u := y
+	if h < 8 {
+		u = x
+	}
+	v := z
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
+
+// noise3 returns the noise value (between -1 and 1) at the specified point.
+func (n *perlinNoise) noise3(x, y, z float64) float64 {
+	// Unit cube that contains the point
+	fx, fy, fz := math.Floor(x), math.Floor(y), math.Floor(z)
+	X, Y, Z := int(fx)&255, int(fy)&255, int(fz)&255
+
+	// Relative position in the cube
+	x, y, z = x-fx, y-fy, z-fz
+	u, v, w := fade(x), fade(y), fade(z)
+
+	// Hash the 8 cube corners
+	p := &n.permutation
+	A := p[X] + Y
+	AA, AB := p[A]+Z, p[A+1]+Z
+	B := p[X+1] + Y
+	BA, BB := p[B]+Z, p[B+1]+Z
+
+	return lerp(w,
+		lerp(v,
+			lerp(u, grad(p[AA], x, y, z), grad(p[BA], x-1, y, z)),
+			lerp(u, grad(p[AB], x, y-1, z), grad(p[BB], x-1, y-1, z))),
+		lerp(v,
+			lerp(u, grad(p[AA+1], x, y, z-1), grad(p[BA+1], x-1, y, z-1)),
+			lerp(u, grad(p[AB+1], x, y-1, z-1), grad(p[BB+1], x-1, y-1, z-1))))
+}
+
+// noise2 returns the 2D noise value (between -1 and 1) at the specified point.
+func (n *perlinNoise) noise2(x, z float64) float64 {
+	return n.noise3(x, 0, z)
+}
+
+// octaves2 sums multiple 2D noise layers with increasing frequency
+// and decreasing amplitude (fractal Brownian motion).
+func (n *perlinNoise) octaves2(x, z float64, octaves int) float64 {
+	var total, max float64
+	amplitude, frequency := 1.0, 1.0
+	for i := 0; i < octaves; i++ {
+		total += n.noise2(x*frequency, z*frequency) * amplitude
+		max += amplitude
+		amplitude /= 2
+		frequency *= 2
+	}
+	return total / max
+}
@@ -2,8 +2,11 @@ package MinecraftLightServer
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"io"
 	"net"
+	"sync"
 )
 
 // Minecraft protocol and handshake constants.
@@ -16,45 +19,119 @@ const (
 
 // Minecraft write packets (id).
 const (
+	spawnEntityPacketID         = 0x00
 	spawnPlayerPacketID         = 0x04
 	writeEntityAnimationID      = 0x05
+	acknowledgeDiggingPacketID  = 0x07
+	blockEntityDataPacketID     = 0x09
+	blockChangePacketID         = 0x0B
 	serverDifficultyPacketID    = 0x0D
 	writeChatPacketID           = 0x0E
+	windowConfirmationPacketID  = 0x11
+	closeWindowPacketID         = 0x12
+	windowItemsPacketID         = 0x13
+	windowPropertyPacketID      = 0x14
+	setSlotPacketID             = 0x15
+	entityStatusPacketID        = 0x1A
+	explosionPacketID           = 0x1B
+	unloadChunkPacketID         = 0x1C
+	changeGameStatePacketID     = 0x1D
 	keepAlivePacketID           = 0x1F
 	writeChunkPacketID          = 0x20
+	updateLightPacketID         = 0x23
 	joinGamePacketID            = 0x24
 	writeEntityRotationPacketID = 0x29
+	openWindowPacketID          = 0x2D
+	openSignEditorPacketID      = 0x2E
+	craftRecipeResponsePacketID = 0x2F
+	combatEventPacketID         = 0x31
 	broadcastPlayerInfoPacketID = 0x32
 	playerPositionPacketID      = 0x34
+	unlockRecipesPacketID       = 0x35
 	destroyEntityPacketID       = 0x36
+	respawnPacketID             = 0x39
 	writeEntityLookPacketID     = 0x3A
+	multiBlockChangePacketID    = 0x3B
+	worldBorderPacketID         = 0x3D
+	heldItemChangePacketID      = 0x3F
 	updateViewPacketID          = 0x40
+	updateViewDistancePacketID  = 0x41
+	spawnPositionPacketID       = 0x42
 	writeEntityMetadataPacketID = 0x44
+	entityVelocityPacketID      = 0x46
+	updateHealthPacketID        = 0x49
+	timeUpdatePacketID          = 0x4E
+	collectItemPacketID         = 0x55
 	writeEntityTeleportPacketID = 0x56
+	declareRecipesPacketID      = 0x5A
 )
 
 // Minecraft read packets (id).
 const (
-	readTeleportConfirmPacketID = 0x00
-	readChatPacketID            = 0x03
-	readKeepAlivePacketID       = 0x10
-	readPositionPacketID        = 0x12
-	readPositionAndLookPacketID = 0x13
-	readRotationPacketID        = 0x14
-	readEntityActionPacketID    = 0x1C
-	readAnimationPacketID       = 0x2C
+	readTeleportConfirmPacketID    = 0x00
+	readChatPacketID               = 0x03
+	readClientStatusPacketID       = 0x04
+	readWindowConfirmationPacketID = 0x07
+	readClickWindowPacketID        = 0x09
+	readCloseWindowPacketID        = 0x0A
+	readInteractEntityPacketID     = 0x0E
+	readKeepAlivePacketID          = 0x10
+	readPositionPacketID           = 0x12
+	readPositionAndLookPacketID    = 0x13
+	readRotationPacketID           = 0x14
+	readCraftRecipeRequestPacketID = 0x19
+	readPlayerDiggingPacketID      = 0x1B
+	readEntityActionPacketID       = 0x1C
+	readRecipeBookStatePacketID    = 0x1E
+	readDisplayedRecipePacketID    = 0x1F
+	readHeldItemChangePacketID     = 0x25
+	readCreativeActionPacketID     = 0x28
+	readUpdateSignPacketID         = 0x2B
+	readAnimationPacketID          = 0x2C
+	readBlockPlacementPacketID     = 0x2E
 )
 
 // Player is a single player that is currently in the server.
 type Player struct {
-	connection       net.Conn // TCP connection
-	id               UUID     // random generated UUID
-	isDeleted        bool     // has current user been deleted from server?
-	username         String   // player username
-	x, y, z          Double   // current coordinates of player
-	yawAbs, pitchAbs Float    // absolute values of player visual in degrees
-	yaw, pitch       Angle    // player visual expressed as an Angle (1/256)
-	onGround         Boolean  // is the player on ground?
+	connection       net.Conn     // TCP connection
+	id               UUID         // random generated UUID
+	isDeleted        bool         // has current user been deleted from server?
+	username         String       // player username
+	x, y, z          Double       // current coordinates of player
+	yawAbs, pitchAbs Float        // absolute values of player visual in degrees
+	yaw, pitch       Angle        // player visual expressed as an Angle (1/256)
+	onGround         Boolean      // is the player on ground?
+	chunks           playerChunks // chunks loaded by the client
+	editingSign      *Position    // sign opened in the sign editor
+	respawn          struct {     // bed or respawn point
+		world *World     // world of the respawn point, nil if the player uses the world spawn
+		pos   Position   // position of the respawn point
+		mutex sync.Mutex // respawn point mutex
+	}
+	gameMode struct { // current game mode
+		mode  GameMode     // survival by default
+		mutex sync.RWMutex // game mode mutex
+	}
+	digging struct { // block that the player is breaking
+		world   *World     // world of the block
+		pos     Position   // position of the block
+		started int64      // world age when the player has started breaking the block
+		active  bool       // the player is breaking a block
+		mutex   sync.Mutex // digging mutex
+	}
+	dropSpam struct { // limit of the items thrown from the creative inventory
+		count int        // increased by each item and decreased every tick
+		mutex sync.Mutex // drop spam mutex
+	}
+	health    health     // health and hunger of the player
+	inventory Inventory  // items of the player
+	recipes   recipeBook // recipes known by the player
+	window    struct {   // container window open by the player
+		id        int        // ID of the open window, 0 if only the inventory is open
+		container *Container // container shown by the open window
+		counter   int        // last window ID used
+		mutex     sync.Mutex // window mutex
+	}
 }
 
 // getNextPacket gets next packet sent by current client.
@@ -94,29 +171,66 @@ func (p *Player) readHandshake(packet *Packet) (state *VarInt, err error) {
 
 // int32FromUUID converts player UUID to an int32.
 func (p *Player) int32FromUUID() int32 {
+	return entityIDFromUUID(p.id)
+}
+
+// entityIDFromUUID returns the entity ID of an entity from its UUID.
+func entityIDFromUUID(id UUID) int32 {
 	// 4 MSBs
-	return int32(p.id[0])<<24 | int32(p.id[1])<<16 | int32(p.id[2])<<8 | int32(p.id[3])
+	return int32(id[0])<<24 | int32(id[1])<<16 | int32(id[2])<<8 | int32(id[3])
 }
 
 // writeJoinGame sends world's settings to client.
-func (p *Player) writeJoinGame() error {
-	return NewPacket(joinGamePacketID,
-		Int(p.int32FromUUID()),             // Entity ID
-		Boolean(false),                     // Is hardcore
-		UnsignedByte(0),                    // 0 = Survival mode
-		Byte(-1),                           // previous gameplay
-		VarInt(1),                          // there is only one world
-		String("minecraft:overworld"),      // available world
-		bytes.NewBuffer(dimensionCodecNBT), // world settings
-		bytes.NewBuffer(dimensionNBT),      //
-		String("minecraft:overworld"),      // player spawn world
-		Long(0x123456789abcdef0),           // hashed seed
-		VarInt(10),                         // max players
-		VarInt(10),                         // rendering distance in chunks
-		Boolean(false),                     // reduced debug info
-		Boolean(false),                     // enable respawn screen
-		Boolean(false),                     // is debug
-		Boolean(true),                      // is flat
+// worlds are all the worlds of the server, the player spawns in its current world.
+func (p *Player) writeJoinGame(worlds []*World, viewDistance int) error {
+	w := p.World()
+	joinGame := NewPacket(joinGamePacketID,
+		Int(p.int32FromUUID()),     // Entity ID
+		Boolean(false),             // Is hardcore
+		UnsignedByte(p.GameMode()), // game mode
+		Byte(-1),                   // previous gameplay
+		VarInt(len(worlds)),        // number of worlds
+	)
+
+	// Add every world name and its dimension type without duplicates
+	var dimensions []*DimensionType
+	known := make(map[string]bool)
+	for _, world := range worlds {
+		_, _ = String(world.Name()).WriteTo(joinGame)
+		if !known[world.dimension.Name] {
+			known[world.dimension.Name] = true
+			dimensions = append(dimensions, world.dimension)
+		}
+	}
+
+	for _, data := range []io.WriterTo{
+		dimensionCodec(dimensions),                   // dimension types and biomes
+		w.dimension.nbt(),                            // dimension of the spawn world
+		String(w.Name()),                             // player spawn world
+		Long(0x123456789abcdef0),                     // hashed seed
+		VarInt(10),                                   // max players
+		VarInt(viewDistance),                         // rendering distance in chunks
+		Boolean(w.rules.Bool("reducedDebugInfo")),    // reduced debug info
+		Boolean(!w.rules.Bool("doImmediateRespawn")), // enable respawn screen
+		Boolean(false),                               // is debug
+		Boolean(w.isFlat()),                          // is flat
+	} {
+		_, _ = data.WriteTo(joinGame)
+	}
+	return joinGame.Pack(p.connection)
+}
+
+// writeRespawn tells the client to load another world, or the same one again after the death screen.
+func (p *Player) writeRespawn(w *World) error {
+	return NewPacket(respawnPacketID,
+		w.dimension.nbt(),          // dimension of the world
+		String(w.Name()),           // world name
+		Long(0x123456789abcdef0),   // hashed seed
+		UnsignedByte(p.GameMode()), // game mode
+		Byte(-1),                   // previous gameplay
+		Boolean(false),             // is debug
+		Boolean(w.isFlat()),        // is flat
+		Boolean(true),              // copy metadata
 	).Pack(p.connection)
 }
 
@@ -129,34 +243,131 @@ func (p *Player) writePlayerPosition(x, y, z Double, yawAbs, pitchAbs Float, fla
 	).Pack(p.connection)
 }
 
-// writeServerDifficulty sends current server difficulty to client.
-func (p *Player) writeServerDifficulty() error {
-	// Mode: peaceful, locked
-	return NewPacket(serverDifficultyPacketID, UnsignedByte(0), Boolean(true)).Pack(p.connection)
-}
-
 // writeChunk sends a world chunk to the client.
-func (p *Player) writeChunk(x, y Int) error {
+func (p *Player) writeChunk(c *Chunk) error {
+	c.mutex.RLock()
+	data := new(bytes.Buffer)
+	mask := c.sectionMask()
+	heightmaps := c.heightmapsNBT()
+	err := c.writeSections(data)
+	biomes := new(bytes.Buffer)
+	for _, id := range c.biomes {
+		_, _ = VarInt(id).WriteTo(biomes)
+	}
+	entities := new(bytes.Buffer)
+	for _, entity := range c.blockEntitiesNBT() {
+		_, _ = entity.WriteTo(entities)
+	}
+	count := len(c.entities)
+	c.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
 	return NewPacket(writeChunkPacketID,
-		x, y, // coordinates of chunk
-		Boolean(true),                                    // full chunk
-		VarInt(0x01),                                     // bit mask, blocks included in this data packet
-		bytes.NewBuffer(heightMapNBT),                    // height map, highest blocks
-		VarInt(1024),                                     // biome array length
-		bytes.NewBuffer(bytes.Repeat([]byte{127}, 1024)), // void biome
-		VarInt(4487),                                     // length of data
-		// data start
-		Short(256),               // non-air blocks
-		UnsignedByte(8),          // bits per block
-		VarInt(256),              // palette length
-		bytes.NewBuffer(palette), // write palette
-		VarInt(512),              // chunk length (512 long, 4096 bytes)
-		bytes.NewBuffer(chunk),   // chunk bytes
-		// data end
-		VarInt(0), // number of block entities (zero)
+		Int(c.X), Int(c.Z), // coordinates of chunk
+		Boolean(true),      // full chunk
+		mask,               // bit mask, sections included in this data packet
+		heightmaps,         // height maps, highest blocks
+		VarInt(biomeCells), // biome array length
+		biomes,             // biome of each 4x4x4 cell
+		VarInt(data.Len()), // length of data
+		data,               // chunk sections
+		VarInt(count),      // number of block entities
+		entities,           // block entities
 	).Pack(p.connection)
 }
 
+// writeBlockEntityData sends the data of a block entity rendered by the client.
+func (p *Player) writeBlockEntityData(pos Position, e *BlockEntity) error {
+	action, ok := blockEntityActions[e.ID]
+	if !ok {
+		// The client doesn't need the data of this block entity
+		return nil
+	}
+	return NewPacket(blockEntityDataPacketID, pos, action, e.nbt(pos.X, pos.Y, pos.Z)).Pack(p.connection)
+}
+
+// OpenSignEditor opens the sign editor for the sign at the specified position,
+// the text written by the player is saved in the sign.
+func (p *Player) OpenSignEditor(pos Position) error {
+	p.editingSign = &pos
+	return NewPacket(openSignEditorPacketID, pos).Pack(p.connection)
+}
+
+// writeUpdateLight sends the sky light and block light of a chunk to the client.
+func (p *Player) writeUpdateLight(c *Chunk) error {
+	packet := NewPacket(updateLightPacketID, VarInt(c.X), VarInt(c.Z), Boolean(true))
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	// Bit 0 is the section below the world, sections start from bit 1
+	var masks [4]VarInt // sky light, block light, empty sky light, empty block light
+	for i := 0; i < chunkSections; i++ {
+		for kind, array := range []*nibbleArray{c.skyLight[i], c.blockLight[i]} {
+			if array != nil && !array.isEmpty() {
+				masks[kind] |= 1 << (i + 1)
+			} else {
+				masks[kind+2] |= 1 << (i + 1)
+			}
+		}
+	}
+	for _, mask := range masks {
+		_, _ = mask.WriteTo(packet)
+	}
+
+	// Light arrays in the same order of the masks
+	for kind, arrays := range []*[chunkSections]*nibbleArray{&c.skyLight, &c.blockLight} {
+		for i, array := range arrays {
+			if masks[kind]&(1<<(i+1)) != 0 {
+				_, _ = VarInt(nibbleLength).WriteTo(packet)
+				_, _ = packet.Write(array[:])
+			}
+		}
+	}
+
+	return packet.Pack(p.connection)
+}
+
+// writeBlockChanges sends the changed blocks of a chunk to the client.
+func (p *Player) writeBlockChanges(changes []blockChange) error {
+	// A single block uses the Block Change packet
+	if len(changes) == 1 {
+		change := changes[0]
+		return NewPacket(blockChangePacketID,
+			Position{change.x, change.y, change.z},
+			VarInt(change.state),
+		).Pack(p.connection)
+	}
+
+	// Group changes by section, only the last change of a block is kept
+	sections := make(map[int]map[int]BlockState)
+	for _, change := range changes {
+		section := change.y >> 4
+		if sections[section] == nil {
+			sections[section] = make(map[int]BlockState)
+		}
+		sections[section][(change.x&15)<<8|(change.z&15)<<4|change.y&15] = change.state
+	}
+
+	x, z := changes[0].x>>4, changes[0].z>>4
+	for y, blocks := range sections {
+		packet := NewPacket(multiBlockChangePacketID,
+			Long(int64(x&0x3FFFFF)<<42|int64(z&0x3FFFFF)<<20|int64(y&0xFFFFF)), // section position
+			Boolean(false),      // inverse of the Update Light trust edges
+			VarInt(len(blocks)), // number of blocks
+		)
+		for position, state := range blocks {
+			_, _ = VarLong(int64(state)<<12 | int64(position)).WriteTo(packet)
+		}
+		if err := packet.Pack(p.connection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // updateViewPosition sends to the player the chunk it is currently in.
 func (p *Player) updateViewPosition() error {
 	return NewPacket(updateViewPacketID,
@@ -165,6 +376,16 @@ func (p *Player) updateViewPosition() error {
 	).Pack(p.connection)
 }
 
+// writeUnloadChunk tells the client to unload the chunk at the specified coordinates.
+func (p *Player) writeUnloadChunk(x, z int) error {
+	return NewPacket(unloadChunkPacketID, Int(x), Int(z)).Pack(p.connection)
+}
+
+// writeUpdateViewDistance sends the server view distance to the client.
+func (p *Player) writeUpdateViewDistance(viewDistance int) error {
+	return NewPacket(updateViewDistancePacketID, VarInt(viewDistance)).Pack(p.connection)
+}
+
 // writeChatMessage sends a message to current player chat.
 func (p *Player) writeChatMessage(msg, username string) error {
 	return NewPacket(writeChatPacketID,
@@ -174,6 +395,20 @@ func (p *Player) writeChatMessage(msg, username string) error {
 	).Pack(p.connection)
 }
 
+// writeSystemMessage sends a message of the server to this client,
+// color is the name of a chat color or empty for the default one.
+func (p *Player) writeSystemMessage(msg, color string) error {
+	component, _ := json.Marshal(struct {
+		Text  string `json:"text"`
+		Color string `json:"color,omitempty"`
+	}{msg, color})
+	return NewPacket(writeChatPacketID,
+		String(component),
+		Byte(1), // system message
+		p.id,
+	).Pack(p.connection)
+}
+
 // writeSpawnPlayer sends a spawn player packet to this client.
 func (p *Player) writeSpawnPlayer(id VarInt, playerUUID UUID, x, y, z Double, yaw, pitch Angle) error {
 	return NewPacket(spawnPlayerPacketID, id, playerUUID, x, y, z, yaw, pitch).Pack(p.connection)
@@ -0,0 +1,307 @@
+package MinecraftLightServer
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Random tick settings, the same as vanilla.
+const (
+	growthLight      = 9  // minimum light for plants to grow and grass to spread
+	meltLight        = 11 // block light above which ice and snow melt
+	saplingChance    = 7  // a sapling grows on average once every 7 random ticks
+	grassSpreadTries = 4  // blocks where grass tries to spread on each random tick
+	maxLeafDistance  = 7  // leaves farther than this from a log decay
+	treeSpace        = 9  // free blocks above a sapling needed to grow a tree
+)
+
+// randomTickHandler runs the random tick of a block.
+type randomTickHandler func(w *World, pos Position, state BlockState)
+
+// randomTickHandlers are the handlers of the blocks that change randomly, by block name.
+var randomTickHandlers = make(map[string]randomTickHandler)
+
+// cropAges are the maximum ages of the crops.
+var cropAges = map[string]int{
+	"minecraft:wheat":     7,
+	"minecraft:carrots":   7,
+	"minecraft:potatoes":  7,
+	"minecraft:beetroots": 3,
+}
+
+func init() {
+	for name := range cropAges {
+		randomTickHandlers[name] = tickCrop
+	}
+	for _, wood := range woodValues {
+		randomTickHandlers["minecraft:"+wood+"_sapling"] = tickSapling
+		randomTickHandlers["minecraft:"+wood+"_leaves"] = tickLeaves
+	}
+	randomTickHandlers["minecraft:grass_block"] = tickSpreadingBlock
+	randomTickHandlers["minecraft:mycelium"] = tickSpreadingBlock
+	randomTickHandlers["minecraft:ice"] = tickIce
+	randomTickHandlers["minecraft:snow"] = tickSnow
+}
+
+// tickRandomBlocks runs the random ticks of the chunks loaded by the players: every tick
+// randomTickSpeed blocks are chosen in each section that isn't empty.
+func (w *World) tickRandomBlocks(loaded map[chunkPos]bool) {
+	speed := w.rules.Int("randomTickSpeed")
+	if speed <= 0 {
+		return
+	}
+
+	chunks := make([]*Chunk, 0, len(loaded))
+	for pos := range loaded {
+		if c := w.loadedChunk(pos.x, pos.z); c != nil {
+			chunks = append(chunks, c)
+		}
+	}
+
+	var ticked []Position
+	for _, c := range chunks {
+		c.mutex.RLock()
+		for i, section := range c.sections {
+			if section == nil || section.nonAir == 0 {
+				continue
+			}
+			for n := 0; n < speed; n++ {
+				index := rand.Intn(sectionVolume)
+				if _, ok := randomTickHandlers[section.blocks[index].Name()]; ok {
+					ticked = append(ticked, Position{
+						c.X*16 + index&15,
+						i*16 + index>>8,
+						c.Z*16 + index>>4&15,
+					})
+				}
+			}
+		}
+		c.mutex.RUnlock()
+	}
+
+	for _, pos := range ticked {
+		// The block may have been changed by a previous random tick
+		state := w.GetBlock(pos.X, pos.Y, pos.Z)
+		if handler, ok := randomTickHandlers[state.Name()]; ok {
+			handler(w, pos, state)
+		}
+	}
+}
+
+// lightAt returns the sky and block light levels at a position.
+func (w *World) lightAt(pos Position) (sky, block int) {
+	if pos.Y < 0 || pos.Y >= chunkHeight {
+		return maxLight, 0
+	}
+	c := w.Chunk(pos.X>>4, pos.Z>>4)
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.getLight(skyLight, pos.X&15, pos.Y, pos.Z&15), c.getLight(blockLight, pos.X&15, pos.Y, pos.Z&15)
+}
+
+// brightness returns the highest light level at a position, ignoring the time of the day.
+func (w *World) brightness(pos Position) int {
+	sky, block := w.lightAt(pos)
+	if block > sky {
+		return block
+	}
+	return sky
+}
+
+// cropGrowthSpeed returns the growth speed of a crop: wet farmland makes it grow faster,
+// crops of the same type around slow it down.
+func (w *World) cropGrowthSpeed(pos Position, name string) float64 {
+	speed := 1.0
+	for dz := -1; dz <= 1; dz++ {
+		for dx := -1; dx <= 1; dx++ {
+			bonus := 0.0
+			if below := w.GetBlock(pos.X+dx, pos.Y-1, pos.Z+dz); below.Name() == "minecraft:farmland" {
+				bonus = 1
+				if below.Property("moisture") != "0" {
+					bonus = 3
+				}
+			}
+			if dx != 0 || dz != 0 {
+				bonus /= 4
+			}
+			speed += bonus
+		}
+	}
+
+	same := func(dx, dz int) bool {
+		return w.GetBlock(pos.X+dx, pos.Y, pos.Z+dz).Name() == name
+	}
+	row, column := same(-1, 0) || same(1, 0), same(0, -1) || same(0, 1)
+	diagonal := same(-1, -1) || same(1, -1) || same(1, 1) || same(-1, 1)
+	if (row && column) || diagonal {
+		speed /= 2
+	}
+	return speed
+}
+
+// tickCrop makes a crop grow by a stage, with a chance that depends on its growth speed.
+func tickCrop(w *World, pos Position, state BlockState) {
+	age, _ := strconv.Atoi(state.Property("age"))
+	if age >= cropAges[state.Name()] || w.brightness(pos) < growthLight {
+		return
+	}
+	if rand.Intn(int(25/w.cropGrowthSpeed(pos, state.Name()))+1) == 0 {
+		w.SetBlock(pos.X, pos.Y, pos.Z, state.With("age", strconv.Itoa(age+1)))
+	}
+}
+
+// tickSapling makes a sapling grow, it becomes a tree at the second stage.
+func tickSapling(w *World, pos Position, state BlockState) {
+	if w.brightness(pos.offset(directionUp)) < growthLight || rand.Intn(saplingChance) != 0 {
+		return
+	}
+	if state.Property("stage") == "0" {
+		w.SetBlock(pos.X, pos.Y, pos.Z, state.With("stage", "1"))
+		return
+	}
+	w.growTree(pos, strings.TrimSuffix(state.Name(), "_sapling"))
+}
+
+// growTree replaces a sapling with a tree of its wood, if there's enough space above it.
+func (w *World) growTree(pos Position, wood string) {
+	if pos.Y+treeSpace >= chunkHeight {
+		return
+	}
+	for y := pos.Y + 1; y <= pos.Y+treeSpace; y++ {
+		if state := w.GetBlock(pos.X, y, pos.Z); state != blockAir && !isLeaves(state) {
+			return
+		}
+	}
+
+	trunk, _ := ParseBlockState(wood + "_log")
+	leaves, _ := ParseBlockState(wood + "_leaves")
+
+	// Trees only replace air, leaves, the sapling and the grass below it
+	set := func(x, y, z int, state BlockState) {
+		current := w.GetBlock(x, y, z)
+		replaceable := current == blockAir || isLeaves(current) || (Position{x, y, z}) == pos
+		if state == blockDirt {
+			name := current.Name()
+			replaceable = name == "minecraft:grass_block" || name == "minecraft:podzol" || name == "minecraft:mycelium"
+		}
+		if replaceable {
+			w.SetBlock(x, y, z, state)
+		}
+	}
+	random := rand.New(rand.NewSource(rand.Int63()))
+	if wood == "spruce" {
+		spruceTree(set, random, pos.X, pos.Y, pos.Z, trunk, leaves)
+	} else {
+		tree(set, random, pos.X, pos.Y, pos.Z, trunk, leaves)
+	}
+}
+
+// canBeGrass checks if grass or mycelium can stay on a block, the block above
+// has to let the light through.
+func (w *World) canBeGrass(pos Position) bool {
+	above := w.GetBlock(pos.X, pos.Y+1, pos.Z)
+	switch {
+	case above == blockSnow:
+		return true
+	case waterFluid.contains(above) && waterFluid.level(above) == fluidSource:
+		return false
+	}
+	return blockOpacity(above) < maxLight
+}
+
+// tickSpreadingBlock turns covered grass or mycelium into dirt and spreads it
+// to the dirt around it when there's enough light.
+func tickSpreadingBlock(w *World, pos Position, state BlockState) {
+	if !w.canBeGrass(pos) {
+		w.SetBlock(pos.X, pos.Y, pos.Z, blockDirt)
+		return
+	}
+	if w.brightness(pos.offset(directionUp)) < growthLight {
+		return
+	}
+
+	for i := 0; i < grassSpreadTries; i++ {
+		target := Position{pos.X + rand.Intn(3) - 1, pos.Y + rand.Intn(5) - 3, pos.Z + rand.Intn(3) - 1}
+		if w.loadedChunk(target.X>>4, target.Z>>4) == nil ||
+			w.GetBlock(target.X, target.Y, target.Z) != blockDirt || !w.canBeGrass(target) ||
+			waterFluid.contains(w.GetBlock(target.X, target.Y+1, target.Z)) {
+			continue
+		}
+		above := w.GetBlock(target.X, target.Y+1, target.Z).Name()
+		snowy := above == "minecraft:snow" || above == "minecraft:snow_block"
+		w.SetBlock(target.X, target.Y, target.Z, state.With("snowy", strconv.FormatBool(snowy)))
+	}
+}
+
+// isLeaves checks if a block is leaves.
+func isLeaves(state BlockState) bool {
+	return strings.HasSuffix(state.Name(), "_leaves")
+}
+
+// isLog checks if a block is a log or wood, that keeps the leaves around it alive.
+func isLog(state BlockState) bool {
+	name := state.Name()
+	return strings.HasSuffix(name, "_log") || strings.HasSuffix(name, "_wood")
+}
+
+// leafDistance returns the distance of leaves from the nearest log following other leaves,
+// maxLeafDistance if it's too far.
+func (w *World) leafDistance(pos Position) int {
+	visited := map[Position]bool{pos: true}
+	current := []Position{pos}
+	for distance := 1; distance < maxLeafDistance; distance++ {
+		var next []Position
+		for _, p := range current {
+			for _, dir := range allDirections {
+				neighbor := p.offset(dir)
+				if visited[neighbor] {
+					continue
+				}
+				visited[neighbor] = true
+				state := w.GetBlock(neighbor.X, neighbor.Y, neighbor.Z)
+				if isLog(state) {
+					return distance
+				}
+				if isLeaves(state) {
+					next = append(next, neighbor)
+				}
+			}
+		}
+		current = next
+	}
+	return maxLeafDistance
+}
+
+// tickLeaves updates the distance of leaves from the nearest log,
+// leaves that aren't placed by players decay when they're too far.
+func tickLeaves(w *World, pos Position, state BlockState) {
+	if state.Property("persistent") == "true" {
+		return
+	}
+	distance := w.leafDistance(pos)
+	if distance >= maxLeafDistance {
+		w.SetBlock(pos.X, pos.Y, pos.Z, blockAir)
+	} else if updated := state.With("distance", strconv.Itoa(distance)); updated != state {
+		w.SetBlock(pos.X, pos.Y, pos.Z, updated)
+	}
+}
+
+// tickIce melts ice near bright light sources, it disappears in ultrawarm dimensions.
+func tickIce(w *World, pos Position, state BlockState) {
+	if _, block := w.lightAt(pos); block <= meltLight-blockOpacity(state) {
+		return
+	}
+	if w.dimension.Ultrawarm {
+		w.SetBlock(pos.X, pos.Y, pos.Z, blockAir)
+	} else {
+		w.SetBlock(pos.X, pos.Y, pos.Z, blockWater)
+	}
+}
+
+// tickSnow melts snow layers near bright light sources.
+func tickSnow(w *World, pos Position, state BlockState) {
+	if _, block := w.lightAt(pos); block > meltLight {
+		w.SetBlock(pos.X, pos.Y, pos.Z, blockAir)
+	}
+}
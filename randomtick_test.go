@@ -0,0 +1,36 @@
+package MinecraftLightServer
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestTickCrop(t *testing.T) {
+	w := newTestWorld(t)
+	farmland, _ := ParseBlockState("minecraft:farmland[moisture=7]")
+
+	for name, maxAge := range cropAges {
+		t.Run(name, func(t *testing.T) {
+			crop, err := ParseBlockState(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pos := Position{0, w.Chunk(0, 0).Height(0, 0), 0}
+			w.SetBlock(pos.X, pos.Y-1, pos.Z, farmland)
+			w.SetBlock(pos.X, pos.Y, pos.Z, crop)
+			for i := 0; i < 10000 && w.GetBlock(pos.X, pos.Y, pos.Z).Property("age") != strconv.Itoa(maxAge); i++ {
+				tickCrop(w, pos, w.GetBlock(pos.X, pos.Y, pos.Z))
+			}
+			if age := w.GetBlock(pos.X, pos.Y, pos.Z).Property("age"); age != strconv.Itoa(maxAge) {
+				t.Fatalf("the crop has age %s, want %d", age, maxAge)
+			}
+			grown := w.GetBlock(pos.X, pos.Y, pos.Z)
+			for i := 0; i < 1000; i++ {
+				tickCrop(w, pos, grown)
+			}
+			if w.GetBlock(pos.X, pos.Y, pos.Z) != grown {
+				t.Error("the crop has grown after the last stage")
+			}
+		})
+	}
+}
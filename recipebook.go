@@ -0,0 +1,280 @@
+package MinecraftLightServer
+
+import "sync"
+
+// Recipe books of the client, with their protocol IDs.
+const (
+	recipeBookCrafting     = iota // crafting grids
+	recipeBookFurnace             // furnace
+	recipeBookBlastFurnace        // blast furnace
+	recipeBookSmoker              // smoker
+	recipeBooks                   // number of recipe books
+)
+
+// Unlock Recipes actions.
+const (
+	unlockRecipesInit   = iota // recipes known when the player joins
+	unlockRecipesAdd           // recipes unlocked later
+	unlockRecipesRemove        // recipes locked again
+)
+
+// recipeBook is the state of the recipe book of a player.
+type recipeBook struct {
+	unlocked map[string]bool   // IDs of the recipes unlocked by the player
+	open     [recipeBooks]bool // the recipe book is open in the window
+	filter   [recipeBooks]bool // only the recipes that can be made are shown
+	mutex    sync.Mutex        // recipe book mutex
+}
+
+// HasRecipe checks if the player has unlocked a recipe.
+func (p *Player) HasRecipe(id string) bool {
+	p.recipes.mutex.Lock()
+	defer p.recipes.mutex.Unlock()
+	return p.recipes.unlocked[namespaced(id)]
+}
+
+// UnlockRecipes adds recipes to the recipe book of the player.
+func (p *Player) UnlockRecipes(ids ...string) error {
+	return p.changeRecipes(unlockRecipesAdd, ids)
+}
+
+// LockRecipes removes recipes from the recipe book of the player.
+func (p *Player) LockRecipes(ids ...string) error {
+	return p.changeRecipes(unlockRecipesRemove, ids)
+}
+
+// changeRecipes adds or removes recipes from the recipe book and sends the ones that changed.
+func (p *Player) changeRecipes(action int, ids []string) error {
+	p.recipes.mutex.Lock()
+	if p.recipes.unlocked == nil {
+		p.recipes.unlocked = make(map[string]bool)
+	}
+	var changed []string
+	for _, id := range ids {
+		id = namespaced(id)
+		if RecipeByID(id) == nil || p.recipes.unlocked[id] == (action == unlockRecipesAdd) {
+			continue
+		}
+		if action == unlockRecipesAdd {
+			p.recipes.unlocked[id] = true
+		} else {
+			delete(p.recipes.unlocked, id)
+		}
+		changed = append(changed, id)
+	}
+	p.recipes.mutex.Unlock()
+
+	if len(changed) == 0 {
+		return nil
+	}
+	return p.writeUnlockRecipes(action, changed)
+}
+
+// canCraft checks if the player can use a crafting recipe: with the doLimitedCrafting
+// game rule only the unlocked recipes can be used.
+func (p *Player) canCraft(r *Recipe) bool {
+	return !p.World().rules.Bool("doLimitedCrafting") || p.HasRecipe(r.ID)
+}
+
+// setRecipeBookState saves if a recipe book is open and filtered, it's sent back when the player joins.
+func (p *Player) setRecipeBookState(book int, open, filter bool) {
+	if book < 0 || book >= recipeBooks {
+		return
+	}
+	p.recipes.mutex.Lock()
+	defer p.recipes.mutex.Unlock()
+	p.recipes.open[book], p.recipes.filter[book] = open, filter
+}
+
+// craftRecipe fills the crafting grid of a window with the ingredients of a recipe chosen
+// in the recipe book, as many times as possible if all is true. The client shows
+// the missing ingredients when the player doesn't have them.
+func (p *Player) craftRecipe(window int, id string, all bool) error {
+	recipe := RecipeByID(id)
+	if recipe == nil || !p.canCraft(recipe) {
+		return nil
+	}
+
+	var c *Container
+	if window != playerWindowID {
+		var open int
+		if open, c = p.OpenContainer(); c == nil || open != window {
+			return nil
+		}
+		c.mutex.Lock()
+	}
+	p.inventory.mutex.Lock()
+	filled := p.windowView(c).fillGrid(recipe, all)
+	p.inventory.mutex.Unlock()
+	if c != nil {
+		c.mutex.Unlock()
+	}
+
+	if !filled {
+		if err := NewPacket(craftRecipeResponsePacketID, Byte(window), String(recipe.ID)).Pack(p.connection); err != nil {
+			return err
+		}
+	}
+	return p.writeOpenWindowItems()
+}
+
+// fillGrid puts the ingredients of a recipe taken from the inventory in the crafting grid,
+// the items already in the grid go back to the inventory. It returns false if the
+// ingredients are missing.
+func (v *windowView) fillGrid(r *Recipe, all bool) bool {
+	if v.grid == nil || !r.fits(v.grid.size) {
+		return false
+	}
+	for i := 0; i < v.grid.size*v.grid.size; i++ {
+		slot := v.slots[v.grid.start+i]
+		*slot = v.inv.add(*slot)
+		if !slot.IsEmpty() {
+			v.updateResult()
+			return false
+		}
+	}
+
+	// Slots of the grid used by each ingredient
+	var cells []int
+	var ingredients []Ingredient
+	for i, ingredient := range r.Ingredients {
+		if len(ingredient) == 0 {
+			continue
+		}
+		cell := i
+		if r.Type == RecipeShaped {
+			cell = i/r.Width*v.grid.size + i%r.Width
+		}
+		cells = append(cells, cell)
+		ingredients = append(ingredients, ingredient)
+	}
+
+	count := 1
+	if all {
+		count = maxStackSize
+	}
+	for ; count > 0; count-- {
+		if taken, ok := v.inv.takeIngredients(ingredients, count); ok {
+			for i, cell := range cells {
+				*v.slots[v.grid.start+cell] = taken[i]
+			}
+			v.updateResult()
+			return true
+		}
+	}
+	v.updateResult()
+	return false
+}
+
+// takeIngredients removes from the main inventory and the hotbar count items for each
+// ingredient, it returns false without changing the inventory if they aren't enough.
+func (inv *Inventory) takeIngredients(ingredients []Ingredient, count int) ([]Slot, bool) {
+	slots := inv.slots
+	taken := make([]Slot, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		found := false
+		for _, id := range ingredient {
+			if item, ok := takeItems(slots[mainInventorySlot:offhandSlot], id, count); ok {
+				taken, found = append(taken, item), true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	inv.slots = slots
+	return taken, true
+}
+
+// takeItems removes count items with an ID from stacks of the same item,
+// it returns false if they aren't enough. The slots are changed anyway.
+func takeItems(slots []Slot, id int32, count int) (Slot, bool) {
+	var item Slot
+	for _, slot := range slots {
+		if slot.ItemID == id && !slot.IsEmpty() && slot.maxStack() >= count {
+			item = slot
+			break
+		}
+	}
+	if item.IsEmpty() {
+		return Slot{}, false
+	}
+
+	total := 0
+	for _, slot := range slots {
+		if slot.stacksWith(item) {
+			total += int(slot.Count)
+		}
+	}
+	if total < count {
+		return Slot{}, false
+	}
+
+	remaining := count
+	for i := range slots {
+		if remaining == 0 {
+			break
+		}
+		if slots[i].stacksWith(item) {
+			used := int(slots[i].Count)
+			if used > remaining {
+				used = remaining
+			}
+			slots[i] = slots[i].withCount(int(slots[i].Count) - used)
+			remaining -= used
+		}
+	}
+	return item.withCount(count), true
+}
+
+// writeDeclareRecipes sends the crafting and smelting recipes of the server.
+func (p *Player) writeDeclareRecipes() error {
+	recipes := Recipes()
+	packet := NewPacket(declareRecipesPacketID, VarInt(len(recipes)))
+	for _, recipe := range recipes {
+		recipe.write(packet)
+	}
+	return packet.Pack(p.connection)
+}
+
+// writeUnlockRecipes sends the recipes added to the recipe book or removed from it,
+// with the state of the recipe books.
+func (p *Player) writeUnlockRecipes(action int, ids []string) error {
+	p.recipes.mutex.Lock()
+	packet := NewPacket(unlockRecipesPacketID, VarInt(action))
+	for book := 0; book < recipeBooks; book++ {
+		_, _ = Boolean(p.recipes.open[book]).WriteTo(packet)
+		_, _ = Boolean(p.recipes.filter[book]).WriteTo(packet)
+	}
+	p.recipes.mutex.Unlock()
+
+	_, _ = VarInt(len(ids)).WriteTo(packet)
+	for _, id := range ids {
+		_, _ = String(id).WriteTo(packet)
+	}
+	if action == unlockRecipesInit {
+		// No recipe is highlighted as new
+		_, _ = VarInt(0).WriteTo(packet)
+	}
+	return packet.Pack(p.connection)
+}
+
+// writeRecipeBook sends all the recipes and unlocks them. Vanilla unlocks the recipes
+// with advancements, that the server doesn't have, so the players know every recipe.
+func (p *Player) writeRecipeBook() error {
+	if err := p.writeDeclareRecipes(); err != nil {
+		return err
+	}
+
+	recipes := Recipes()
+	ids := make([]string, 0, len(recipes))
+	p.recipes.mutex.Lock()
+	p.recipes.unlocked = make(map[string]bool, len(recipes))
+	for _, recipe := range recipes {
+		p.recipes.unlocked[recipe.ID] = true
+		ids = append(ids, recipe.ID)
+	}
+	p.recipes.mutex.Unlock()
+	return p.writeUnlockRecipes(unlockRecipesInit, ids)
+}
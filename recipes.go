@@ -0,0 +1,504 @@
+package MinecraftLightServer
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// RecipeType is the type of a recipe, named as in the vanilla data.
+type RecipeType string
+
+// Supported recipe types.
+const (
+	RecipeShaped    RecipeType = "minecraft:crafting_shaped"    // items placed in a pattern of the crafting grid
+	RecipeShapeless RecipeType = "minecraft:crafting_shapeless" // items placed anywhere in the crafting grid
+	RecipeSmelting  RecipeType = "minecraft:smelting"           // item cooked in a furnace
+)
+
+// Recipe settings.
+const (
+	maxRecipeSize   = 3   // width and height of the biggest crafting grid
+	defaultCookTime = 200 // ticks to smelt an item, when the recipe doesn't set them
+)
+
+// Ingredient is the list of items that can be used in a slot of a recipe,
+// it's empty for the slots that must be empty.
+type Ingredient []int32
+
+// Recipe is a way of making an item from other items.
+type Recipe struct {
+	ID          string       // namespaced name of the recipe
+	Type        RecipeType   // crafting or smelting recipe
+	Group       string       // recipes of the same group are shown together in the recipe book
+	Width       int          // width of the pattern of a shaped recipe
+	Height      int          // height of the pattern of a shaped recipe
+	Ingredients []Ingredient // row by row for shaped recipes, one for smelting recipes
+	Result      Slot         // item made by the recipe
+	Experience  float32      // experience gained by smelting
+	CookingTime int          // ticks to smelt the ingredient
+}
+
+// recipeRegistry contains the recipes known by the server and the item tags used by them.
+var recipeRegistry = struct {
+	recipes map[string]*Recipe  // recipes by ID
+	tags    map[string][]string // item names of each item tag
+	mutex   sync.RWMutex        // recipes mutex
+}{
+	recipes: make(map[string]*Recipe),
+	tags:    make(map[string][]string),
+}
+
+// builtinItemTags are the vanilla item tags used by the built-in recipes,
+// limited to the built-in items.
+var builtinItemTags = map[string][]string{
+	"minecraft:planks": {"minecraft:oak_planks", "minecraft:spruce_planks", "minecraft:birch_planks", "minecraft:jungle_planks",
+		"minecraft:acacia_planks", "minecraft:dark_oak_planks", "minecraft:crimson_planks", "minecraft:warped_planks"},
+	"minecraft:crimson_stems": {"minecraft:crimson_stem", "minecraft:stripped_crimson_stem",
+		"minecraft:crimson_hyphae", "minecraft:stripped_crimson_hyphae"},
+	"minecraft:warped_stems": {"minecraft:warped_stem", "minecraft:stripped_warped_stem",
+		"minecraft:warped_hyphae", "minecraft:stripped_warped_hyphae"},
+	"minecraft:stone_crafting_materials": {"minecraft:cobblestone"},
+	"minecraft:sand":                     {"minecraft:sand"},
+}
+
+// builtinRecipes are vanilla recipes known by the server without loading the vanilla data
+// (see LoadRecipes), they only use the built-in items.
+var builtinRecipes = map[string]string{
+	"minecraft:crimson_planks": `{"type":"minecraft:crafting_shapeless","group":"planks","ingredients":[{"tag":"minecraft:crimson_stems"}],"result":{"item":"minecraft:crimson_planks","count":4}}`,
+	"minecraft:warped_planks":  `{"type":"minecraft:crafting_shapeless","group":"planks","ingredients":[{"tag":"minecraft:warped_stems"}],"result":{"item":"minecraft:warped_planks","count":4}}`,
+	"minecraft:crafting_table": `{"type":"minecraft:crafting_shaped","pattern":["##","##"],"key":{"#":{"tag":"minecraft:planks"}},"result":{"item":"minecraft:crafting_table"}}`,
+	"minecraft:chest":          `{"type":"minecraft:crafting_shaped","pattern":["###","# #","###"],"key":{"#":{"tag":"minecraft:planks"}},"result":{"item":"minecraft:chest"}}`,
+	"minecraft:furnace":        `{"type":"minecraft:crafting_shaped","pattern":["###","# #","###"],"key":{"#":{"tag":"minecraft:stone_crafting_materials"}},"result":{"item":"minecraft:furnace"}}`,
+	"minecraft:sandstone":      `{"type":"minecraft:crafting_shaped","pattern":["##","##"],"key":{"#":{"item":"minecraft:sand"}},"result":{"item":"minecraft:sandstone"}}`,
+	"minecraft:stone_bricks":   `{"type":"minecraft:crafting_shaped","pattern":["##","##"],"key":{"#":{"item":"minecraft:stone"}},"result":{"item":"minecraft:stone_bricks","count":4}}`,
+	"minecraft:stone":          `{"type":"minecraft:smelting","ingredient":{"item":"minecraft:cobblestone"},"result":"minecraft:stone","experience":0.1,"cookingtime":200}`,
+	"minecraft:smooth_stone":   `{"type":"minecraft:smelting","ingredient":{"item":"minecraft:stone"},"result":"minecraft:smooth_stone","experience":0.1,"cookingtime":200}`,
+	"minecraft:glass":          `{"type":"minecraft:smelting","ingredient":{"tag":"minecraft:sand"},"result":"minecraft:glass","experience":0.1,"cookingtime":200}`,
+}
+
+func init() {
+	for name, tag := range builtinItemTags {
+		recipeRegistry.tags[name] = tag
+	}
+	for _, wood := range woodValues {
+		recipeRegistry.tags["minecraft:"+wood+"_logs"] = []string{"minecraft:" + wood + "_log", "minecraft:stripped_" + wood + "_log",
+			"minecraft:" + wood + "_wood", "minecraft:stripped_" + wood + "_wood"}
+		builtinRecipes["minecraft:"+wood+"_planks"] = `{"type":"minecraft:crafting_shapeless","group":"planks","ingredients":[{"tag":"minecraft:` +
+			wood + `_logs"}],"result":{"item":"minecraft:` + wood + `_planks","count":4}}`
+	}
+
+	for id, data := range builtinRecipes {
+		recipe, err := ParseRecipe(id, []byte(data))
+		if err != nil {
+			panic(err)
+		}
+		RegisterRecipe(recipe)
+	}
+}
+
+// RegisterRecipe adds a recipe to the server, replacing the one with the same ID.
+// The players that join later receive it.
+func RegisterRecipe(r *Recipe) {
+	recipeRegistry.mutex.Lock()
+	defer recipeRegistry.mutex.Unlock()
+	recipeRegistry.recipes[r.ID] = r
+}
+
+// RecipeByID returns the recipe with the specified ID, nil if it doesn't exist.
+func RecipeByID(id string) *Recipe {
+	recipeRegistry.mutex.RLock()
+	defer recipeRegistry.mutex.RUnlock()
+	return recipeRegistry.recipes[namespaced(id)]
+}
+
+// Recipes returns all the recipes of the server, sorted by ID.
+func Recipes() []*Recipe {
+	recipeRegistry.mutex.RLock()
+	recipes := make([]*Recipe, 0, len(recipeRegistry.recipes))
+	for _, recipe := range recipeRegistry.recipes {
+		recipes = append(recipes, recipe)
+	}
+	recipeRegistry.mutex.RUnlock()
+	sort.Slice(recipes, func(i, j int) bool {
+		return recipes[i].ID < recipes[j].ID
+	})
+	return recipes
+}
+
+// SetItemTag defines an item tag used by the ingredients of the recipes parsed later.
+func SetItemTag(name string, items []string) {
+	recipeRegistry.mutex.Lock()
+	defer recipeRegistry.mutex.Unlock()
+	recipeRegistry.tags[namespaced(name)] = items
+}
+
+// LoadItemTags reads the item tags of a data pack directory, like data/minecraft/tags/items.
+// Tags can include other tags with the # prefix. They must be loaded before the recipes.
+func LoadItemTags(dir string) error {
+	tags := make(map[string][]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var tag struct {
+			Values []string `json:"values"`
+		}
+		if err := json.Unmarshal(data, &tag); err != nil {
+			return errors.New("invalid item tag " + path + ": " + err.Error())
+		}
+		tags[dataPackID(dir, path)] = tag.Values
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Resolve the tags included by other tags
+	var resolve func(name string, visited map[string]bool) []string
+	resolve = func(name string, visited map[string]bool) []string {
+		if visited[name] {
+			return nil
+		}
+		visited[name] = true
+		var items []string
+		for _, value := range tags[name] {
+			if strings.HasPrefix(value, "#") {
+				items = append(items, resolve(namespaced(value[1:]), visited)...)
+			} else {
+				items = append(items, namespaced(value))
+			}
+		}
+		return items
+	}
+	for name := range tags {
+		SetItemTag(name, resolve(name, make(map[string]bool)))
+	}
+	return nil
+}
+
+// LoadRecipes reads the recipes of a data pack directory, like data/minecraft/recipes,
+// and adds them to the server. The recipes that can't be used, like the special
+// crafting recipes and the ones with unknown items, are skipped.
+// It returns the number of recipes added.
+func LoadRecipes(dir string) (int, error) {
+	loaded := 0
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if recipe, err := ParseRecipe(dataPackID(dir, path), data); err == nil {
+			RegisterRecipe(recipe)
+			loaded++
+		}
+		return nil
+	})
+	return loaded, err
+}
+
+// dataPackID returns the namespaced ID of a data pack file, from its path in a directory.
+func dataPackID(dir, path string) string {
+	rel, _ := filepath.Rel(dir, path)
+	return namespaced(strings.TrimSuffix(filepath.ToSlash(rel), ".json"))
+}
+
+// ParseRecipe reads a recipe in the vanilla JSON format.
+func ParseRecipe(id string, data []byte) (*Recipe, error) {
+	var recipe struct {
+		Type        RecipeType                 `json:"type"`
+		Group       string                     `json:"group"`
+		Pattern     []string                   `json:"pattern"`
+		Key         map[string]json.RawMessage `json:"key"`
+		Ingredients []json.RawMessage          `json:"ingredients"`
+		Ingredient  json.RawMessage            `json:"ingredient"`
+		Result      json.RawMessage            `json:"result"`
+		Experience  float32                    `json:"experience"`
+		CookingTime int                        `json:"cookingtime"`
+	}
+	if err := json.Unmarshal(data, &recipe); err != nil {
+		return nil, errors.New("invalid recipe " + id + ": " + err.Error())
+	}
+
+	r := &Recipe{ID: namespaced(id), Type: RecipeType(namespaced(string(recipe.Type))), Group: recipe.Group}
+	var err error
+	switch r.Type {
+	case RecipeShaped:
+		err = r.parsePattern(recipe.Pattern, recipe.Key)
+	case RecipeShapeless:
+		if len(recipe.Ingredients) == 0 || len(recipe.Ingredients) > maxRecipeSize*maxRecipeSize {
+			return nil, errors.New("invalid ingredients in recipe " + id)
+		}
+		for _, data := range recipe.Ingredients {
+			ingredient, err := parseIngredient(data)
+			if err != nil {
+				return nil, err
+			}
+			r.Ingredients = append(r.Ingredients, ingredient)
+		}
+	case RecipeSmelting:
+		ingredient, err := parseIngredient(recipe.Ingredient)
+		if err != nil {
+			return nil, err
+		}
+		r.Ingredients = []Ingredient{ingredient}
+		r.Experience, r.CookingTime = recipe.Experience, recipe.CookingTime
+		if r.CookingTime <= 0 {
+			r.CookingTime = defaultCookTime
+		}
+	default:
+		return nil, errors.New("unsupported recipe type: " + string(recipe.Type))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.Result, err = parseResult(recipe.Result)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// parsePattern reads the ingredients of a shaped recipe from its pattern and keys.
+func (r *Recipe) parsePattern(pattern []string, keys map[string]json.RawMessage) error {
+	r.Height = len(pattern)
+	if r.Height == 0 || r.Height > maxRecipeSize {
+		return errors.New("invalid pattern in recipe " + r.ID)
+	}
+	r.Width = utf8.RuneCountInString(pattern[0])
+
+	ingredients := map[rune]Ingredient{' ': nil}
+	for key, data := range keys {
+		if utf8.RuneCountInString(key) != 1 || key == " " {
+			return errors.New("invalid key in recipe " + r.ID + ": " + key)
+		}
+		ingredient, err := parseIngredient(data)
+		if err != nil {
+			return err
+		}
+		ingredients[[]rune(key)[0]] = ingredient
+	}
+
+	for _, row := range pattern {
+		if utf8.RuneCountInString(row) != r.Width || r.Width == 0 || r.Width > maxRecipeSize {
+			return errors.New("invalid pattern in recipe " + r.ID)
+		}
+		for _, key := range row {
+			ingredient, ok := ingredients[key]
+			if !ok {
+				return errors.New("undefined key in recipe " + r.ID + ": " + string(key))
+			}
+			r.Ingredients = append(r.Ingredients, ingredient)
+		}
+	}
+	return nil
+}
+
+// ingredientChoice is an item or an item tag accepted by an ingredient.
+type ingredientChoice struct {
+	Item string `json:"item"`
+	Tag  string `json:"tag"`
+}
+
+// parseIngredient reads an ingredient: an item, a tag or a list of them.
+func parseIngredient(data json.RawMessage) (Ingredient, error) {
+	var choices []ingredientChoice
+	if err := json.Unmarshal(data, &choices); err != nil {
+		choices = make([]ingredientChoice, 1)
+		if err := json.Unmarshal(data, &choices[0]); err != nil {
+			return nil, errors.New("invalid ingredient: " + string(data))
+		}
+	}
+
+	var ingredient Ingredient
+	for _, choice := range choices {
+		names := []string{choice.Item}
+		if choice.Tag != "" {
+			recipeRegistry.mutex.RLock()
+			names = recipeRegistry.tags[namespaced(choice.Tag)]
+			recipeRegistry.mutex.RUnlock()
+		}
+		for _, name := range names {
+			if id, ok := itemRegistry.ID(name); ok {
+				ingredient = append(ingredient, id)
+			}
+		}
+	}
+	if len(ingredient) == 0 {
+		return nil, errors.New("ingredient without known items: " + string(data))
+	}
+	return ingredient, nil
+}
+
+// parseResult reads the result of a recipe: an item name, or an item with a count.
+func parseResult(data json.RawMessage) (Slot, error) {
+	result := struct {
+		Item  string `json:"item"`
+		Count int    `json:"count"`
+	}{Count: 1}
+	if err := json.Unmarshal(data, &result.Item); err != nil {
+		if err := json.Unmarshal(data, &result); err != nil {
+			return Slot{}, errors.New("invalid result: " + string(data))
+		}
+	}
+	if result.Count <= 0 || result.Count > maxStackSize {
+		return Slot{}, errors.New("invalid result count: " + string(data))
+	}
+	return NewItem(result.Item, result.Count)
+}
+
+// matches checks if an item can be used as the ingredient.
+func (i Ingredient) matches(item Slot) bool {
+	if len(i) == 0 || item.IsEmpty() {
+		return len(i) == 0 && item.IsEmpty()
+	}
+	for _, id := range i {
+		if id == item.ItemID {
+			return true
+		}
+	}
+	return false
+}
+
+// isCrafting checks if the recipe is made in a crafting grid.
+func (r *Recipe) isCrafting() bool {
+	return r.Type == RecipeShaped || r.Type == RecipeShapeless
+}
+
+// fits checks if the recipe can be made in a square crafting grid of the specified size.
+func (r *Recipe) fits(size int) bool {
+	if r.Type == RecipeShaped {
+		return r.Width <= size && r.Height <= size
+	}
+	return r.Type == RecipeShapeless && len(r.Ingredients) <= size*size
+}
+
+// matches checks if the items of a square crafting grid, row by row, make the recipe.
+func (r *Recipe) matches(grid []Slot, size int) bool {
+	// Only the smallest rectangle with all the items is compared with the pattern
+	minX, minY, maxX, maxY := size, size, -1, -1
+	var items []Slot
+	for i, item := range grid {
+		if item.IsEmpty() {
+			continue
+		}
+		items = append(items, item)
+		x, y := i%size, i/size
+		if x < minX {
+			minX = x
+		}
+		if x > maxX {
+			maxX = x
+		}
+		if y < minY {
+			minY = y
+		}
+		if y > maxY {
+			maxY = y
+		}
+	}
+	if len(items) == 0 {
+		return false
+	}
+
+	switch r.Type {
+	case RecipeShaped:
+		if maxX-minX+1 != r.Width || maxY-minY+1 != r.Height {
+			return false
+		}
+		normal, mirrored := true, true
+		for y := 0; y < r.Height; y++ {
+			for x := 0; x < r.Width; x++ {
+				item := grid[(minY+y)*size+minX+x]
+				normal = normal && r.Ingredients[y*r.Width+x].matches(item)
+				mirrored = mirrored && r.Ingredients[y*r.Width+r.Width-1-x].matches(item)
+			}
+		}
+		return normal || mirrored
+
+	case RecipeShapeless:
+		return len(items) == len(r.Ingredients) && matchShapeless(items, r.Ingredients, make([]bool, len(items)))
+	}
+	return false
+}
+
+// matchShapeless checks if each ingredient can use a different item,
+// used marks the items already taken by other ingredients.
+func matchShapeless(items []Slot, ingredients []Ingredient, used []bool) bool {
+	if len(ingredients) == 0 {
+		return true
+	}
+	for i, item := range items {
+		if used[i] || !ingredients[0].matches(item) {
+			continue
+		}
+		used[i] = true
+		if matchShapeless(items, ingredients[1:], used) {
+			return true
+		}
+		used[i] = false
+	}
+	return false
+}
+
+// findCraftingRecipe returns the recipe made by the items of a square crafting grid,
+// nil if there isn't one. Only the recipes accepted by allowed are used, all if it's nil.
+func findCraftingRecipe(grid []Slot, size int, allowed func(r *Recipe) bool) *Recipe {
+	for _, recipe := range Recipes() {
+		if recipe.isCrafting() && recipe.fits(size) && recipe.matches(grid, size) && (allowed == nil || allowed(recipe)) {
+			return recipe
+		}
+	}
+	return nil
+}
+
+// writeIngredient writes an ingredient as the list of the items that it accepts.
+func writeIngredient(packet *Packet, ingredient Ingredient) {
+	_, _ = VarInt(len(ingredient)).WriteTo(packet)
+	for _, id := range ingredient {
+		_, _ = Slot{Present: true, ItemID: id, Count: 1}.WriteTo(packet)
+	}
+}
+
+// write writes the recipe in the format of the Declare Recipes packet.
+func (r *Recipe) write(packet *Packet) {
+	_, _ = String(r.Type).WriteTo(packet)
+	_, _ = String(r.ID).WriteTo(packet)
+	switch r.Type {
+	case RecipeShaped:
+		_, _ = VarInt(r.Width).WriteTo(packet)
+		_, _ = VarInt(r.Height).WriteTo(packet)
+		_, _ = String(r.Group).WriteTo(packet)
+		for _, ingredient := range r.Ingredients {
+			writeIngredient(packet, ingredient)
+		}
+	case RecipeShapeless:
+		_, _ = String(r.Group).WriteTo(packet)
+		_, _ = VarInt(len(r.Ingredients)).WriteTo(packet)
+		for _, ingredient := range r.Ingredients {
+			writeIngredient(packet, ingredient)
+		}
+	case RecipeSmelting:
+		_, _ = String(r.Group).WriteTo(packet)
+		writeIngredient(packet, r.Ingredients[0])
+	}
+	_, _ = r.Result.WriteTo(packet)
+	if r.Type == RecipeSmelting {
+		_, _ = Float(r.Experience).WriteTo(packet)
+		_, _ = VarInt(r.CookingTime).WriteTo(packet)
+	}
+}
@@ -0,0 +1,91 @@
+package MinecraftLightServer
+
+import "testing"
+
+// testItem returns a stack of a built-in item, it stops the test if the item doesn't exist.
+func testItem(t *testing.T, name string, count int) Slot {
+	t.Helper()
+	item, err := NewItem(name, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return item
+}
+
+// testRecipe parses a recipe, it stops the test if the recipe isn't valid.
+func testRecipe(t *testing.T, data string) *Recipe {
+	t.Helper()
+	recipe, err := ParseRecipe("test", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recipe
+}
+
+func TestRecipeMatches(t *testing.T) {
+	sandstone := RecipeByID("minecraft:sandstone")
+	chest := RecipeByID("minecraft:chest")
+	oakPlanks := RecipeByID("minecraft:oak_planks")
+	asymmetric := testRecipe(t, `{"type":"crafting_shaped","pattern":["SC"," C"],"key":{"S":{"item":"stone"},"C":{"item":"cobblestone"}},"result":"stone"}`)
+	mixed := testRecipe(t, `{"type":"crafting_shapeless","ingredients":[{"tag":"planks"},{"item":"oak_planks"}],"result":"stone"}`)
+
+	tests := []struct {
+		name   string
+		recipe *Recipe
+		size   int
+		items  []string // items of the grid row by row, empty strings are empty slots
+		want   bool
+	}{
+		{"shaped in the same grid", sandstone, 2, []string{"sand", "sand", "sand", "sand"}, true},
+		{"shaped in a bigger grid", sandstone, 3, []string{"", "", "", "", "sand", "sand", "", "sand", "sand"}, true},
+		{"shaped with a missing item", sandstone, 2, []string{"sand", "sand", "sand", ""}, false},
+		{"shaped with another item", sandstone, 2, []string{"sand", "sand", "sand", "stone"}, false},
+		{"shaped with an extra item", sandstone, 3, []string{"sand", "sand", "", "sand", "sand", "", "", "", "sand"}, false},
+		{"shaped with an empty slot", chest, 3, []string{"oak_planks", "oak_planks", "oak_planks", "birch_planks", "", "oak_planks", "oak_planks", "oak_planks", "oak_planks"}, true},
+		{"shaped with a filled hole", chest, 3, []string{"oak_planks", "oak_planks", "oak_planks", "oak_planks", "oak_planks", "oak_planks", "oak_planks", "oak_planks", "oak_planks"}, false},
+		{"shaped in a small grid", chest, 2, []string{"oak_planks", "oak_planks", "oak_planks", "oak_planks"}, false},
+		{"asymmetric", asymmetric, 2, []string{"stone", "cobblestone", "", "cobblestone"}, true},
+		{"asymmetric mirrored", asymmetric, 2, []string{"cobblestone", "stone", "cobblestone", ""}, true},
+		{"asymmetric upside down", asymmetric, 2, []string{"", "cobblestone", "stone", "cobblestone"}, false},
+		{"shapeless anywhere", oakPlanks, 3, []string{"", "", "", "", "", "", "", "", "stripped_oak_log"}, true},
+		{"shapeless with another wood", oakPlanks, 2, []string{"birch_log", "", "", ""}, false},
+		{"shapeless with too many items", oakPlanks, 2, []string{"oak_log", "oak_log", "", ""}, false},
+		{"shapeless in order", mixed, 2, []string{"spruce_planks", "oak_planks", "", ""}, true},
+		{"shapeless in another order", mixed, 2, []string{"oak_planks", "spruce_planks", "", ""}, true},
+		{"shapeless without the specific item", mixed, 2, []string{"spruce_planks", "birch_planks", "", ""}, false},
+		{"empty grid", oakPlanks, 2, []string{"", "", "", ""}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.recipe == nil {
+				t.Fatal("recipe not found")
+			}
+			grid := make([]Slot, len(test.items))
+			for i, name := range test.items {
+				if name != "" {
+					grid[i] = testItem(t, name, 1)
+				}
+			}
+			if got := test.recipe.fits(test.size) && test.recipe.matches(grid, test.size); got != test.want {
+				t.Errorf("matches returned %v", got)
+			}
+		})
+	}
+}
+
+func TestRecipeMultiByteKeys(t *testing.T) {
+	recipe := testRecipe(t, `{"type":"crafting_shaped","pattern":["éé","é "],"key":{"é":{"item":"stone"}},"result":"cobblestone"}`)
+	if recipe.Width != 2 || recipe.Height != 2 || len(recipe.Ingredients) != 4 {
+		t.Fatalf("pattern of %dx%d with %d ingredients, want 2x2 with 4", recipe.Width, recipe.Height, len(recipe.Ingredients))
+	}
+
+	stone := testItem(t, "stone", 1)
+	if !recipe.matches([]Slot{stone, stone, stone, {}}, 2) {
+		t.Error("recipe doesn't match its pattern")
+	}
+
+	if _, err := ParseRecipe("test", []byte(`{"type":"crafting_shaped","pattern":["éé","é"],"key":{"é":{"item":"stone"}},"result":"cobblestone"}`)); err == nil {
+		t.Error("pattern with rows of different widths accepted")
+	}
+}
@@ -0,0 +1,575 @@
+package MinecraftLightServer
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Redstone settings in ticks, the same as vanilla.
+const (
+	maxPower           = 15 // power of the sources, redstone dust loses 1 for each block
+	buttonDelay        = 20 // ticks a stone button stays pressed
+	woodenButtonDelay  = 30 // ticks a wooden button stays pressed
+	repeaterDelay      = 2  // ticks of each delay step of a repeater
+	maxRepeaterDelay   = 4  // delay steps of a repeater
+	lampOffDelay       = 4  // ticks before an unpowered lamp turns off
+	torchDelay         = 2  // ticks before a redstone torch changes
+	pressurePlateDelay = 20 // ticks between two checks of a pressed plate
+	pressurePlateGrace = 30 // ticks without players on a plate before it's released
+)
+
+// redstoneComponents are the blocks that take part in redstone circuits without conducting power.
+var redstoneComponents = map[string]bool{
+	"minecraft:redstone_wire":        true,
+	"minecraft:lever":                true,
+	"minecraft:stone_button":         true,
+	"minecraft:stone_pressure_plate": true,
+	"minecraft:redstone_torch":       true,
+	"minecraft:redstone_wall_torch":  true,
+	"minecraft:repeater":             true,
+}
+
+// facingDirections are the offsets of the facing property values.
+var facingDirections = map[string]Position{
+	"north": {0, 0, -1},
+	"south": {0, 0, 1},
+	"west":  {-1, 0, 0},
+	"east":  {1, 0, 0},
+	"up":    {0, 1, 0},
+	"down":  {0, -1, 0},
+}
+
+// Directions used by the redstone components.
+var (
+	directionUp   = Position{0, 1, 0}
+	directionDown = Position{0, -1, 0}
+)
+
+// pressurePlates are the pressure plates pressed by the players of a world.
+type pressurePlates struct {
+	stepped map[Position]int64 // last tick a player was on each pressed plate
+	mutex   sync.Mutex         // pressure plates mutex
+}
+
+func init() {
+	for _, wood := range woodValues {
+		redstoneComponents["minecraft:"+wood+"_pressure_plate"] = true
+	}
+	for _, wood := range append(woodValues, "crimson", "warped") {
+		redstoneComponents["minecraft:"+wood+"_button"] = true
+	}
+	for _, wood := range append(woodValues, "crimson", "warped", "iron") {
+		neighborUpdateHandlers["minecraft:"+wood+"_door"] = updateDoor
+	}
+
+	neighborUpdateHandlers["minecraft:redstone_wire"] = updateWire
+	neighborUpdateHandlers["minecraft:redstone_lamp"] = updateLamp
+	neighborUpdateHandlers["minecraft:redstone_torch"] = updateTorch
+	neighborUpdateHandlers["minecraft:redstone_wall_torch"] = updateTorch
+	neighborUpdateHandlers["minecraft:repeater"] = updateRepeater
+	blockTickHandlers["minecraft:redstone_lamp"] = tickLamp
+	blockTickHandlers["minecraft:redstone_torch"] = tickTorch
+	blockTickHandlers["minecraft:redstone_wall_torch"] = tickTorch
+	blockTickHandlers["minecraft:repeater"] = tickRepeater
+	for name := range redstoneComponents {
+		if strings.HasSuffix(name, "_pressure_plate") {
+			blockTickHandlers[name] = tickPressurePlate
+		}
+		if isButton(name) {
+			blockTickHandlers[name] = tickButton
+		}
+	}
+}
+
+// opposite returns the opposite direction.
+func (p Position) opposite() Position {
+	return Position{-p.X, -p.Y, -p.Z}
+}
+
+// isRedstoneComponent checks if a block is part of redstone circuits.
+func isRedstoneComponent(state BlockState) bool {
+	return redstoneComponents[state.Name()]
+}
+
+// isConductor checks if a block transmits the power of the components attached to it.
+func isConductor(state BlockState) bool {
+	if !heightmapMatches(motionBlocking, state) || isFluid(state) || isRedstoneComponent(state) {
+		return false
+	}
+	name := state.Name()
+	return !strings.Contains(name, "glass") && !strings.HasSuffix(name, "_leaves") &&
+		!strings.HasSuffix(name, "_door") && !strings.Contains(name, "piston")
+}
+
+// isButton checks if a block is a stone or wooden button.
+func isButton(name string) bool {
+	return strings.HasSuffix(name, "_button")
+}
+
+// isPowered checks if the powered property of a block is true.
+func isPowered(state BlockState) bool {
+	return state.Property("powered") == "true"
+}
+
+// isLit checks if the lit property of a block is true.
+func isLit(state BlockState) bool {
+	return state.Property("lit") == "true"
+}
+
+// attachedDirection returns the direction of the block where a lever,
+// button or torch is attached.
+func attachedDirection(state BlockState) Position {
+	switch state.Property("face") {
+	case "floor":
+		return directionDown
+	case "ceiling":
+		return directionUp
+	}
+	if facing, ok := facingDirections[state.Property("facing")]; ok {
+		// Wall components face away from their block
+		return facing.opposite()
+	}
+	return directionDown
+}
+
+// wireSignal returns the power that redstone dust gives to the block in a direction:
+// the block below and the ones where the dust points.
+func wireSignal(state BlockState, dir Position) int {
+	if dir == directionUp {
+		return 0
+	}
+	power, _ := strconv.Atoi(state.Property("power"))
+	if dir == directionDown {
+		return power
+	}
+	for name, facing := range facingDirections {
+		if facing == dir && state.Property(name) != "none" {
+			return power
+		}
+	}
+	return 0
+}
+
+// directSignal returns the strong power that a block gives to the block in a direction,
+// strongly powered blocks power the redstone dust next to them.
+func directSignal(state BlockState, dir Position) int {
+	name := state.Name()
+	switch {
+	case name == "minecraft:redstone_wire":
+		return wireSignal(state, dir)
+	case name == "minecraft:lever" || isButton(name):
+		if isPowered(state) && dir == attachedDirection(state) {
+			return maxPower
+		}
+	case strings.HasSuffix(name, "_pressure_plate"):
+		if isPowered(state) && dir == directionDown {
+			return maxPower
+		}
+	case name == "minecraft:redstone_torch" || name == "minecraft:redstone_wall_torch":
+		if isLit(state) && dir == directionUp {
+			return maxPower
+		}
+	case name == "minecraft:repeater":
+		if isPowered(state) && dir == repeaterOutput(state) {
+			return maxPower
+		}
+	}
+	return 0
+}
+
+// emittedSignal returns the power that a block gives to the block in a direction.
+func emittedSignal(state BlockState, dir Position) int {
+	name := state.Name()
+	switch {
+	case name == "minecraft:redstone_wire":
+		return wireSignal(state, dir)
+	case name == "minecraft:lever" || isButton(name) || strings.HasSuffix(name, "_pressure_plate"):
+		if isPowered(state) {
+			return maxPower
+		}
+	case name == "minecraft:redstone_torch":
+		if isLit(state) && dir != directionDown {
+			return maxPower
+		}
+	case name == "minecraft:redstone_wall_torch":
+		if isLit(state) && dir != attachedDirection(state) {
+			return maxPower
+		}
+	case name == "minecraft:repeater":
+		if isPowered(state) && dir == repeaterOutput(state) {
+			return maxPower
+		}
+	}
+	return 0
+}
+
+// directPowerInto returns the strong power that a block receives from its neighbors,
+// the power of the redstone dust is ignored if wires is false.
+func (w *World) directPowerInto(pos Position, wires bool) int {
+	power := 0
+	for _, dir := range allDirections {
+		neighbor := pos.offset(dir)
+		state := w.GetBlock(neighbor.X, neighbor.Y, neighbor.Z)
+		if !wires && state.Name() == "minecraft:redstone_wire" {
+			continue
+		}
+		if signal := directSignal(state, dir.opposite()); signal > power {
+			power = signal
+		}
+	}
+	return power
+}
+
+// signalFrom returns the power that a block receives from its neighbor in a direction,
+// conductors transmit the strong power that they receive.
+func (w *World) signalFrom(pos, dir Position, wires bool) int {
+	neighbor := pos.offset(dir)
+	state := w.GetBlock(neighbor.X, neighbor.Y, neighbor.Z)
+	if isConductor(state) {
+		return w.directPowerInto(neighbor, wires)
+	}
+	if !wires && state.Name() == "minecraft:redstone_wire" {
+		return 0
+	}
+	return emittedSignal(state, dir.opposite())
+}
+
+// neighborPower returns the highest power that a block receives from its neighbors,
+// the power of the redstone dust is ignored if wires is false.
+func (w *World) neighborPower(pos Position, wires bool) int {
+	power := 0
+	for _, dir := range allDirections {
+		if signal := w.signalFrom(pos, dir, wires); signal > power {
+			power = signal
+			if power == maxPower {
+				break
+			}
+		}
+	}
+	return power
+}
+
+// Power returns the redstone power received by the block at a position, from 0 to 15.
+func (w *World) Power(pos Position) int {
+	return w.neighborPower(pos, true)
+}
+
+// wirePower returns the power of redstone dust, -1 if the block isn't redstone dust.
+func (w *World) wirePower(pos Position) int {
+	state := w.GetBlock(pos.X, pos.Y, pos.Z)
+	if state.Name() != "minecraft:redstone_wire" {
+		return -1
+	}
+	power, _ := strconv.Atoi(state.Property("power"))
+	return power
+}
+
+// connectsToWire checks if redstone dust points to a block in a direction.
+func connectsToWire(state BlockState, dir Position) bool {
+	name := state.Name()
+	switch {
+	case name == "minecraft:redstone_wire":
+		return true
+	case name == "minecraft:repeater":
+		facing := facingDirections[state.Property("facing")]
+		return facing == dir || facing == dir.opposite()
+	}
+	return isRedstoneComponent(state)
+}
+
+// wireShape returns the state of redstone dust with its sides connected to its neighbors.
+func (w *World) wireShape(pos Position, state BlockState) BlockState {
+	aboveConductor := isConductor(w.GetBlock(pos.X, pos.Y+1, pos.Z))
+	sides := make(map[string]string, len(horizontalDirections))
+	connections := 0
+	for _, name := range []string{"north", "south", "west", "east"} {
+		dir := facingDirections[name]
+		neighbor := pos.offset(dir)
+		neighborState := w.GetBlock(neighbor.X, neighbor.Y, neighbor.Z)
+		conductor := isConductor(neighborState)
+
+		side := "none"
+		switch {
+		case !aboveConductor && conductor && w.wirePower(neighbor.offset(directionUp)) >= 0:
+			side = "up"
+		case connectsToWire(neighborState, dir):
+			side = "side"
+		case !conductor && w.wirePower(neighbor.offset(directionDown)) >= 0:
+			side = "side"
+		}
+		if side != "none" {
+			connections++
+		}
+		sides[name] = side
+	}
+
+	// Unconnected dust is a cross, dust connected on one side becomes a line
+	switch {
+	case connections == 0:
+		for name := range sides {
+			sides[name] = "side"
+		}
+	case connections == 1:
+		for name, side := range sides {
+			if side != "none" {
+				sides[oppositeFacing(name)] = "side"
+			}
+		}
+	}
+	for name, side := range sides {
+		state = state.With(name, side)
+	}
+	return state
+}
+
+// oppositeFacing returns the opposite horizontal facing.
+func oppositeFacing(facing string) string {
+	switch facing {
+	case "north":
+		return "south"
+	case "south":
+		return "north"
+	case "west":
+		return "east"
+	}
+	return "west"
+}
+
+// updateWire updates the shape and the power of redstone dust, that loses 1 power
+// for each block. Dust without a block below breaks.
+func updateWire(w *World, pos Position, state BlockState) {
+	if !isConductor(w.GetBlock(pos.X, pos.Y-1, pos.Z)) {
+		w.SetBlock(pos.X, pos.Y, pos.Z, blockAir)
+		return
+	}
+
+	power := w.neighborPower(pos, false)
+	aboveConductor := isConductor(w.GetBlock(pos.X, pos.Y+1, pos.Z))
+	for _, dir := range horizontalDirections {
+		if power == maxPower {
+			break
+		}
+		neighbor := pos.offset(dir)
+		candidates := []int{w.wirePower(neighbor)}
+		if isConductor(w.GetBlock(neighbor.X, neighbor.Y, neighbor.Z)) {
+			if !aboveConductor {
+				candidates = append(candidates, w.wirePower(neighbor.offset(directionUp)))
+			}
+		} else {
+			candidates = append(candidates, w.wirePower(neighbor.offset(directionDown)))
+		}
+		for _, candidate := range candidates {
+			if candidate-1 > power {
+				power = candidate - 1
+			}
+		}
+	}
+
+	updated := w.wireShape(pos, state).With("power", strconv.Itoa(power))
+	if updated != state {
+		w.SetBlock(pos.X, pos.Y, pos.Z, updated)
+	}
+}
+
+// updateLamp turns on a redstone lamp when it's powered, it turns off after a delay.
+func updateLamp(w *World, pos Position, state BlockState) {
+	powered := w.Power(pos) > 0
+	if powered && !isLit(state) {
+		w.SetBlock(pos.X, pos.Y, pos.Z, state.With("lit", "true"))
+	} else if !powered && isLit(state) {
+		w.ScheduleTick(pos, lampOffDelay)
+	}
+}
+
+// tickLamp turns off a redstone lamp that isn't powered anymore.
+func tickLamp(w *World, pos Position, state BlockState) {
+	if isLit(state) && w.Power(pos) == 0 {
+		w.SetBlock(pos.X, pos.Y, pos.Z, state.With("lit", "false"))
+	}
+}
+
+// torchShouldBeLit checks if a redstone torch is lit: it's off when the block
+// where it's attached is powered.
+func (w *World) torchShouldBeLit(pos Position, state BlockState) bool {
+	return w.signalFrom(pos, attachedDirection(state), true) == 0
+}
+
+// updateTorch schedules a redstone torch that has to change.
+func updateTorch(w *World, pos Position, state BlockState) {
+	if w.torchShouldBeLit(pos, state) != isLit(state) {
+		w.ScheduleTick(pos, torchDelay)
+	}
+}
+
+// tickTorch turns a redstone torch on or off.
+func tickTorch(w *World, pos Position, state BlockState) {
+	if lit := w.torchShouldBeLit(pos, state); lit != isLit(state) {
+		w.SetBlock(pos.X, pos.Y, pos.Z, state.With("lit", strconv.FormatBool(lit)))
+	}
+}
+
+// repeaterOutput returns the direction where a repeater sends power,
+// the opposite of its facing.
+func repeaterOutput(state BlockState) Position {
+	return facingDirections[state.Property("facing")].opposite()
+}
+
+// repeaterShouldBePowered checks if a repeater receives power from the block behind it.
+func (w *World) repeaterShouldBePowered(pos Position, state BlockState) bool {
+	return w.signalFrom(pos, repeaterOutput(state).opposite(), true) > 0
+}
+
+// repeaterLocked checks if a powered repeater points to a side of a repeater.
+func (w *World) repeaterLocked(pos Position, state BlockState) bool {
+	output := repeaterOutput(state)
+	for _, dir := range horizontalDirections {
+		if dir == output || dir == output.opposite() {
+			continue
+		}
+		neighbor := pos.offset(dir)
+		neighborState := w.GetBlock(neighbor.X, neighbor.Y, neighbor.Z)
+		if neighborState.Name() == "minecraft:repeater" && directSignal(neighborState, dir.opposite()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// repeaterDelayTicks returns the ticks that a repeater waits before changing.
+func repeaterDelayTicks(state BlockState) int {
+	delay, _ := strconv.Atoi(state.Property("delay"))
+	return delay * repeaterDelay
+}
+
+// updateRepeater locks a repeater when another one powers its side and schedules
+// an unlocked repeater that has to change.
+func updateRepeater(w *World, pos Position, state BlockState) {
+	locked := w.repeaterLocked(pos, state)
+	if locked != (state.Property("locked") == "true") {
+		w.SetBlock(pos.X, pos.Y, pos.Z, state.With("locked", strconv.FormatBool(locked)))
+		return
+	}
+	if !locked && w.repeaterShouldBePowered(pos, state) != isPowered(state) {
+		w.ScheduleTick(pos, repeaterDelayTicks(state))
+	}
+}
+
+// tickRepeater turns a repeater on or off, short pulses are extended to the delay of the repeater.
+func tickRepeater(w *World, pos Position, state BlockState) {
+	if state.Property("locked") == "true" {
+		return
+	}
+	powered := w.repeaterShouldBePowered(pos, state)
+	switch {
+	case isPowered(state) && !powered:
+		w.SetBlock(pos.X, pos.Y, pos.Z, state.With("powered", "false"))
+	case !isPowered(state):
+		w.SetBlock(pos.X, pos.Y, pos.Z, state.With("powered", "true"))
+		if !powered {
+			w.ScheduleTick(pos, repeaterDelayTicks(state))
+		}
+	}
+}
+
+// doorHalves returns the positions of the lower and upper halves of a door.
+func doorHalves(pos Position, state BlockState) (lower, upper Position) {
+	if state.Property("half") == "upper" {
+		return pos.offset(directionDown), pos
+	}
+	return pos, pos.offset(directionUp)
+}
+
+// setDoor changes the open and powered properties of both the halves of a door.
+func (w *World) setDoor(pos Position, state BlockState, open, powered bool) {
+	lower, upper := doorHalves(pos, state)
+	for _, half := range []Position{lower, upper} {
+		halfState := w.GetBlock(half.X, half.Y, half.Z)
+		if halfState.Name() == state.Name() {
+			halfState = halfState.With("open", strconv.FormatBool(open)).With("powered", strconv.FormatBool(powered))
+			w.SetBlock(half.X, half.Y, half.Z, halfState)
+		}
+	}
+}
+
+// updateDoor opens a door when any of its halves is powered and closes it when
+// the power stops.
+func updateDoor(w *World, pos Position, state BlockState) {
+	lower, upper := doorHalves(pos, state)
+	powered := w.Power(lower) > 0 || w.Power(upper) > 0
+	if powered != isPowered(state) {
+		w.setDoor(pos, state, powered, powered)
+	}
+}
+
+// tickButton releases a pressed button.
+func tickButton(w *World, pos Position, state BlockState) {
+	if isPowered(state) {
+		w.SetBlock(pos.X, pos.Y, pos.Z, state.With("powered", "false"))
+	}
+}
+
+// UseBlock makes a player interact with a block: it toggles levers and wooden doors,
+// presses buttons and changes the delay of repeaters. It returns false if the block can't be used.
+func (w *World) UseBlock(pos Position) bool {
+	state := w.GetBlock(pos.X, pos.Y, pos.Z)
+	name := state.Name()
+	switch {
+	case name == "minecraft:lever":
+		w.SetBlock(pos.X, pos.Y, pos.Z, state.With("powered", strconv.FormatBool(!isPowered(state))))
+	case isButton(name):
+		if !isPowered(state) {
+			w.SetBlock(pos.X, pos.Y, pos.Z, state.With("powered", "true"))
+			if name == "minecraft:stone_button" {
+				w.ScheduleTick(pos, buttonDelay)
+			} else {
+				w.ScheduleTick(pos, woodenButtonDelay)
+			}
+		}
+	case name == "minecraft:repeater":
+		delay := repeaterDelayTicks(state)/repeaterDelay%maxRepeaterDelay + 1
+		w.SetBlock(pos.X, pos.Y, pos.Z, state.With("delay", strconv.Itoa(delay)))
+	case strings.HasSuffix(name, "_door") && name != "minecraft:iron_door":
+		w.setDoor(pos, state, state.Property("open") != "true", isPowered(state))
+	default:
+		return false
+	}
+	return true
+}
+
+// stepOn presses the pressure plate at a position, if there's one.
+// Plates are released when no players step on them.
+func (w *World) stepOn(pos Position) {
+	state := w.GetBlock(pos.X, pos.Y, pos.Z)
+	if !strings.HasSuffix(state.Name(), "_pressure_plate") {
+		return
+	}
+
+	w.plates.mutex.Lock()
+	if w.plates.stepped == nil {
+		w.plates.stepped = make(map[Position]int64)
+	}
+	w.plates.stepped[pos] = w.Age()
+	w.plates.mutex.Unlock()
+
+	if !isPowered(state) {
+		w.SetBlock(pos.X, pos.Y, pos.Z, state.With("powered", "true"))
+		w.ScheduleTick(pos, pressurePlateDelay)
+	}
+}
+
+// tickPressurePlate releases a pressure plate when no players have stepped on it recently.
+func tickPressurePlate(w *World, pos Position, state BlockState) {
+	w.plates.mutex.Lock()
+	released := w.Age()-w.plates.stepped[pos] > pressurePlateGrace
+	if released {
+		delete(w.plates.stepped, pos)
+	}
+	w.plates.mutex.Unlock()
+
+	if !released {
+		w.ScheduleTick(pos, pressurePlateDelay)
+	} else if isPowered(state) {
+		w.SetBlock(pos.X, pos.Y, pos.Z, state.With("powered", "false"))
+	}
+}
@@ -0,0 +1,125 @@
+package MinecraftLightServer
+
+import "testing"
+
+// testBlock parses a block state, it stops the test if the state isn't valid.
+func testBlock(t *testing.T, s string) BlockState {
+	t.Helper()
+	state, err := ParseBlockState(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+// runTicks runs the scheduled block ticks of a world for a number of ticks.
+func runTicks(w *World, ticks int) {
+	for i := 0; i < ticks; i++ {
+		w.tickTime()
+		w.tickScheduled()
+	}
+}
+
+func TestRepeater(t *testing.T) {
+	w := newTestWorld(t)
+	lever, repeater, lamp := Position{0, 10, 1}, Position{0, 10, 0}, Position{0, 10, -1}
+	for z := -1; z <= 1; z++ {
+		w.SetBlock(0, 9, z, blockStone)
+	}
+	w.SetBlock(lever.X, lever.Y, lever.Z, testBlock(t, "minecraft:lever[face=floor]"))
+	w.SetBlock(repeater.X, repeater.Y, repeater.Z, testBlock(t, "minecraft:repeater[facing=south,delay=2]"))
+	w.SetBlock(lamp.X, lamp.Y, lamp.Z, testBlock(t, "minecraft:redstone_lamp"))
+
+	lampLit := func() bool {
+		return isLit(w.GetBlock(lamp.X, lamp.Y, lamp.Z))
+	}
+	w.UseBlock(lever)
+	runTicks(w, 3)
+	if lampLit() {
+		t.Fatal("the lamp has been turned on before the delay of the repeater")
+	}
+	runTicks(w, 1)
+	if !lampLit() {
+		t.Fatal("the lamp hasn't been turned on by the repeater")
+	}
+
+	w.UseBlock(lever)
+	runTicks(w, 4+lampOffDelay)
+	if lampLit() {
+		t.Fatal("the lamp hasn't been turned off")
+	}
+
+	// A powered repeater on the side locks the repeater
+	w.SetBlock(1, 9, 0, blockStone)
+	w.SetBlock(2, 9, 0, blockStone)
+	w.SetBlock(2, 10, 0, testBlock(t, "minecraft:lever[face=floor]"))
+	w.SetBlock(1, 10, 0, testBlock(t, "minecraft:repeater[facing=east]"))
+	w.UseBlock(Position{2, 10, 0})
+	runTicks(w, 2)
+	if w.GetBlock(repeater.X, repeater.Y, repeater.Z).Property("locked") != "true" {
+		t.Fatal("the repeater isn't locked")
+	}
+	w.UseBlock(lever)
+	runTicks(w, 10)
+	if lampLit() {
+		t.Error("a locked repeater has changed")
+	}
+
+	if !w.UseBlock(repeater) || w.GetBlock(repeater.X, repeater.Y, repeater.Z).Property("delay") != "3" {
+		t.Error("the delay of the repeater hasn't been changed")
+	}
+}
+
+func TestButtons(t *testing.T) {
+	tests := []struct {
+		name  string
+		delay int
+	}{
+		{"minecraft:stone_button", buttonDelay},
+		{"minecraft:oak_button", woodenButtonDelay},
+		{"minecraft:dark_oak_button", woodenButtonDelay},
+		{"minecraft:warped_button", woodenButtonDelay},
+	}
+	for _, test := range tests {
+		w := newTestWorld(t)
+		button, lamp := Position{0, 10, 0}, Position{1, 10, 0}
+		w.SetBlock(0, 9, 0, blockStone)
+		w.SetBlock(button.X, button.Y, button.Z, testBlock(t, test.name+"[face=floor]"))
+		w.SetBlock(lamp.X, lamp.Y, lamp.Z, testBlock(t, "minecraft:redstone_lamp"))
+
+		if !w.UseBlock(button) || !isLit(w.GetBlock(lamp.X, lamp.Y, lamp.Z)) {
+			t.Errorf("%s hasn't powered the lamp", test.name)
+			continue
+		}
+		runTicks(w, test.delay-1)
+		if !isPowered(w.GetBlock(button.X, button.Y, button.Z)) {
+			t.Errorf("%s has been released before %d ticks", test.name, test.delay)
+		}
+		runTicks(w, 1)
+		if isPowered(w.GetBlock(button.X, button.Y, button.Z)) {
+			t.Errorf("%s hasn't been released after %d ticks", test.name, test.delay)
+		}
+	}
+}
+
+func TestDoors(t *testing.T) {
+	w := newTestWorld(t)
+	for i, wood := range append(woodValues, "crimson", "warped", "iron") {
+		lower, upper := Position{i * 3, 10, 0}, Position{i * 3, 11, 0}
+		name := "minecraft:" + wood + "_door"
+		w.SetBlock(lower.X, lower.Y-1, lower.Z, blockStone)
+		w.SetBlock(lower.X, lower.Y, lower.Z, testBlock(t, name))
+		w.SetBlock(upper.X, upper.Y, upper.Z, testBlock(t, name+"[half=upper]"))
+
+		used := w.UseBlock(upper)
+		if open := w.GetBlock(lower.X, lower.Y, lower.Z).Property("open") == "true"; used != (wood != "iron") || open != used {
+			t.Errorf("%s: used = %v, open = %v", name, used, open)
+		}
+
+		w.SetBlock(lower.X+1, lower.Y-1, lower.Z, blockStone)
+		w.SetBlock(lower.X+1, lower.Y, lower.Z, testBlock(t, "minecraft:lever[face=floor,powered=true]"))
+		if !isPowered(w.GetBlock(upper.X, upper.Y, upper.Z)) {
+			t.Errorf("%s hasn't been powered by a lever", name)
+		}
+	}
+}
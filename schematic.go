@@ -0,0 +1,557 @@
+package MinecraftLightServer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schematic file settings.
+const (
+	schematicVersion     = 2       // Sponge schematic version written by Save
+	schematicDataVersion = 2586    // Minecraft 1.16.5 data version
+	maxSchematicVolume   = 1 << 20 // blocks of the biggest schematic, like 256x16x256
+)
+
+// Schematic is a cuboid of blocks that can be pasted in a world,
+// loaded from Sponge (.schem) or MCEdit (.schematic) files.
+type Schematic struct {
+	Width, Height, Length int                  // size on the x, y and z axes
+	Offset                Position             // position of the schematic relative to its origin
+	Unknown               int                  // blocks not found in the registry, replaced by air
+	blocks                []BlockState         // blocks in y, z, x order
+	entities              map[int]*BlockEntity // block entities by block index
+}
+
+// NewSchematic creates an empty schematic of the specified size.
+func NewSchematic(width, height, length int) (*Schematic, error) {
+	if !validSchematicSize(width, height, length) {
+		return nil, errors.New("invalid schematic size")
+	}
+	return &Schematic{
+		Width:    width,
+		Height:   height,
+		Length:   length,
+		blocks:   make([]BlockState, width*height*length),
+		entities: make(map[int]*BlockEntity),
+	}, nil
+}
+
+// validSchematicSize checks if a schematic of the specified size isn't empty nor too big.
+func validSchematicSize(width, height, length int) bool {
+	for _, side := range []int{width, height, length} {
+		if side <= 0 || side > maxSchematicVolume {
+			return false
+		}
+	}
+	return width*height <= maxSchematicVolume && width*height*length <= maxSchematicVolume
+}
+
+// CopySchematic creates a schematic with the blocks of a world between
+// two opposite corners of a cuboid (both included). The chunks of the
+// region must be already loaded, they aren't generated.
+func CopySchematic(w *World, from, to Position) (*Schematic, error) {
+	minX, maxX := sortPair(from.X, to.X)
+	minY, maxY := sortPair(from.Y, to.Y)
+	minZ, maxZ := sortPair(from.Z, to.Z)
+	if minY < 0 || maxY >= chunkHeight {
+		return nil, errors.New("region out of the world")
+	}
+	if !validSchematicSize(maxX-minX+1, maxY-minY+1, maxZ-minZ+1) {
+		return nil, errors.New("region too big, the limit is " + strconv.Itoa(maxSchematicVolume) + " blocks")
+	}
+	for x := minX >> 4; x <= maxX>>4; x++ {
+		for z := minZ >> 4; z <= maxZ>>4; z++ {
+			if w.loadedChunk(x, z) == nil {
+				return nil, errors.New("region not loaded")
+			}
+		}
+	}
+
+	s, err := NewSchematic(maxX-minX+1, maxY-minY+1, maxZ-minZ+1)
+	if err != nil {
+		return nil, err
+	}
+	for y := 0; y < s.Height; y++ {
+		for z := 0; z < s.Length; z++ {
+			for x := 0; x < s.Width; x++ {
+				s.SetBlock(x, y, z, w.GetBlock(minX+x, minY+y, minZ+z))
+				if e := w.BlockEntity(minX+x, minY+y, minZ+z); e != nil {
+					s.SetBlockEntity(x, y, z, e.Clone())
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+// index returns the index of a block of the schematic, -1 if it's outside.
+func (s *Schematic) index(x, y, z int) int {
+	if x < 0 || y < 0 || z < 0 || x >= s.Width || y >= s.Height || z >= s.Length {
+		return -1
+	}
+	return (y*s.Length+z)*s.Width + x
+}
+
+// Block returns the block state at coordinates relative to the schematic.
+func (s *Schematic) Block(x, y, z int) BlockState {
+	if i := s.index(x, y, z); i != -1 {
+		return s.blocks[i]
+	}
+	return blockAir
+}
+
+// SetBlock changes the block state at coordinates relative to the schematic.
+func (s *Schematic) SetBlock(x, y, z int, state BlockState) {
+	if i := s.index(x, y, z); i != -1 {
+		s.blocks[i] = state
+	}
+}
+
+// BlockEntity returns the block entity at coordinates relative to the schematic, nil if there isn't one.
+func (s *Schematic) BlockEntity(x, y, z int) *BlockEntity {
+	return s.entities[s.index(x, y, z)]
+}
+
+// SetBlockEntity changes the block entity at coordinates relative to the schematic, nil removes it.
+func (s *Schematic) SetBlockEntity(x, y, z int, e *BlockEntity) {
+	if i := s.index(x, y, z); i == -1 {
+		return
+	} else if e == nil {
+		delete(s.entities, i)
+	} else {
+		s.entities[i] = e
+	}
+}
+
+// Paste copies the schematic in a world with its minimum corner at the specified position.
+func (s *Schematic) Paste(w *World, pos Position) {
+	for y := 0; y < s.Height; y++ {
+		for z := 0; z < s.Length; z++ {
+			for x := 0; x < s.Width; x++ {
+				w.SetBlock(pos.X+x, pos.Y+y, pos.Z+z, s.Block(x, y, z))
+			}
+		}
+	}
+
+	// Block entities can be added only after their blocks
+	for i, e := range s.entities {
+		x, y, z := i%s.Width, i/(s.Width*s.Length), i/s.Width%s.Length
+		w.SetBlockEntity(pos.X+x, pos.Y+y, pos.Z+z, e.Clone())
+	}
+}
+
+// LoadSchematic reads a Sponge (version 1, 2 or 3) or MCEdit schematic,
+// compressed with gzip or not.
+func LoadSchematic(r io.Reader) (*Schematic, error) {
+	// Files are usually compressed, check the gzip header
+	buffered := bufio.NewReader(r)
+	if header, err := buffered.Peek(2); err == nil && header[0] == 0x1f && header[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = buffered
+	}
+
+	root, err := ReadNBT(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Version 3 wraps everything in a Schematic compound
+	if wrapped, ok := root["Schematic"].(NBTCompound); ok {
+		root = wrapped
+	}
+
+	switch {
+	case root["Version"] != nil:
+		return loadSpongeSchematic(root)
+	case root["Blocks"] != nil:
+		return loadLegacySchematic(root)
+	}
+	return nil, errors.New("unknown schematic format")
+}
+
+// LoadSchematicFile reads a schematic from a file.
+func LoadSchematicFile(path string) (*Schematic, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadSchematic(file)
+}
+
+// newSchematicFromNBT creates an empty schematic using the size and the offset of a root tag.
+func newSchematicFromNBT(root NBTCompound) (*Schematic, error) {
+	width, _ := nbtNumber(root["Width"])
+	height, _ := nbtNumber(root["Height"])
+	length, _ := nbtNumber(root["Length"])
+	s, err := NewSchematic(width&0xFFFF, height&0xFFFF, length&0xFFFF)
+	if err != nil {
+		return nil, err
+	}
+	if offset, ok := root["Offset"].([]int32); ok && len(offset) == 3 {
+		s.Offset = Position{int(offset[0]), int(offset[1]), int(offset[2])}
+	}
+	return s, nil
+}
+
+// loadSpongeSchematic reads the content of a Sponge schematic.
+func loadSpongeSchematic(root NBTCompound) (*Schematic, error) {
+	s, err := newSchematicFromNBT(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// Version 3 moved blocks inside a Blocks compound
+	blocks := root
+	paletteName, dataName, entitiesName := "Palette", "BlockData", "BlockEntities"
+	if version, _ := nbtNumber(root["Version"]); version >= 3 {
+		blocks, _ = root["Blocks"].(NBTCompound)
+		dataName = "Data"
+	} else if version == 1 {
+		entitiesName = "TileEntities"
+	}
+
+	// Map the palette indexes to block states
+	palette := make(map[int]BlockState)
+	names, _ := blocks[paletteName].(NBTCompound)
+	unknown := make(map[int]bool)
+	for name, value := range names {
+		index, _ := nbtNumber(value)
+		if state, err := ParseBlockState(name); err == nil {
+			palette[index] = state
+		} else {
+			unknown[index] = true
+		}
+	}
+
+	// Block data is a sequence of VarInts
+	data, _ := blocks[dataName].([]byte)
+	reader := bytes.NewReader(data)
+	for i := range s.blocks {
+		var index VarInt
+		if _, err := index.ReadFrom(reader); err != nil {
+			return nil, errors.New("schematic block data too short")
+		}
+		if unknown[int(index)] {
+			s.Unknown++
+		}
+		s.blocks[i] = palette[int(index)]
+	}
+
+	entities, _ := blocks[entitiesName].(NBTList)
+	for _, tag := range entities {
+		tag, ok := tag.(NBTCompound)
+		if !ok {
+			continue
+		}
+		pos, _ := tag["Pos"].([]int32)
+		id, _ := tag["Id"].(string)
+		if len(pos) != 3 || id == "" {
+			continue
+		}
+
+		// Version 3 stores the tags in a Data compound
+		e := NewBlockEntity(id)
+		data, ok := tag["Data"].(NBTCompound)
+		if !ok {
+			data = tag
+		}
+		for name, value := range data {
+			switch name {
+			case "Pos", "Id", "id", "x", "y", "z":
+			default:
+				e.Data[name] = value
+			}
+		}
+		s.SetBlockEntity(int(pos[0]), int(pos[1]), int(pos[2]), e)
+	}
+	return s, nil
+}
+
+// loadLegacySchematic reads the content of an MCEdit schematic, which uses
+// the numeric block IDs of Minecraft 1.12 and older.
+func loadLegacySchematic(root NBTCompound) (*Schematic, error) {
+	s, err := newSchematicFromNBT(root)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, _ := root["Blocks"].([]byte)
+	meta, _ := root["Data"].([]byte)
+	add, _ := root["AddBlocks"].([]byte)
+	if len(ids) < len(s.blocks) || len(meta) < len(s.blocks) {
+		return nil, errors.New("schematic block data too short")
+	}
+
+	for i := range s.blocks {
+		id := int(ids[i])
+		// AddBlocks contains the 4 high bits of the IDs, two blocks per byte
+		if i>>1 < len(add) {
+			if i&1 == 0 {
+				id |= int(add[i>>1]&0xF) << 8
+			} else {
+				id |= int(add[i>>1]>>4) << 8
+			}
+		}
+
+		state, err := ParseBlockState(legacyBlock(id, int(meta[i]&0xF)))
+		if err != nil {
+			s.Unknown++
+		}
+		s.blocks[i] = state
+	}
+
+	entities, _ := root["TileEntities"].(NBTList)
+	for _, tag := range entities {
+		tag, ok := tag.(NBTCompound)
+		if !ok {
+			continue
+		}
+		x, okX := nbtNumber(tag["x"])
+		y, okY := nbtNumber(tag["y"])
+		z, okZ := nbtNumber(tag["z"])
+		id, _ := tag["id"].(string)
+		if !okX || !okY || !okZ || id == "" {
+			continue
+		}
+
+		e := NewBlockEntity(legacyBlockEntity(id))
+		for name, value := range tag {
+			switch name {
+			case "id", "x", "y", "z":
+			default:
+				e.Data[name] = value
+			}
+		}
+		s.SetBlockEntity(x, y, z, e)
+	}
+	return s, nil
+}
+
+// Save writes the schematic in the Sponge format (version 2) compressed with gzip.
+func (s *Schematic) Save(w io.Writer) error {
+	// Palette of the states used by the schematic
+	indexes := make(map[BlockState]int)
+	palette := make(NBTCompound)
+	data := new(bytes.Buffer)
+	for _, state := range s.blocks {
+		index, ok := indexes[state]
+		if !ok {
+			index = len(indexes)
+			indexes[state] = index
+			palette[state.String()] = int32(index)
+		}
+		_, _ = VarInt(index).WriteTo(data)
+	}
+
+	// Block entities sorted by position
+	positions := make([]int, 0, len(s.entities))
+	for i := range s.entities {
+		positions = append(positions, i)
+	}
+	sort.Ints(positions)
+	entities := make(NBTList, 0, len(positions))
+	for _, i := range positions {
+		e := s.entities[i]
+		tag := make(NBTCompound, len(e.Data)+2)
+		for name, value := range e.Data {
+			tag[name] = value
+		}
+		tag["Id"] = e.ID
+		tag["Pos"] = []int32{int32(i % s.Width), int32(i / (s.Width * s.Length)), int32(i / s.Width % s.Length)}
+		entities = append(entities, tag)
+	}
+
+	root := NBTCompound{
+		"Version":       int32(schematicVersion),
+		"DataVersion":   int32(schematicDataVersion),
+		"Width":         int16(s.Width),
+		"Height":        int16(s.Height),
+		"Length":        int16(s.Length),
+		"Offset":        []int32{int32(s.Offset.X), int32(s.Offset.Y), int32(s.Offset.Z)},
+		"PaletteMax":    int32(len(palette)),
+		"Palette":       palette,
+		"BlockData":     data.Bytes(),
+		"BlockEntities": entities,
+	}
+
+	gz := gzip.NewWriter(w)
+	if _, err := root.writeNamed(gz, "Schematic"); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// SaveFile writes the schematic to a file in the Sponge format.
+func (s *Schematic) SaveFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := s.Save(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// nbtNumber converts an NBT integer tag to an int.
+func nbtNumber(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case int8:
+		return int(v), true
+	case int16:
+		return int(v), true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// sortPair returns two numbers in ascending order.
+func sortPair(a, b int) (int, int) {
+	if a > b {
+		return b, a
+	}
+	return a, b
+}
+
+// legacyFacing are the directions of the blocks of old versions, by metadata value.
+var legacyFacing = map[int]string{2: "north", 3: "south", 4: "west", 5: "east"}
+
+// legacyBlocks are the names of the blocks of old versions without variants, by numeric ID.
+var legacyBlocks = map[int]string{
+	0: "air", 2: "grass_block", 4: "cobblestone", 7: "bedrock", 13: "gravel",
+	14: "gold_ore", 15: "iron_ore", 16: "coal_ore", 19: "sponge", 20: "glass",
+	21: "lapis_ore", 22: "lapis_block", 30: "cobweb", 32: "dead_bush", 37: "dandelion",
+	39: "brown_mushroom", 40: "red_mushroom", 41: "gold_block", 42: "iron_block", 45: "bricks",
+	46: "tnt", 47: "bookshelf", 48: "mossy_cobblestone", 49: "obsidian", 52: "spawner",
+	55: "redstone_wire", 56: "diamond_ore", 57: "diamond_block", 58: "crafting_table", 60: "farmland",
+	64: "oak_door", 66: "rail", 69: "lever", 70: "stone_pressure_plate", 71: "iron_door",
+	72: "oak_pressure_plate", 73: "redstone_ore", 74: "redstone_ore[lit=true]", 77: "stone_button", 79: "ice",
+	80: "snow_block", 81: "cactus", 82: "clay", 83: "sugar_cane", 84: "jukebox",
+	85: "oak_fence", 86: "carved_pumpkin", 87: "netherrack", 88: "soul_sand", 89: "glowstone",
+	90: "nether_portal", 91: "jack_o_lantern", 92: "cake", 93: "repeater", 94: "repeater[powered=true]",
+	110: "mycelium", 123: "redstone_lamp", 124: "redstone_lamp[lit=true]", 143: "oak_button",
+}
+
+// legacyBlock returns the block state string of a block of old versions
+// using its numeric ID and its metadata.
+func legacyBlock(id, meta int) string {
+	stones := []string{"stone", "granite", "polished_granite", "diorite", "polished_diorite", "andesite", "polished_andesite"}
+	flowers := []string{"poppy", "blue_orchid", "allium", "azure_bluet", "red_tulip", "orange_tulip", "white_tulip", "pink_tulip", "oxeye_daisy"}
+	stairsFacing := []string{"east", "west", "south", "north"}
+
+	switch id {
+	case 1:
+		if meta < len(stones) {
+			return stones[meta]
+		}
+	case 3:
+		return []string{"dirt", "coarse_dirt", "podzol", "dirt"}[meta&3]
+	case 5:
+		if meta < len(woodValues) {
+			return woodValues[meta] + "_planks"
+		}
+	case 6:
+		if meta&7 < len(woodValues) {
+			return woodValues[meta&7] + "_sapling"
+		}
+	case 8, 9:
+		return "water[level=" + strconv.Itoa(meta) + "]"
+	case 10, 11:
+		return "lava[level=" + strconv.Itoa(meta) + "]"
+	case 12:
+		return []string{"sand", "red_sand"}[meta&1]
+	case 17, 162:
+		wood := woodValues[meta&3]
+		if id == 162 {
+			wood = woodValues[4+meta&1]
+		}
+		return wood + "_log[axis=" + []string{"y", "x", "z", "y"}[meta>>2] + "]"
+	case 18, 161:
+		wood := woodValues[meta&3]
+		if id == 161 {
+			wood = woodValues[4+meta&1]
+		}
+		return wood + "_leaves[persistent=" + strconv.FormatBool(meta&4 != 0) + "]"
+	case 24:
+		return []string{"sandstone", "chiseled_sandstone", "cut_sandstone", "sandstone"}[meta&3]
+	case 31:
+		return []string{"dead_bush", "grass", "fern", "grass"}[meta&3]
+	case 35:
+		return colorValues[meta] + "_wool"
+	case 38:
+		if meta < len(flowers) {
+			return flowers[meta]
+		}
+	case 50, 75, 76:
+		name := map[int]string{50: "torch", 75: "redstone_torch[lit=false]", 76: "redstone_torch"}[id]
+		if meta >= 1 && meta <= 4 {
+			wall := strings.Replace(name, "torch", "wall_torch", 1)
+			facing := []string{"east", "west", "south", "north"}[meta-1]
+			if strings.HasSuffix(wall, "]") {
+				return wall[:len(wall)-1] + ",facing=" + facing + "]"
+			}
+			return wall + "[facing=" + facing + "]"
+		}
+		return name
+	case 53, 67:
+		name := map[int]string{53: "oak_stairs", 67: "cobblestone_stairs"}[id]
+		half := "bottom"
+		if meta&4 != 0 {
+			half = "top"
+		}
+		return name + "[facing=" + stairsFacing[meta&3] + ",half=" + half + "]"
+	case 54, 61, 62, 65, 68:
+		name := map[int]string{54: "chest", 61: "furnace", 62: "furnace[lit=true]", 65: "ladder", 68: "oak_wall_sign"}[id]
+		if facing, ok := legacyFacing[meta]; ok {
+			if strings.HasSuffix(name, "]") {
+				return name[:len(name)-1] + ",facing=" + facing + "]"
+			}
+			return name + "[facing=" + facing + "]"
+		}
+		return name
+	case 59:
+		return "wheat[age=" + strconv.Itoa(meta&7) + "]"
+	case 63:
+		return "oak_sign[rotation=" + strconv.Itoa(meta) + "]"
+	case 78:
+		return "snow[layers=" + strconv.Itoa(meta&7+1) + "]"
+	case 95:
+		return colorValues[meta] + "_stained_glass"
+	default:
+		if name, ok := legacyBlocks[id]; ok {
+			return name
+		}
+	}
+	return "unknown:" + strconv.Itoa(id)
+}
+
+// legacyBlockEntity converts a block entity ID of old versions to the current one.
+func legacyBlockEntity(id string) string {
+	legacy := map[string]string{
+		"Sign": "sign", "Chest": "chest", "Banner": "banner", "Skull": "skull",
+		"MobSpawner": "mob_spawner", "Furnace": "furnace", "Trap": "dispenser",
+		"RecordPlayer": "jukebox", "Music": "note_block",
+	}
+	if name, ok := legacy[id]; ok {
+		return namespaced(name)
+	}
+	return namespaced(strings.ToLower(id))
+}
@@ -0,0 +1,69 @@
+package MinecraftLightServer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestValidSchematicSize(t *testing.T) {
+	tests := []struct {
+		width, height, length int
+		want                  bool
+	}{
+		{1, 1, 1, true},
+		{256, 16, 256, true},
+		{256, 17, 256, false},
+		{0, 1, 1, false},
+		{-1, -1, 1, false},
+		{maxSchematicVolume, 1, 1, true},
+		{math.MaxInt32, math.MaxInt32, math.MaxInt32, false},
+	}
+	for _, test := range tests {
+		if got := validSchematicSize(test.width, test.height, test.length); got != test.want {
+			t.Errorf("validSchematicSize(%d, %d, %d) = %v, want %v", test.width, test.height, test.length, got, test.want)
+		}
+	}
+}
+
+func TestCopySchematicLoadedChunks(t *testing.T) {
+	w := newTestWorld(t)
+	w.Chunk(0, 0)
+
+	s, err := CopySchematic(w, Position{0, 0, 0}, Position{15, 3, 15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Block(0, 0, 0) != w.GetBlock(0, 0, 0) {
+		t.Error("the copied block is different")
+	}
+	if _, err := CopySchematic(w, Position{0, 0, 0}, Position{16, 3, 0}); err == nil {
+		t.Error("a region in a chunk that isn't loaded has been copied")
+	}
+	if w.loadedChunk(1, 0) != nil {
+		t.Error("the copy has generated a chunk")
+	}
+	if _, err := CopySchematic(w, Position{-30000000, 0, -30000000}, Position{30000000, 255, 30000000}); err == nil {
+		t.Error("a region bigger than the limit has been copied")
+	}
+}
+
+func TestLegacySchematicAddBlocks(t *testing.T) {
+	// The second block has the ID 257, which doesn't exist
+	s, err := loadLegacySchematic(NBTCompound{
+		"Width": int16(3), "Height": int16(1), "Length": int16(1),
+		"Blocks":    []byte{1, 1, 1},
+		"Data":      []byte{0, 0, 0},
+		"AddBlocks": []byte{0x10, 0x00},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x, want := range []BlockState{blockStone, blockAir, blockStone} {
+		if got := s.Block(x, 0, 0); got != want {
+			t.Errorf("the block at x %d is %v, want %v", x, got, want)
+		}
+	}
+	if s.Unknown != 1 {
+		t.Errorf("%d unknown blocks, want 1", s.Unknown)
+	}
+}
@@ -9,7 +9,10 @@ import (
 	"time"
 )
 
-const serverPort = "25565" // default listen port
+const (
+	serverPort = "25565"          // default listen port
+	tickRate   = time.Second / 20 // duration of a server tick
+)
 
 // Server is a running Minecraft server.
 type Server struct {
@@ -21,6 +24,26 @@ type Server struct {
 	players    sync.Map   // map of players online
 	counter    int        // number of players online
 	counterMut sync.Mutex // mutex for players counter
+	worlds     struct {   // worlds hosted by the server
+		byName map[string]*World // worlds by namespaced name
+		order  []*World          // worlds in insertion order, the first is the default one
+		mutex  sync.RWMutex      // worlds mutex
+	}
+	commands struct { // commands sent by players in chat
+		handlers map[string]*command // commands by name
+		mutex    sync.RWMutex        // commands mutex
+	}
+	operators struct { // players that can use the operator commands
+		names map[string]bool // usernames of the operators
+		mutex sync.RWMutex    // operators mutex
+	}
+	tick struct { // game loop handling
+		stop chan struct{} // close to stop the tick loop
+	}
+	settings struct { // gameplay settings
+		viewDistance int          // view distance in chunks
+		mutex        sync.RWMutex // mutex for settings
+	}
 }
 
 // NewServer creates a new Server using default port.
@@ -37,12 +60,68 @@ func NewServer(portNumber ...string) *Server {
 
 	s.listener.portValue = make(chan string)
 	s.listener.err = make(chan error)
+	s.tick.stop = make(chan struct{})
+	s.settings.viewDistance = defaultViewDistance
+	s.registerBuiltinCommands()
+
+	// Default world is the vanilla classic superflat
+	generator, _ := NewFlatGenerator(defaultFlatLayers)
+	s.worlds.byName = make(map[string]*World)
+	_ = s.AddWorld("minecraft:overworld", NewWorld(generator))
 	return s
 }
 
+// World returns the default world of the server, where the players spawn.
+func (s *Server) World() *World {
+	s.worlds.mutex.RLock()
+	defer s.worlds.mutex.RUnlock()
+	return s.worlds.order[0]
+}
+
+// GetWorld returns the world with the specified name, nil if it doesn't exist.
+func (s *Server) GetWorld(name string) *World {
+	s.worlds.mutex.RLock()
+	defer s.worlds.mutex.RUnlock()
+	return s.worlds.byName[namespaced(name)]
+}
+
+// Worlds returns all the worlds of the server.
+func (s *Server) Worlds() []*World {
+	s.worlds.mutex.RLock()
+	defer s.worlds.mutex.RUnlock()
+	return append([]*World(nil), s.worlds.order...)
+}
+
+// AddWorld adds a world to the server with the specified name.
+// Players that are already connected can switch to the new world,
+// but the client only suggests the names received when it joined.
+func (s *Server) AddWorld(name string, w *World) error {
+	name = namespaced(name)
+
+	s.worlds.mutex.Lock()
+	defer s.worlds.mutex.Unlock()
+	if _, ok := s.worlds.byName[name]; ok {
+		return errors.New("world already exists: " + name)
+	}
+	for _, world := range s.worlds.order {
+		if world == w {
+			return errors.New("world already added as " + world.name)
+		}
+	}
+
+	w.name = name
+	w.mutex.Lock()
+	w.server = s
+	w.mutex.Unlock()
+	s.worlds.byName[name] = w
+	s.worlds.order = append(s.worlds.order, w)
+	return nil
+}
+
 // Start starts the server using the current port.
 func (s *Server) Start() error {
 	go s.listen(s.listener.portValue, s.listener.err)
+	go s.tickLoop()
 	s.listener.portValue <- s.listener.port
 	return <-s.listener.err
 }
@@ -59,14 +138,139 @@ func (s *Server) Close() error {
 	// Close port changer channel
 	close(s.listener.portValue)
 
+	// Stop game loop
+	close(s.tick.stop)
+
 	// Remove each of the connected clients
 	s.players.Range(func(key interface{}, value interface{}) bool {
-		s.removePlayerAndExit(value.(*Player), errors.New("server closed"))
+		s.removePlayer(value.(*Player), errors.New("server closed"))
 		return true
 	})
+
+	// Save the worlds created with a directory
+	for _, w := range s.Worlds() {
+		if err := w.Save(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// ViewDistance returns the view distance of the players in chunks.
+func (s *Server) ViewDistance() int {
+	s.settings.mutex.RLock()
+	defer s.settings.mutex.RUnlock()
+	return s.settings.viewDistance
+}
+
+// SetViewDistance changes the view distance of the players,
+// sending to them the chunks in the new range.
+func (s *Server) SetViewDistance(chunks int) {
+	s.settings.mutex.Lock()
+	s.settings.viewDistance = chunks
+	s.settings.mutex.Unlock()
+
+	s.players.Range(func(key interface{}, value interface{}) bool {
+		player := value.(*Player)
+		if err := player.writeUpdateViewDistance(chunks); err != nil {
+			s.removePlayer(player, err)
+		} else if err := player.updateChunks(chunks); err != nil {
+			s.removePlayer(player, err)
+		}
+		return true
+	})
+}
+
+// tickLoop runs the game loop, doing a server tick every tickRate
+// until the server is closed.
+// This function must be started within a new goroutine.
+func (s *Server) tickLoop() {
+	ticker := time.NewTicker(tickRate)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.tick.stop:
+			return
+		case <-ticker.C:
+			s.doTick()
+		}
+	}
+}
+
+// doTick updates the game state once.
+func (s *Server) doTick() {
+	// Send world changes to the players that have loaded the chunks
+	for _, world := range s.Worlds() {
+		world.border.tick()
+		world.tickTime()
+		world.tickWeather()
+		world.tickScheduled()
+		world.tickRandomBlocks(s.loadedChunks(world))
+		world.tickFallingBlocks()
+		world.tickPrimedTNT()
+		world.tickDroppedItems(s.worldPlayers(world))
+		world.tickFurnaces()
+		changes := world.flushChanges()
+		if changes.isEmpty() {
+			continue
+		}
+		s.players.Range(func(key interface{}, value interface{}) bool {
+			player := value.(*Player)
+			if err := player.writeWorldChanges(world, changes); err != nil {
+				s.removePlayer(player, err)
+			}
+			return true
+		})
+	}
+
+	// Stream chunks to players and close the containers they can't use anymore
+	s.players.Range(func(key interface{}, value interface{}) bool {
+		player := value.(*Player)
+		if err := player.sendQueuedChunks(maxChunksPerTick); err != nil {
+			s.removePlayer(player, err)
+		}
+		if err := player.checkWindow(); err != nil {
+			s.removePlayer(player, err)
+		}
+		if err := player.tickHealth(s); err != nil {
+			s.removePlayer(player, err)
+		}
+		player.tickDropSpam()
+		return true
+	})
+}
+
+// loadedChunks returns the chunks of a world loaded by at least a player.
+func (s *Server) loadedChunks(w *World) map[chunkPos]bool {
+	loaded := make(map[chunkPos]bool)
+	s.players.Range(func(key interface{}, value interface{}) bool {
+		player := value.(*Player)
+		if player.World() != w {
+			return true
+		}
+		player.chunks.mutex.Lock()
+		for pos := range player.chunks.loaded {
+			loaded[pos] = true
+		}
+		player.chunks.mutex.Unlock()
+		return true
+	})
+	return loaded
+}
+
+// worldPlayers returns the players in a world.
+func (s *Server) worldPlayers(w *World) []*Player {
+	var players []*Player
+	s.players.Range(func(key interface{}, value interface{}) bool {
+		if player := value.(*Player); player.World() == w {
+			players = append(players, player)
+		}
+		return true
+	})
+	return players
+}
+
 // keepAliveUser sends keepalive packet to current player.
 // This function must be started within a new goroutine.
 func (s *Server) keepAliveUser(p *Player) {
@@ -113,6 +317,9 @@ func (s *Server) addPlayer(p *Player) {
 func (s *Server) removePlayer(p *Player, err error) {
 	p.isDeleted = true
 	_ = p.connection.Close()
+	if window, _ := p.OpenContainer(); window != playerWindowID {
+		p.dropItems(p.closeWindow(window))
+	}
 
 	// Remove player from players map
 	if _, ok := s.players.LoadAndDelete(p.username); ok {
@@ -165,12 +372,12 @@ func (s *Server) broadcastPlayerInfo() {
 		s.players.Range(func(key interface{}, value interface{}) bool {
 			currentPlayer := value.(*Player)
 
-			_, _ = currentPlayer.id.WriteTo(broadcast)       // player uuid
-			_, _ = currentPlayer.username.WriteTo(broadcast) // username
-			_, _ = VarInt(0).WriteTo(broadcast)                // no properties
-			_, _ = VarInt(0).WriteTo(broadcast)                // gamemode 0 (survival)
-			_, _ = VarInt(123).WriteTo(broadcast)              // hardcoded ping
-			_, _ = Boolean(false).WriteTo(broadcast)           // has display name
+			_, _ = currentPlayer.id.WriteTo(broadcast)                 // player uuid
+			_, _ = currentPlayer.username.WriteTo(broadcast)           // username
+			_, _ = VarInt(0).WriteTo(broadcast)                        // no properties
+			_, _ = VarInt(currentPlayer.GameMode()).WriteTo(broadcast) // gamemode
+			_, _ = VarInt(123).WriteTo(broadcast)                      // hardcoded ping
+			_, _ = Boolean(false).WriteTo(broadcast)                   // has display name
 			return true
 		})
 
@@ -194,14 +401,24 @@ func (s *Server) broadcastChatMessage(msg, username string) {
 	fmt.Println("Broadcast chat message: <" + username + "> " + msg)
 }
 
-// broadcastSpawnPlayer sends the position of all other players to every client.
+// broadcastSystemMessage sends a message of the server to all connected players.
+func (s *Server) broadcastSystemMessage(msg string) {
+	s.players.Range(func(key interface{}, value interface{}) bool {
+		_ = value.(*Player).writeSystemMessage(msg, "")
+		return true
+	})
+
+	fmt.Println("Broadcast system message: " + msg)
+}
+
+// broadcastSpawnPlayer sends the position of all other players in the same world to every client.
 func (s *Server) broadcastSpawnPlayer() {
 	s.players.Range(func(key interface{}, playerInterface interface{}) bool {
 		currentPlayer := playerInterface.(*Player)
 		s.players.Range(func(key interface{}, p interface{}) bool {
-			// Get all other players
+			// Get all other players of the same world
 			otherPlayer := p.(*Player)
-			if currentPlayer.id != otherPlayer.id {
+			if currentPlayer.id != otherPlayer.id && currentPlayer.World() == otherPlayer.World() {
 				_ = currentPlayer.writeSpawnPlayer(
 					VarInt(otherPlayer.int32FromUUID()),
 					otherPlayer.id,
@@ -224,11 +441,12 @@ func (s *Server) broadcastSpawnPlayer() {
 
 // broadcastPlayerPosAndLook sends to all other clients the position and the view of a player.
 func (s *Server) broadcastPlayerPosAndLook(id VarInt, x, y, z Double, yaw, pitch Angle, onGround Boolean) {
+	world := s.entityWorld(id)
 	s.players.Range(func(key interface{}, playerInterface interface{}) bool {
 		player := playerInterface.(*Player)
 
-		// Don't send to current player
-		if VarInt(player.int32FromUUID()) != id {
+		// Don't send to current player and to the other worlds
+		if VarInt(player.int32FromUUID()) != id && player.World() == world {
 			_ = player.writeEntityTeleport(x, y, z, yaw, pitch, onGround, id)
 			_ = player.writeEntityLook(id, yaw)
 		}
@@ -239,11 +457,12 @@ func (s *Server) broadcastPlayerPosAndLook(id VarInt, x, y, z Double, yaw, pitch
 
 // broadcastPlayerPosAndLook sends to all other clients the rotation and the look of a player.
 func (s *Server) broadcastPlayerRotation(id VarInt, yaw, pitch Angle, onGround Boolean) {
+	world := s.entityWorld(id)
 	s.players.Range(func(key interface{}, playerInterface interface{}) bool {
 		player := playerInterface.(*Player)
 
-		// Don't send to current player
-		if VarInt(player.int32FromUUID()) != id {
+		// Don't send to current player and to the other worlds
+		if VarInt(player.int32FromUUID()) != id && player.World() == world {
 			_ = player.writeEntityRotation(id, yaw, pitch, onGround)
 			_ = player.writeEntityLook(id, yaw)
 		}
@@ -254,11 +473,12 @@ func (s *Server) broadcastPlayerRotation(id VarInt, yaw, pitch Angle, onGround B
 
 // broadcastEntityAction sends to all other clients an action of a player.
 func (s *Server) broadcastEntityAction(id VarInt, action VarInt) {
+	world := s.entityWorld(id)
 	s.players.Range(func(key interface{}, playerInterface interface{}) bool {
 		player := playerInterface.(*Player)
 
-		// Don't send to current player
-		if VarInt(player.int32FromUUID()) != id {
+		// Don't send to current player and to the other worlds
+		if VarInt(player.int32FromUUID()) != id && player.World() == world {
 			_ = player.writeEntityAction(id, action)
 		}
 
@@ -266,13 +486,69 @@ func (s *Server) broadcastEntityAction(id VarInt, action VarInt) {
 	})
 }
 
+// broadcastEntityStatus sends to all other clients an Entity Status of a player.
+func (s *Server) broadcastEntityStatus(p *Player, status int) {
+	s.players.Range(func(key interface{}, playerInterface interface{}) bool {
+		player := playerInterface.(*Player)
+
+		// Don't send to current player and to the other worlds
+		if player != p && player.World() == p.World() {
+			_ = NewPacket(entityStatusPacketID, Int(p.int32FromUUID()), Byte(status)).Pack(player.connection)
+		}
+
+		return true
+	})
+}
+
+// broadcastRespawn spawns again a player that has respawned for all other clients.
+func (s *Server) broadcastRespawn(p *Player) {
+	s.players.Range(func(key interface{}, playerInterface interface{}) bool {
+		player := playerInterface.(*Player)
+
+		// Don't send to current player, the players of the other worlds
+		// only remove it because it could have respawned in another world
+		if player != p {
+			id := VarInt(p.int32FromUUID())
+			_ = NewPacket(destroyEntityPacketID, VarInt(1), id).Pack(player.connection)
+			if player.World() == p.World() {
+				_ = player.writeSpawnPlayer(id, p.id, p.x, p.y, p.z, p.yaw, p.pitch)
+				_ = player.writeEntityLook(id, p.yaw)
+			}
+		}
+
+		return true
+	})
+}
+
+// entityWorld returns the world of the player with an entity ID, nil if there isn't one.
+func (s *Server) entityWorld(id VarInt) *World {
+	if player := s.playerByEntityID(int32(id)); player != nil {
+		return player.World()
+	}
+	return nil
+}
+
+// playerByEntityID returns the player with an entity ID, nil if there isn't one.
+func (s *Server) playerByEntityID(id int32) *Player {
+	var found *Player
+	s.players.Range(func(key interface{}, value interface{}) bool {
+		if player := value.(*Player); player.int32FromUUID() == id {
+			found = player
+			return false
+		}
+		return true
+	})
+	return found
+}
+
 // broadcastEntityAnimation sends to all other clients an animation of a player.
 func (s *Server) broadcastEntityAnimation(id VarInt, animation VarInt) {
+	world := s.entityWorld(id)
 	s.players.Range(func(key interface{}, playerInterface interface{}) bool {
 		player := playerInterface.(*Player)
 
-		// Don't send to current player
-		if VarInt(player.int32FromUUID()) != id {
+		// Don't send to current player and to the other worlds
+		if VarInt(player.int32FromUUID()) != id && player.World() == world {
 			_ = player.writeEntityAnimation(id, animation)
 		}
 
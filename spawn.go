@@ -0,0 +1,169 @@
+package MinecraftLightServer
+
+import (
+	"math/rand"
+	"strings"
+)
+
+// Spawn settings.
+const (
+	defaultSpawnY     = 64 // height of the default spawn point, used when there isn't a safe location
+	spawnSearchTries  = 32 // random locations tried inside the spawn radius
+	spawnPositionSize = 2  // blocks of air needed above the ground
+)
+
+// unsafeBlocks are the blocks where players can't spawn, on them or inside them.
+var unsafeBlocks = map[string]bool{
+	"minecraft:water":            true,
+	"minecraft:lava":             true,
+	"minecraft:fire":             true,
+	"minecraft:soul_fire":        true,
+	"minecraft:magma_block":      true,
+	"minecraft:cactus":           true,
+	"minecraft:campfire":         true,
+	"minecraft:sweet_berry_bush": true,
+	"minecraft:nether_portal":    true,
+	"minecraft:end_portal":       true,
+}
+
+// Spawn returns the spawn point of the world, players spawn around it.
+func (w *World) Spawn() Position {
+	w.spawn.mutex.RLock()
+	defer w.spawn.mutex.RUnlock()
+	return w.spawn.pos
+}
+
+// SetSpawn changes the spawn point of the world, compasses point to it.
+func (w *World) SetSpawn(pos Position) {
+	w.spawn.mutex.Lock()
+	w.spawn.pos = pos
+	w.spawn.mutex.Unlock()
+
+	w.changes.mutex.Lock()
+	w.changes.spawn = true
+	w.changes.mutex.Unlock()
+}
+
+// isSafeGround checks if a player can stand on a block.
+func isSafeGround(state BlockState) bool {
+	return heightmapMatches(motionBlocking, state) && !unsafeBlocks[state.Name()]
+}
+
+// isPassable checks if a player can be inside a block.
+func isPassable(state BlockState) bool {
+	return !heightmapMatches(motionBlocking, state) && !unsafeBlocks[state.Name()]
+}
+
+// IsSafe checks if a player can stand at a position: the block below is solid
+// ground and there's enough space for the player.
+func (w *World) IsSafe(pos Position) bool {
+	if pos.Y < 1 || pos.Y+spawnPositionSize > w.dimension.LogicalHeight {
+		return false
+	}
+	if !isSafeGround(w.GetBlock(pos.X, pos.Y-1, pos.Z)) {
+		return false
+	}
+	for y := pos.Y; y < pos.Y+spawnPositionSize; y++ {
+		if !isPassable(w.GetBlock(pos.X, y, pos.Z)) {
+			return false
+		}
+	}
+	return true
+}
+
+// SafeLocation returns the highest position of a column where a player can stand,
+// false if there isn't one. Dimensions with a ceiling are searched below their logical height.
+func (w *World) SafeLocation(x, z int) (Position, bool) {
+	top := w.Chunk(x>>4, z>>4).Height(x&15, z&15)
+	if top > w.dimension.LogicalHeight-spawnPositionSize {
+		top = w.dimension.LogicalHeight - spawnPositionSize
+	}
+
+	for y := top; y > 0; y-- {
+		if pos := (Position{x, y, z}); w.IsSafe(pos) {
+			return pos, true
+		}
+	}
+	return Position{}, false
+}
+
+// spawnLocation returns where a new player spawns: a safe location
+// inside the spawn radius, the spawn point itself if there isn't one.
+func (w *World) spawnLocation() Position {
+	spawn := w.Spawn()
+
+	// The spawn radius can't exceed the border
+	radius := w.rules.Int("spawnRadius")
+	w.border.mutex.RLock()
+	inside := int(-w.border.distanceOutside(float64(spawn.X)+0.5, float64(spawn.Z)+0.5))
+	w.border.mutex.RUnlock()
+	if radius > inside {
+		radius = inside
+	}
+	if radius > 0 {
+		for i := 0; i < spawnSearchTries; i++ {
+			x := spawn.X + rand.Intn(2*radius+1) - radius
+			z := spawn.Z + rand.Intn(2*radius+1) - radius
+			if pos, ok := w.SafeLocation(x, z); ok {
+				return pos
+			}
+		}
+	}
+	if pos, ok := w.SafeLocation(spawn.X, spawn.Z); ok {
+		return pos
+	}
+	return spawn
+}
+
+// RespawnPoint returns the bed or respawn point of the player and its world,
+// false if the player spawns at the world spawn.
+func (p *Player) RespawnPoint() (*World, Position, bool) {
+	p.respawn.mutex.Lock()
+	defer p.respawn.mutex.Unlock()
+	return p.respawn.world, p.respawn.pos, p.respawn.world != nil
+}
+
+// SetRespawnPoint changes the bed or respawn point of the player,
+// a nil world removes it.
+func (p *Player) SetRespawnPoint(w *World, pos Position) {
+	p.respawn.mutex.Lock()
+	defer p.respawn.mutex.Unlock()
+	p.respawn.world, p.respawn.pos = w, pos
+}
+
+// respawnLocation returns where the player respawns: next to its respawn point if it's
+// still safe, otherwise around the spawn point of the default world.
+func (p *Player) respawnLocation(s *Server) (*World, Position) {
+	if w, pos, ok := p.RespawnPoint(); ok {
+		if bed, ok := w.standUpPosition(pos); ok {
+			return w, bed
+		}
+		// The respawn point is obstructed
+		p.SetRespawnPoint(nil, Position{})
+	}
+	w := s.World()
+	return w, w.spawnLocation()
+}
+
+// standUpPosition returns a safe position at a respawn point or around it,
+// like the positions where a player stands up from a bed.
+func (w *World) standUpPosition(pos Position) (Position, bool) {
+	if !strings.HasSuffix(w.GetBlock(pos.X, pos.Y, pos.Z).Name(), "_bed") && w.IsSafe(pos) {
+		return pos, true
+	}
+	for _, dy := range []int{0, 1, -1} {
+		for dx := -1; dx <= 1; dx++ {
+			for dz := -1; dz <= 1; dz++ {
+				if around := (Position{pos.X + dx, pos.Y + dy, pos.Z + dz}); w.IsSafe(around) {
+					return around, true
+				}
+			}
+		}
+	}
+	return Position{}, false
+}
+
+// writeSpawnPosition sends the spawn point of a world to the client, compasses point to it.
+func (p *Player) writeSpawnPosition(w *World) error {
+	return NewPacket(spawnPositionPacketID, w.Spawn()).Pack(p.connection)
+}
@@ -0,0 +1,98 @@
+package MinecraftLightServer
+
+// Day settings in ticks.
+const (
+	dayLength        = 24000 // ticks in a Minecraft day
+	timeUpdatePeriod = 20    // ticks between two Time Update packets
+)
+
+// Named times of day used by the time command.
+var namedTimes = map[string]int64{
+	"day":      1000,
+	"noon":     6000,
+	"night":    13000,
+	"midnight": 18000,
+}
+
+// Age returns the number of ticks since the world has been created.
+func (w *World) Age() int64 {
+	w.clock.mutex.RLock()
+	defer w.clock.mutex.RUnlock()
+	return w.clock.age
+}
+
+// Time returns the time of day of the world in ticks, it increases by
+// dayLength every day.
+func (w *World) Time() int64 {
+	w.clock.mutex.RLock()
+	defer w.clock.mutex.RUnlock()
+	return w.clock.time
+}
+
+// SetTime changes the time of day of the world.
+func (w *World) SetTime(time int64) {
+	w.clock.mutex.Lock()
+	w.clock.time = time
+	w.clock.mutex.Unlock()
+	w.timeChanged()
+}
+
+// AddTime moves the time of day forward by the specified ticks.
+func (w *World) AddTime(ticks int64) {
+	w.clock.mutex.Lock()
+	w.clock.time += ticks
+	w.clock.mutex.Unlock()
+	w.timeChanged()
+}
+
+// DaylightCycle checks if the time of day advances.
+func (w *World) DaylightCycle() bool {
+	w.clock.mutex.RLock()
+	defer w.clock.mutex.RUnlock()
+	return w.clock.cycle
+}
+
+// SetDaylightCycle starts or stops the advancing of the time of day,
+// it's the doDaylightCycle game rule.
+func (w *World) SetDaylightCycle(enabled bool) {
+	_ = w.rules.SetBool("doDaylightCycle", enabled)
+}
+
+// timeChanged marks the time as changed, it's sent to the players on the next tick.
+func (w *World) timeChanged() {
+	w.changes.mutex.Lock()
+	w.changes.time = true
+	w.changes.mutex.Unlock()
+}
+
+// tickTime advances the age and the time of day of the world by a tick.
+// Time is sent to the players every timeUpdatePeriod ticks to correct their clocks.
+func (w *World) tickTime() {
+	w.clock.mutex.Lock()
+	w.clock.age++
+	if w.clock.cycle {
+		w.clock.time++
+	}
+	update := w.clock.age%timeUpdatePeriod == 0
+	w.clock.mutex.Unlock()
+
+	if update {
+		w.timeChanged()
+	}
+}
+
+// writeTimeUpdate sends the age and the time of day of a world to the client.
+func (p *Player) writeTimeUpdate(w *World) error {
+	w.clock.mutex.RLock()
+	age, time := w.clock.age, w.clock.time
+	if !w.clock.cycle {
+		// A negative time of day stops the client clock
+		time = -time
+		if time == 0 {
+			time = -1
+		}
+	}
+	w.clock.mutex.RUnlock()
+
+	return NewPacket(timeUpdatePacketID, Long(age), Long(time)).Pack(p.connection)
+}
@@ -0,0 +1,219 @@
+package MinecraftLightServer
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Primed TNT settings, the same as vanilla.
+const (
+	tntEntityType     = 64     // entity type of primed TNT
+	tntFuse           = 80     // ticks before primed TNT explodes
+	tntPower          = 4      // power of the explosion of TNT
+	tntSize           = 0.98   // width and height of primed TNT
+	tntExplosionY     = 0.0625 // height of the explosion above the bottom of the entity
+	tntJump           = 0.2    // vertical speed of TNT when it's primed
+	tntSpread         = 0.02   // horizontal speed of TNT when it's primed
+	tntGroundFriction = 0.7    // horizontal speed multiplier of TNT on the ground
+	tntBounce         = -0.5   // vertical speed multiplier of TNT that hits the ground
+	tntFuseMetadata   = 7      // index of the fuse in the entity metadata
+)
+
+// primedTNT is TNT that is going to explode.
+type primedTNT struct {
+	id         int32   // entity ID
+	uuid       UUID    // entity UUID
+	x, y, z    float64 // position of the bottom center of the entity
+	vx, vy, vz float64 // speed in blocks per tick
+	fuse       int     // ticks before the explosion
+	spawned    bool    // the TNT has just been primed
+	removed    bool    // the TNT has exploded
+}
+
+// primedTNTs are the primed TNT entities of a world.
+type primedTNTs struct {
+	entities []*primedTNT // TNT that is going to explode
+	mutex    sync.Mutex   // primed TNT mutex
+}
+
+func init() {
+	neighborUpdateHandlers["minecraft:tnt"] = updateTNT
+}
+
+// updateTNT primes TNT that receives redstone power.
+func updateTNT(w *World, pos Position, _ BlockState) {
+	if w.Power(pos) > 0 {
+		w.PrimeTNT(pos)
+	}
+}
+
+// PrimeTNT replaces the TNT block at a position with primed TNT that explodes after 4 seconds.
+// It returns false if the block isn't TNT.
+func (w *World) PrimeTNT(pos Position) bool {
+	if w.GetBlock(pos.X, pos.Y, pos.Z).Name() != "minecraft:tnt" {
+		return false
+	}
+	w.SetBlock(pos.X, pos.Y, pos.Z, blockAir)
+	w.spawnPrimedTNT(pos, tntFuse)
+	return true
+}
+
+// primeExplodedTNT primes TNT destroyed by an explosion, it has a shorter fuse.
+func (w *World) primeExplodedTNT(pos Position) {
+	w.spawnPrimedTNT(pos, rand.Intn(tntFuse/4)+tntFuse/8)
+}
+
+// spawnPrimedTNT spawns primed TNT in a block, it jumps in a random direction.
+func (w *World) spawnPrimedTNT(pos Position, fuse int) {
+	id := UUID(uuid.New())
+	angle := rand.Float64() * 2 * math.Pi
+	w.tnt.mutex.Lock()
+	defer w.tnt.mutex.Unlock()
+	w.tnt.entities = append(w.tnt.entities, &primedTNT{
+		id:      entityIDFromUUID(id),
+		uuid:    id,
+		x:       float64(pos.X) + 0.5,
+		y:       float64(pos.Y),
+		z:       float64(pos.Z) + 0.5,
+		vx:      -math.Sin(angle) * tntSpread,
+		vy:      tntJump,
+		vz:      -math.Cos(angle) * tntSpread,
+		fuse:    fuse,
+		spawned: true,
+	})
+}
+
+// tickPrimedTNT moves the primed TNT and makes it explode when its fuse ends.
+// Their new state is sent to the players on the next tick.
+func (w *World) tickPrimedTNT() {
+	w.tnt.mutex.Lock()
+	entities := w.tnt.entities
+	w.tnt.entities = nil
+	w.tnt.mutex.Unlock()
+	if len(entities) == 0 {
+		return
+	}
+
+	primed := make([]*primedTNT, 0, len(entities))
+	updates := make([]primedTNT, 0, len(entities))
+	var exploded []*primedTNT
+	for _, tnt := range entities {
+		if !tnt.spawned {
+			w.movePrimedTNT(tnt)
+			tnt.fuse--
+			if tnt.fuse <= 0 {
+				tnt.removed = true
+				exploded = append(exploded, tnt)
+			}
+		}
+		updates = append(updates, *tnt)
+		tnt.spawned = false
+		if !tnt.removed {
+			primed = append(primed, tnt)
+		}
+	}
+
+	w.tnt.mutex.Lock()
+	w.tnt.entities = append(w.tnt.entities, primed...)
+	w.tnt.mutex.Unlock()
+
+	w.changes.mutex.Lock()
+	w.changes.tnt = append(w.changes.tnt, updates...)
+	w.changes.mutex.Unlock()
+
+	for _, tnt := range exploded {
+		w.explode(tnt.x, tnt.y+tntExplosionY, tnt.z, tntPower, false, true)
+	}
+}
+
+// movePrimedTNT advances primed TNT by a tick, it stops against solid blocks.
+func (w *World) movePrimedTNT(tnt *primedTNT) {
+	tnt.vy -= fallingBlockGravity
+	solid := func(x, y, z float64) bool {
+		state := w.GetBlock(int(math.Floor(x)), int(math.Floor(y)), int(math.Floor(z)))
+		return y >= 0 && heightmapMatches(motionBlocking, state) && !isFluid(state)
+	}
+
+	// Each axis is moved separately, like vanilla collisions
+	onGround := false
+	if solid(tnt.x, tnt.y+tnt.vy, tnt.z) {
+		if tnt.vy < 0 {
+			tnt.y, onGround = math.Floor(tnt.y+tnt.vy)+1, true
+		}
+		tnt.vy = 0
+	} else {
+		tnt.y += tnt.vy
+	}
+	if solid(tnt.x+tnt.vx, tnt.y, tnt.z) {
+		tnt.vx = 0
+	} else {
+		tnt.x += tnt.vx
+	}
+	if solid(tnt.x, tnt.y, tnt.z+tnt.vz) {
+		tnt.vz = 0
+	} else {
+		tnt.z += tnt.vz
+	}
+
+	tnt.vx, tnt.vy, tnt.vz = tnt.vx*fallingBlockDrag, tnt.vy*fallingBlockDrag, tnt.vz*fallingBlockDrag
+	if onGround {
+		tnt.vx, tnt.vy, tnt.vz = tnt.vx*tntGroundFriction, tnt.vy*tntBounce, tnt.vz*tntGroundFriction
+	}
+	if tnt.y < 0 {
+		tnt.removed = true
+	}
+}
+
+// pushPrimedTNT pushes the primed TNT hit by an explosion away from it.
+func (w *World) pushPrimedTNT(e explosion) {
+	w.tnt.mutex.Lock()
+	defer w.tnt.mutex.Unlock()
+	for _, tnt := range w.tnt.entities {
+		if impact := w.impact(e, tnt.x, tnt.y, tnt.z, tntSize, tntSize); impact > 0 {
+			vx, vy, vz := knockback(e, tnt.x, tnt.y, tnt.z, impact)
+			tnt.vx, tnt.vy, tnt.vz = tnt.vx+vx, tnt.vy+vy, tnt.vz+vz
+		}
+	}
+}
+
+// writePrimedTNT sends the primed TNT that has been spawned, moved or exploded.
+func (p *Player) writePrimedTNT(entities []primedTNT) error {
+	for _, tnt := range entities {
+		if !p.hasChunk(int(math.Floor(tnt.x))>>4, int(math.Floor(tnt.z))>>4) {
+			continue
+		}
+
+		var packets []*Packet
+		switch {
+		case tnt.spawned:
+			packets = append(packets, NewPacket(spawnEntityPacketID,
+				VarInt(tnt.id), tnt.uuid, // entity id and uuid
+				VarInt(tntEntityType),                       // entity type
+				Double(tnt.x), Double(tnt.y), Double(tnt.z), // position
+				Angle(0), Angle(0), Int(0), // pitch, yaw and data
+				Short(tnt.vx*velocityUnit), Short(tnt.vy*velocityUnit), Short(tnt.vz*velocityUnit), // velocity
+			))
+			metadata := NewPacket(writeEntityMetadataPacketID, VarInt(tnt.id))
+			_, _ = UnsignedByte(tntFuseMetadata).WriteTo(metadata) // field unique id
+			_, _ = VarInt(1).WriteTo(metadata)                     // varint
+			_, _ = VarInt(tnt.fuse).WriteTo(metadata)              // fuse
+			_, _ = UnsignedByte(0xFF).WriteTo(metadata)            // Terminate entity metadata array
+			packets = append(packets, metadata)
+		case tnt.removed:
+			packets = append(packets, NewPacket(destroyEntityPacketID, VarInt(1), VarInt(tnt.id)))
+		default:
+			packets = append(packets, NewPacket(writeEntityTeleportPacketID,
+				VarInt(tnt.id), Double(tnt.x), Double(tnt.y), Double(tnt.z),
+				Angle(0), Angle(0), Boolean(false)))
+		}
+		for _, packet := range packets {
+			if err := packet.Pack(p.connection); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
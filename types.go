@@ -1,10 +1,12 @@
 package MinecraftLightServer
 
 import (
+	"bytes"
 	"errors"
 	"github.com/google/uuid"
 	"io"
 	"math"
+	"strconv"
 )
 
 // Minecraft packet field types
@@ -39,6 +41,17 @@ type (
 	Angle Byte
 	// UUID is an unsigned 128-bit integer.
 	UUID uuid.UUID
+	// Position is a block position packed in a 64-bit integer (x 26 bits, z 26 bits, y 12 bits).
+	Position struct {
+		X, Y, Z int
+	}
+	// Slot is an item stack of an inventory, empty slots aren't Present.
+	Slot struct {
+		Present bool        // the slot contains an item
+		ItemID  int32       // item registry ID
+		Count   int8        // number of items in the stack
+		NBT     NBTCompound // item data, nil if the item has none
+	}
 )
 
 // WriteTo encodes a Boolean.
@@ -361,11 +374,103 @@ func (u *UUID) ReadFrom(r io.Reader) (n int64, err error) {
 	return int64(nn), err
 }
 
+// WriteTo encodes a Position.
+func (p Position) WriteTo(w io.Writer) (n int64, err error) {
+	return Long(int64(p.X&0x3FFFFFF)<<38 | int64(p.Z&0x3FFFFFF)<<12 | int64(p.Y&0xFFF)).WriteTo(w)
+}
+
+// ReadFrom decodes a Position.
+func (p *Position) ReadFrom(r io.Reader) (n int64, err error) {
+	var v Long
+	if n, err = v.ReadFrom(r); err != nil {
+		return
+	}
+
+	// Arithmetic shifts keep the sign of each coordinate
+	p.X = int(v >> 38)
+	p.Y = int(v << 52 >> 52)
+	p.Z = int(v << 26 >> 38)
+	return
+}
+
+// WriteTo encodes a Slot.
+func (s Slot) WriteTo(w io.Writer) (n int64, err error) {
+	fields := []io.WriterTo{Boolean(s.Present)}
+	if s.Present {
+		fields = append(fields, VarInt(s.ItemID), Byte(s.Count))
+		if s.NBT != nil {
+			fields = append(fields, s.NBT)
+		} else {
+			fields = append(fields, Byte(nbtEnd))
+		}
+	}
+
+	for _, field := range fields {
+		nn, err := field.WriteTo(w)
+		n += nn
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// ReadFrom decodes a Slot.
+func (s *Slot) ReadFrom(r io.Reader) (n int64, err error) {
+	var present Boolean
+	var id VarInt
+	var count, tagType Byte
+	for _, field := range []io.ReaderFrom{&present, &id, &count, &tagType} {
+		nn, err := field.ReadFrom(r)
+		n += nn
+		if err != nil {
+			return n, err
+		}
+		if !present {
+			*s = Slot{}
+			return n, nil
+		}
+	}
+
+	*s = Slot{Present: true, ItemID: int32(id), Count: int8(count)}
+	if tagType != nbtEnd {
+		// The type of the root tag has already been read
+		counter := &countingReader{r: io.MultiReader(bytes.NewReader([]byte{byte(tagType)}), r)}
+		s.NBT, err = ReadNBT(counter)
+		n += counter.n - 1
+	}
+	return n, err
+}
+
+// IsEmpty checks if a slot has no items.
+func (s Slot) IsEmpty() bool {
+	return !s.Present || s.Count <= 0
+}
+
+// countingReader counts the bytes read from a reader.
+type countingReader struct {
+	r io.Reader // source of the bytes
+	n int64     // bytes read
+}
+
+// Read reads from the source reader and counts the bytes.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// String returns the coordinates of a Position separated by spaces.
+func (p Position) String() string {
+	return strconv.Itoa(p.X) + " " + strconv.Itoa(p.Y) + " " + strconv.Itoa(p.Z)
+}
+
+// offset returns the position moved by a direction.
+func (p Position) offset(dir Position) Position {
+	return Position{p.X + dir.X, p.Y + dir.Y, p.Z + dir.Z}
+}
+
 // coordinateToChunk convert an absolute double coordinate to a chunk coordinate.
 func coordinateToChunk(coordinate Double) VarInt {
-	coordinate /= 16
-	if coordinate < 0 {
-		coordinate -= 1
-	}
-	return VarInt(coordinate)
+	return VarInt(math.Floor(float64(coordinate) / 16))
 }
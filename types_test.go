@@ -0,0 +1,66 @@
+package MinecraftLightServer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSlotEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		slot Slot
+		size int // encoded bytes
+	}{
+		{"empty", Slot{}, 1},
+		{"without data", Slot{Present: true, ItemID: 1, Count: 64}, 4},
+		{"large item ID", Slot{Present: true, ItemID: 975, Count: 1}, 5},
+		{"with data", Slot{Present: true, ItemID: 600, Count: 1, NBT: NBTCompound{
+			"Damage": int32(12),
+			"Enchantments": NBTList{
+				NBTCompound{"id": "minecraft:efficiency", "lvl": int16(5)},
+			},
+		}}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			written, err := test.slot.WriteTo(&buffer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if written != int64(buffer.Len()) || (test.size > 0 && buffer.Len() != test.size) {
+				t.Fatalf("%d bytes written, the encoding has %d bytes", written, buffer.Len())
+			}
+
+			// The bytes after the slot must not be read
+			buffer.WriteByte(0xFF)
+			var decoded Slot
+			read, err := decoded.ReadFrom(&buffer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if read != written || buffer.Len() != 1 {
+				t.Errorf("%d bytes read, %d written, %d left", read, written, buffer.Len())
+			}
+			if !reflect.DeepEqual(decoded, test.slot) {
+				t.Errorf("decoded %v, want %v", decoded, test.slot)
+			}
+		})
+	}
+}
+
+func TestSlotReadErrors(t *testing.T) {
+	var buffer bytes.Buffer
+	if _, err := (Slot{Present: true, ItemID: 1, Count: 1, NBT: NBTCompound{"Damage": int32(1)}}).WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buffer.Bytes()
+	for size := 1; size < len(encoded); size++ {
+		var s Slot
+		if _, err := s.ReadFrom(bytes.NewReader(encoded[:size])); err == nil {
+			t.Errorf("a slot truncated to %d bytes has been decoded", size)
+		}
+	}
+}
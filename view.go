@@ -0,0 +1,267 @@
+package MinecraftLightServer
+
+import "sync"
+
+// Chunk streaming settings.
+const (
+	defaultViewDistance = 10 // view distance in chunks
+	maxChunksPerTick    = 8  // chunks sent to each player in a single tick
+)
+
+// playerChunks tracks the chunks that a player has loaded.
+type playerChunks struct {
+	world    *World            // world of the player
+	loaded   map[chunkPos]bool // chunks already sent to the client
+	queue    []chunkPos        // chunks waiting to be sent, nearest first
+	center   chunkPos          // chunk of the player when the queue has been built
+	distance int               // view distance used to build the queue
+	mutex    sync.Mutex        // chunks tracking mutex
+}
+
+// spiral returns the chunk offsets within distance from the center
+// in spiral order, starting from the center itself.
+func spiral(distance int) []chunkPos {
+	side := 2*distance + 1
+	offsets := make([]chunkPos, 0, side*side)
+
+	x, z, dx, dz := 0, 0, 0, -1
+	for i := 0; i < side*side; i++ {
+		offsets = append(offsets, chunkPos{x, z})
+
+		// Turn at the corners of the current ring
+		if x == z || (x < 0 && x == -z) || (x > 0 && x == 1-z) {
+			dx, dz = -dz, dx
+		}
+		x, z = x+dx, z+dz
+	}
+	return offsets
+}
+
+// chunkPosition returns the chunk coordinates of the player.
+func (p *Player) chunkPosition() chunkPos {
+	return chunkPos{int(coordinateToChunk(p.x)), int(coordinateToChunk(p.z))}
+}
+
+// updateChunks queues the chunks within viewDistance that the player
+// hasn't loaded yet and unloads the ones that left the range.
+func (p *Player) updateChunks(viewDistance int) error {
+	p.chunks.mutex.Lock()
+	defer p.chunks.mutex.Unlock()
+
+	if p.chunks.loaded == nil {
+		p.chunks.loaded = make(map[chunkPos]bool)
+	}
+	center := p.chunkPosition()
+	p.chunks.center = center
+	p.chunks.distance = viewDistance
+
+	// Queue missing chunks in spiral order
+	p.chunks.queue = p.chunks.queue[:0]
+	for _, offset := range spiral(viewDistance) {
+		pos := chunkPos{center.x + offset.x, center.z + offset.z}
+		if !p.chunks.loaded[pos] {
+			p.chunks.queue = append(p.chunks.queue, pos)
+		}
+	}
+
+	// Unload chunks out of range
+	for pos := range p.chunks.loaded {
+		if abs(pos.x-center.x) > viewDistance || abs(pos.z-center.z) > viewDistance {
+			delete(p.chunks.loaded, pos)
+			if err := p.writeUnloadChunk(pos.x, pos.z); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// sendQueuedChunks sends to the player at most max chunks of its queue.
+func (p *Player) sendQueuedChunks(max int) error {
+	p.chunks.mutex.Lock()
+	defer p.chunks.mutex.Unlock()
+
+	for sent := 0; sent < max && len(p.chunks.queue) > 0; sent++ {
+		pos := p.chunks.queue[0]
+		p.chunks.queue = p.chunks.queue[1:]
+
+		c := p.chunks.world.Chunk(pos.x, pos.z)
+		if err := p.writeUpdateLight(c); err != nil {
+			return err
+		}
+		if err := p.writeChunk(c); err != nil {
+			return err
+		}
+		if err := p.writeChunkItems(p.chunks.world, pos); err != nil {
+			return err
+		}
+		p.chunks.loaded[pos] = true
+	}
+	return nil
+}
+
+// writeWorldChanges sends the block and light changes of the chunks loaded by the player.
+func (p *Player) writeWorldChanges(w *World, changes worldChanges) error {
+	if p.World() != w {
+		return nil
+	}
+
+	// Explosions are sent before the blocks that they destroy
+	if err := p.writeExplosions(changes.explosions); err != nil {
+		return err
+	}
+
+	for pos, blocks := range changes.blocks {
+		if p.hasChunk(pos.x, pos.z) {
+			if err := p.writeBlockChanges(blocks); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Block entities are sent after their blocks
+	for pos, entities := range changes.entities {
+		if !p.hasChunk(pos.x, pos.z) {
+			continue
+		}
+		for _, entity := range entities {
+			if e := w.BlockEntity(entity.X, entity.Y, entity.Z); e != nil {
+				if err := p.writeBlockEntityData(entity, e); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := p.writeFallingBlocks(changes.falling); err != nil {
+		return err
+	}
+	if err := p.writePrimedTNT(changes.tnt); err != nil {
+		return err
+	}
+	if err := p.writeDroppedItems(changes.items); err != nil {
+		return err
+	}
+
+	if changes.time {
+		if err := p.writeTimeUpdate(w); err != nil {
+			return err
+		}
+	}
+	if changes.weather {
+		if err := p.writeWeather(w, false); err != nil {
+			return err
+		}
+	}
+	for _, pos := range changes.lightning {
+		if p.hasChunk(pos.X>>4, pos.Z>>4) {
+			if err := p.writeLightning(pos); err != nil {
+				return err
+			}
+		}
+	}
+	for _, rule := range changes.gameRules {
+		if err := p.writeGameRule(w, rule); err != nil {
+			return err
+		}
+	}
+	if changes.spawn {
+		if err := p.writeSpawnPosition(w); err != nil {
+			return err
+		}
+	}
+	if changes.difficulty {
+		if err := p.writeServerDifficulty(w); err != nil {
+			return err
+		}
+	}
+	if changes.border != 0 {
+		if err := p.writeWorldBorderActions(w.border, changes.border); err != nil {
+			return err
+		}
+	}
+
+	for pos := range changes.light {
+		if p.hasChunk(pos.x, pos.z) {
+			if err := p.writeUpdateLight(w.loadedChunk(pos.x, pos.z)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// World returns the world where the player is.
+func (p *Player) World() *World {
+	p.chunks.mutex.Lock()
+	defer p.chunks.mutex.Unlock()
+	return p.chunks.world
+}
+
+// SwitchWorld moves the player to a position of a world added to the server,
+// the client loads the new world and its chunks are sent again.
+func (p *Player) SwitchWorld(w *World, pos Position) error {
+	p.chunks.mutex.Lock()
+	changed := p.chunks.world != w
+	if changed {
+		// The client discards all its chunks when it changes world
+		p.chunks.world = w
+		p.chunks.loaded = nil
+		p.chunks.queue = nil
+	}
+	distance := p.chunks.distance
+	p.chunks.mutex.Unlock()
+
+	if changed {
+		if err := p.writeRespawn(w); err != nil {
+			return err
+		}
+		if err := p.writeWorldBorder(w.border); err != nil {
+			return err
+		}
+		if err := p.writeTimeUpdate(w); err != nil {
+			return err
+		}
+		if err := p.writeServerDifficulty(w); err != nil {
+			return err
+		}
+		if err := p.writeSpawnPosition(w); err != nil {
+			return err
+		}
+		// The new world of the client has clear weather
+		if err := p.writeWeather(w, true); err != nil {
+			return err
+		}
+		// Game rules sent by Join Game are different for each world
+		for _, rule := range []string{"reducedDebugInfo", "doImmediateRespawn"} {
+			if err := p.writeGameRule(w, rule); err != nil {
+				return err
+			}
+		}
+	}
+
+	p.x, p.y, p.z = Double(pos.X)+0.5, Double(pos.Y), Double(pos.Z)+0.5
+	if err := p.writePlayerPosition(p.x, p.y, p.z, p.yawAbs, p.pitchAbs, Byte(0x00), VarInt(p.int32FromUUID())); err != nil {
+		return err
+	}
+	if err := p.updateViewPosition(); err != nil {
+		return err
+	}
+	return p.updateChunks(distance)
+}
+
+// hasChunk checks if the player has loaded the chunk at the specified coordinates.
+func (p *Player) hasChunk(x, z int) bool {
+	p.chunks.mutex.Lock()
+	defer p.chunks.mutex.Unlock()
+	return p.chunks.loaded[chunkPos{x, z}]
+}
+
+// abs returns the absolute value of an int.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
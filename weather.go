@@ -0,0 +1,282 @@
+package MinecraftLightServer
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Weather is the weather of a world.
+type Weather int
+
+// Weather types.
+const (
+	WeatherClear   Weather = iota // no rain
+	WeatherRain                   // rain, or snow in cold biomes
+	WeatherThunder                // rain with lightning
+)
+
+// Weather settings in ticks, the same as vanilla.
+const (
+	defaultWeatherDuration = 6000   // duration of the weather set by the weather command
+	rainLevelStep          = 0.01   // change of the rain and thunder levels during a tick
+	rainingLevel           = 0.2    // rain level above which it's raining
+	lightningChance        = 100000 // a thundering chunk is struck once every lightningChance ticks
+)
+
+// Change Game State reasons used by the weather.
+const (
+	gameStateEndRaining   = 1
+	gameStateBeginRaining = 2
+	gameStateRainLevel    = 7
+	gameStateThunderLevel = 8
+)
+
+// lightningBoltEntityType is the entity type of lightning bolts.
+const lightningBoltEntityType = 41
+
+// weather is the weather state of a world.
+type weather struct {
+	raining, thundering      bool         // current weather, reached by the levels over time
+	clearTime                int          // ticks of clear weather forced by the weather command
+	rainTime, thunderTime    int          // ticks until raining and thundering toggle
+	rainLevel, thunderLevel  float32      // current intensity, from 0 to 1
+	oldRainLevel, oldThunder float32      // intensity during the previous tick
+	cycle                    bool         // the weather changes over time
+	mutex                    sync.RWMutex // weather mutex
+}
+
+// String returns the name of the weather.
+func (w Weather) String() string {
+	switch w {
+	case WeatherRain:
+		return "rain"
+	case WeatherThunder:
+		return "thunder"
+	}
+	return "clear"
+}
+
+// ParseWeather returns the weather with the specified name.
+func ParseWeather(name string) (Weather, error) {
+	for weather := WeatherClear; weather <= WeatherThunder; weather++ {
+		if weather.String() == name {
+			return weather, nil
+		}
+	}
+	return WeatherClear, errors.New("unknown weather: " + name)
+}
+
+// Weather returns the weather that the world is reaching.
+func (w *World) Weather() Weather {
+	w.weather.mutex.RLock()
+	defer w.weather.mutex.RUnlock()
+	switch {
+	case w.weather.thundering && w.weather.raining:
+		return WeatherThunder
+	case w.weather.raining:
+		return WeatherRain
+	}
+	return WeatherClear
+}
+
+// SetWeather changes the weather of the world for the specified duration,
+// after that the weather changes randomly again. Rain fades in and out over a few seconds.
+func (w *World) SetWeather(weather Weather, duration time.Duration) {
+	ticks := int(duration / tickRate)
+	if ticks <= 0 {
+		ticks = defaultWeatherDuration
+	}
+
+	w.weather.mutex.Lock()
+	defer w.weather.mutex.Unlock()
+	w.weather.raining = weather != WeatherClear
+	w.weather.thundering = weather == WeatherThunder
+	if weather == WeatherClear {
+		w.weather.clearTime, w.weather.rainTime, w.weather.thunderTime = ticks, 0, 0
+	} else {
+		w.weather.clearTime, w.weather.rainTime, w.weather.thunderTime = 0, ticks, ticks
+	}
+}
+
+// WeatherCycle checks if the weather changes over time.
+func (w *World) WeatherCycle() bool {
+	w.weather.mutex.RLock()
+	defer w.weather.mutex.RUnlock()
+	return w.weather.cycle
+}
+
+// SetWeatherCycle starts or stops the random changes of the weather,
+// it's the doWeatherCycle game rule.
+func (w *World) SetWeatherCycle(enabled bool) {
+	_ = w.rules.SetBool("doWeatherCycle", enabled)
+}
+
+// isRaining checks if the rain is strong enough to be seen by the clients.
+func (w *weather) isRaining() bool {
+	return w.rainLevel > rainingLevel
+}
+
+// hasWeather checks if the weather of the world can be seen, it can't in dimensions
+// without sky light or with a ceiling.
+func (w *World) hasWeather() bool {
+	return w.dimension.HasSkyLight && !w.dimension.HasCeiling
+}
+
+// tickWeather advances the weather of the world by a tick,
+// changing it randomly when its duration ends and fading the rain levels.
+func (w *World) tickWeather() {
+	if !w.hasWeather() {
+		return
+	}
+
+	w.weather.mutex.Lock()
+	state := &w.weather
+	if state.cycle {
+		if state.clearTime > 0 {
+			state.clearTime--
+			state.raining, state.thundering = false, false
+			state.rainTime, state.thunderTime = 0, 0
+		} else {
+			if state.thunderTime > 0 {
+				if state.thunderTime--; state.thunderTime == 0 {
+					state.thundering = !state.thundering
+				}
+			} else if state.thundering {
+				state.thunderTime = rand.Intn(12000) + 3600
+			} else {
+				state.thunderTime = rand.Intn(168000) + 12000
+			}
+
+			if state.rainTime > 0 {
+				if state.rainTime--; state.rainTime == 0 {
+					state.raining = !state.raining
+				}
+			} else if state.raining {
+				state.rainTime = rand.Intn(12000) + 12000
+			} else {
+				state.rainTime = rand.Intn(168000) + 12000
+			}
+		}
+	}
+
+	state.oldRainLevel, state.oldThunder = state.rainLevel, state.thunderLevel
+	state.rainLevel = fadeLevel(state.rainLevel, state.raining)
+	state.thunderLevel = fadeLevel(state.thunderLevel, state.thundering)
+	changed := state.rainLevel != state.oldRainLevel || state.thunderLevel != state.oldThunder
+	thundering := state.isRaining() && state.thunderLevel > 0.9
+	w.weather.mutex.Unlock()
+
+	if changed {
+		w.changes.mutex.Lock()
+		w.changes.weather = true
+		w.changes.mutex.Unlock()
+	}
+	if thundering {
+		w.strikeRandomLightning()
+	}
+}
+
+// fadeLevel moves a rain level towards 1 if active or towards 0.
+func fadeLevel(level float32, active bool) float32 {
+	if active {
+		level += rainLevelStep
+	} else {
+		level -= rainLevelStep
+	}
+	if level < 0 {
+		return 0
+	} else if level > 1 {
+		return 1
+	}
+	return level
+}
+
+// strikeRandomLightning strikes each loaded chunk with a small probability,
+// on the highest block of a random column of a rainy biome.
+func (w *World) strikeRandomLightning() {
+	w.mutex.RLock()
+	var struck []Position
+	for pos, chunk := range w.chunks {
+		if rand.Intn(lightningChance) != 0 {
+			continue
+		}
+		x, z := rand.Intn(16), rand.Intn(16)
+		y := chunk.Height(x, z)
+		// Lightning only strikes where it rains
+		if biome := biomeRegistry.Biome(chunk.Biome(x, y, z)); biome == nil || biome.Precipitation == "rain" {
+			struck = append(struck, Position{pos.x*16 + x, y, pos.z*16 + z})
+		}
+	}
+	w.mutex.RUnlock()
+
+	for _, pos := range struck {
+		w.StrikeLightning(pos)
+	}
+}
+
+// StrikeLightning spawns a lightning bolt at a block position, it's only visual.
+func (w *World) StrikeLightning(pos Position) {
+	w.changes.mutex.Lock()
+	defer w.changes.mutex.Unlock()
+	w.changes.lightning = append(w.changes.lightning, pos)
+}
+
+// writeWeather sends the changes of the rain state and levels of a world to the client.
+// joined is true when the player has just entered the world and its client has clear weather.
+func (p *Player) writeWeather(w *World, joined bool) error {
+	w.weather.mutex.RLock()
+	raining := w.weather.isRaining()
+	wasRaining := w.weather.oldRainLevel > rainingLevel
+	rainChanged := w.weather.rainLevel != w.weather.oldRainLevel
+	thunderChanged := w.weather.thunderLevel != w.weather.oldThunder
+	rainLevel, thunderLevel := w.weather.rainLevel, w.weather.thunderLevel
+	w.weather.mutex.RUnlock()
+
+	if joined {
+		if !raining {
+			return nil
+		}
+		wasRaining, rainChanged, thunderChanged = false, true, true
+	}
+
+	if raining != wasRaining {
+		reason := gameStateEndRaining
+		if raining {
+			reason = gameStateBeginRaining
+		}
+		if err := p.writeChangeGameState(reason, 0); err != nil {
+			return err
+		}
+	}
+	if rainChanged {
+		if err := p.writeChangeGameState(gameStateRainLevel, rainLevel); err != nil {
+			return err
+		}
+	}
+	if thunderChanged {
+		return p.writeChangeGameState(gameStateThunderLevel, thunderLevel)
+	}
+	return nil
+}
+
+// writeChangeGameState sends a change of the game state to the client.
+func (p *Player) writeChangeGameState(reason int, value float32) error {
+	return NewPacket(changeGameStatePacketID, UnsignedByte(reason), Float(value)).Pack(p.connection)
+}
+
+// writeLightning spawns a lightning bolt entity for the client at a block position,
+// the client removes it by itself at the end of its animation.
+func (p *Player) writeLightning(pos Position) error {
+	id := UUID(uuid.New())
+	return NewPacket(spawnEntityPacketID,
+		VarInt(entityIDFromUUID(id)), id, // entity id and uuid
+		VarInt(lightningBoltEntityType),                     // entity type
+		Double(pos.X)+0.5, Double(pos.Y), Double(pos.Z)+0.5, // position
+		Angle(0), Angle(0), Int(0), // pitch, yaw and data
+		Short(0), Short(0), Short(0), // velocity
+	).Pack(p.connection)
+}
@@ -0,0 +1,482 @@
+package MinecraftLightServer
+
+// WindowType is the type of a container window, it chooses how the client shows its slots.
+type WindowType int
+
+// Window types, with their protocol IDs.
+const (
+	WindowGeneric9x1 WindowType = 0  // chest with 1 row
+	WindowGeneric9x2 WindowType = 1  // chest with 2 rows
+	WindowGeneric9x3 WindowType = 2  // chest with 3 rows
+	WindowGeneric9x4 WindowType = 3  // chest with 4 rows
+	WindowGeneric9x5 WindowType = 4  // chest with 5 rows
+	WindowGeneric9x6 WindowType = 5  // chest with 6 rows
+	WindowAnvil      WindowType = 7  // 2 items and their result
+	WindowCrafting   WindowType = 11 // crafting table
+	WindowFurnace    WindowType = 13 // ingredient, fuel and result
+)
+
+// playerSlots are the slots of the main inventory and the hotbar shown below the containers.
+const playerSlots = offhandSlot - mainInventorySlot
+
+// windowLayout is the arrangement of the slots of a window type.
+type windowLayout struct {
+	size    int  // slots of the container
+	result  int  // slot where items can only be taken, -1 if there isn't one
+	inputs  int  // shift clicks in the player inventory move the items to the first inputs slots, or between the main inventory and the hotbar if it's 0
+	reverse bool // shift clicks in the container fill the player inventory from the last slot
+	grid    int  // size of the square crafting grid after the result slot, 0 if there isn't one
+}
+
+// windowLayouts are the layouts of the supported window types.
+var windowLayouts = map[WindowType]windowLayout{
+	WindowGeneric9x1: {9, -1, 9, true, 0},
+	WindowGeneric9x2: {18, -1, 18, true, 0},
+	WindowGeneric9x3: {27, -1, 27, true, 0},
+	WindowGeneric9x4: {36, -1, 36, true, 0},
+	WindowGeneric9x5: {45, -1, 45, true, 0},
+	WindowGeneric9x6: {54, -1, 54, true, 0},
+	WindowAnvil:      {3, 2, 2, false, 0},
+	WindowCrafting:   {10, 0, 0, false, maxRecipeSize},
+	WindowFurnace:    {3, 2, 0, false, 0},
+}
+
+// quickMoveTarget is a range of slots where a shift click moves the items of other slots.
+type quickMoveTarget struct {
+	from, to   int  // range of clicked slots
+	start, end int  // range of destination slots
+	reverse    bool // the destination slots are filled from the last one
+}
+
+// playerQuickMoveTargets are the shift click targets of the player window.
+var playerQuickMoveTargets = []quickMoveTarget{
+	{craftingResultSlot, craftingResultSlot, mainInventorySlot, offhandSlot, true},
+	{craftingGridSlot, mainInventorySlot - 1, mainInventorySlot, offhandSlot, false},
+	{mainInventorySlot, hotbarSlot - 1, hotbarSlot, offhandSlot, false},
+	{hotbarSlot, offhandSlot - 1, mainInventorySlot, hotbarSlot, false},
+	{offhandSlot, offhandSlot, mainInventorySlot, offhandSlot, false},
+}
+
+// targets returns the shift click targets of a container window,
+// the player inventory is shown after the slots of the container.
+func (l windowLayout) targets() []quickMoveTarget {
+	hotbar, end := l.size+hotbarSlot-mainInventorySlot, l.size+playerSlots
+	var targets []quickMoveTarget
+	if l.result >= 0 {
+		targets = append(targets, quickMoveTarget{l.result, l.result, l.size, end, true})
+	}
+	targets = append(targets, quickMoveTarget{0, l.size - 1, l.size, end, l.reverse})
+	if l.inputs > 0 {
+		return append(targets, quickMoveTarget{l.size, end - 1, 0, l.inputs, false})
+	}
+	return append(targets,
+		quickMoveTarget{l.size, hotbar - 1, hotbar, end, false},
+		quickMoveTarget{hotbar, end - 1, l.size, hotbar, false},
+	)
+}
+
+// craftingGrid is the crafting grid of a window, the item that it makes is in the result slot.
+type craftingGrid struct {
+	start   int                  // first slot of the grid
+	size    int                  // width and height of the grid
+	allowed func(r *Recipe) bool // checks if the player can use a recipe, nil if all are allowed
+}
+
+// windowView is the list of the slots shown by a window, it runs the clicks of a player.
+// The slots point to the items of the container and of the player inventory.
+type windowView struct {
+	slots   []*Slot           // slots of the window, numbered as the protocol
+	result  int               // slot where items can only be taken, -1 if there isn't one
+	targets []quickMoveTarget // where shift clicks move the items of each slot
+	grid    *craftingGrid     // crafting grid of the window, nil if there isn't one
+	inv     *Inventory        // inventory of the player, with the cursor and the drag state
+}
+
+// playerView returns the view of the player window. It doesn't lock the inventory.
+func (inv *Inventory) playerView() *windowView {
+	v := &windowView{
+		result:  craftingResultSlot,
+		targets: playerQuickMoveTargets,
+		grid:    &craftingGrid{start: craftingGridSlot, size: 2},
+		inv:     inv,
+	}
+	for i := range inv.slots {
+		v.slots = append(v.slots, &inv.slots[i])
+	}
+	return v
+}
+
+// containerView returns the view of a container window with the inventory of a player.
+// It doesn't lock the container nor the inventory.
+func (c *Container) containerView(inv *Inventory) *windowView {
+	layout := windowLayouts[c.windowType]
+	v := &windowView{result: layout.result, targets: layout.targets(), inv: inv}
+	if layout.grid > 0 {
+		v.grid = &craftingGrid{start: layout.result + 1, size: layout.grid}
+	}
+	for i := range c.slots {
+		v.slots = append(v.slots, &c.slots[i])
+	}
+	for i := mainInventorySlot; i < offhandSlot; i++ {
+		v.slots = append(v.slots, &inv.slots[i])
+	}
+	return v
+}
+
+// mergeInto adds items to a slot that contains the same item, it returns the items that don't fit.
+func mergeInto(slot *Slot, item Slot) Slot {
+	if !slot.stacksWith(item) {
+		return item
+	}
+	moved := slot.maxStack() - int(slot.Count)
+	if moved > int(item.Count) {
+		moved = int(item.Count)
+	}
+	if moved <= 0 {
+		return item
+	}
+	*slot = slot.withCount(int(slot.Count) + moved)
+	return item.withCount(int(item.Count) - moved)
+}
+
+// moveItems moves items into a range of slots, filling the stacks of the same item
+// before the empty slots, and returns the items that don't fit.
+func (v *windowView) moveItems(item Slot, start, end int, reverse bool) Slot {
+	order := make([]*Slot, 0, end-start)
+	for slot := start; slot < end; slot++ {
+		if reverse {
+			order = append(order, v.slots[end-1-(slot-start)])
+		} else {
+			order = append(order, v.slots[slot])
+		}
+	}
+
+	for _, slot := range order {
+		item = mergeInto(slot, item)
+	}
+	for _, slot := range order {
+		if item.IsEmpty() {
+			break
+		}
+		if slot.IsEmpty() {
+			*slot, item = item, Slot{}
+		}
+	}
+	return item
+}
+
+// click runs a Click Window action. It returns the item that the client should have sent
+// with the action, to check that the client agrees with the server, and the items thrown
+// out of the window. ok is false if the action isn't valid.
+func (v *windowView) click(slot, button, mode int, creative bool) (expected Slot, dropped []Slot, ok bool) {
+	inv := v.inv
+	valid := slot >= 0 && slot < len(v.slots)
+	if mode != clickDrag {
+		inv.drag.active = false
+	}
+
+	switch mode {
+	case clickPickup:
+		if button != 0 && button != 1 {
+			return Slot{}, nil, false
+		}
+		if slot == outsideSlot {
+			return Slot{}, v.dropCursor(button == 1), true
+		}
+		if !valid {
+			return Slot{}, nil, false
+		}
+		expected = *v.slots[slot]
+		v.pickup(slot, button == 1)
+
+	case clickQuickMove:
+		if !valid || (button != 0 && button != 1) {
+			return Slot{}, nil, false
+		}
+		before := *v.slots[slot]
+		if v.quickMove(slot) {
+			expected = before
+		}
+
+	case clickSwap:
+		if !valid || ((button < 0 || button >= hotbarSize) && button != offhandButton) {
+			return Slot{}, nil, false
+		}
+		target := &inv.slots[offhandSlot]
+		if button != offhandButton {
+			target = &inv.slots[hotbarSlot+button]
+		}
+		// Results can only be moved to an empty slot
+		if slot != v.result {
+			*v.slots[slot], *target = *target, *v.slots[slot]
+		} else if target.IsEmpty() && !v.slots[slot].IsEmpty() {
+			*target, *v.slots[slot] = *v.slots[slot], Slot{}
+			v.takeResult()
+		}
+
+	case clickClone:
+		if !valid {
+			return Slot{}, nil, false
+		}
+		if item := *v.slots[slot]; creative && inv.cursor.IsEmpty() && !item.IsEmpty() {
+			inv.cursor = item.withCount(item.maxStack())
+		}
+
+	case clickThrow:
+		if slot == outsideSlot {
+			return Slot{}, nil, true
+		}
+		if !valid || (button != 0 && button != 1) {
+			return Slot{}, nil, false
+		}
+		if item := *v.slots[slot]; inv.cursor.IsEmpty() && !item.IsEmpty() {
+			count := 1
+			if button == 1 || slot == v.result {
+				count = int(item.Count)
+			}
+			*v.slots[slot] = item.withCount(int(item.Count) - count)
+			dropped = append(dropped, item.withCount(count))
+			if slot == v.result {
+				v.takeResult()
+			}
+		}
+
+	case clickDrag:
+		if !valid && slot != outsideSlot {
+			return Slot{}, nil, false
+		}
+		v.dragItems(slot, button, creative)
+
+	case clickPickupAll:
+		if !valid || button != 0 {
+			return Slot{}, nil, false
+		}
+		if !inv.cursor.IsEmpty() && v.slots[slot].IsEmpty() {
+			v.pickupAll()
+		}
+
+	default:
+		return Slot{}, nil, false
+	}
+	v.updateResult()
+	return expected, dropped, true
+}
+
+// dropCursor throws the items held by the cursor, only one if single is true.
+func (v *windowView) dropCursor(single bool) []Slot {
+	inv := v.inv
+	if inv.cursor.IsEmpty() {
+		return nil
+	}
+	count := int(inv.cursor.Count)
+	if single {
+		count = 1
+	}
+	dropped := inv.cursor.withCount(count)
+	inv.cursor = inv.cursor.withCount(int(inv.cursor.Count) - count)
+	return []Slot{dropped}
+}
+
+// pickup swaps the items of the cursor and a slot or moves them from one to the other,
+// half of the items or only one are moved if half is true.
+func (v *windowView) pickup(slot int, half bool) {
+	inv := v.inv
+	item, cursor := *v.slots[slot], inv.cursor
+	switch {
+	case slot == v.result:
+		// Results can only be taken
+		if item.IsEmpty() {
+			return
+		}
+		if cursor.IsEmpty() {
+			inv.cursor, *v.slots[slot] = item, Slot{}
+			v.takeResult()
+		} else if cursor.stacksWith(item) && int(cursor.Count)+int(item.Count) <= cursor.maxStack() {
+			inv.cursor, *v.slots[slot] = cursor.withCount(int(cursor.Count)+int(item.Count)), Slot{}
+			v.takeResult()
+		}
+
+	case cursor.IsEmpty():
+		taken := int(item.Count)
+		if half {
+			taken = (taken + 1) / 2
+		}
+		inv.cursor = item.withCount(taken)
+		*v.slots[slot] = item.withCount(int(item.Count) - taken)
+
+	case item.IsEmpty() || cursor.stacksWith(item):
+		placed := int(cursor.Count)
+		if half {
+			placed = 1
+		}
+		if space := cursor.maxStack() - int(item.Count); placed > space {
+			placed = space
+		}
+		if placed > 0 {
+			*v.slots[slot] = cursor.withCount(int(item.Count) + placed)
+			inv.cursor = cursor.withCount(int(cursor.Count) - placed)
+		}
+
+	default:
+		*v.slots[slot], inv.cursor = cursor, item
+	}
+}
+
+// quickMove moves the items of a slot to the other part of the window,
+// it returns false if nothing has been moved.
+func (v *windowView) quickMove(slot int) bool {
+	item := *v.slots[slot]
+	if item.IsEmpty() {
+		return false
+	}
+	for _, target := range v.targets {
+		if slot < target.from || slot > target.to {
+			continue
+		}
+		if slot != v.result || v.grid == nil {
+			*v.slots[slot] = v.moveItems(item, target.start, target.end, target.reverse)
+			return !v.slots[slot].Equals(item)
+		}
+
+		// The same item is crafted again while there are ingredients and space for it
+		moved := false
+		for crafted := item; crafted.Equals(item) && v.canFit(crafted, target.start, target.end); crafted = *v.slots[slot] {
+			v.moveItems(crafted, target.start, target.end, target.reverse)
+			*v.slots[slot] = Slot{}
+			v.takeResult()
+			moved = true
+		}
+		return moved
+	}
+	return false
+}
+
+// canFit checks if all the items fit in a range of slots.
+func (v *windowView) canFit(item Slot, start, end int) bool {
+	space := 0
+	for _, slot := range v.slots[start:end] {
+		if slot.IsEmpty() {
+			space += item.maxStack()
+		} else if slot.stacksWith(item) {
+			space += slot.maxStack() - int(slot.Count)
+		}
+	}
+	return space >= int(item.Count)
+}
+
+// updateResult shows the item made by the items of the crafting grid in the result slot.
+func (v *windowView) updateResult() {
+	if v.grid == nil {
+		return
+	}
+	grid := make([]Slot, v.grid.size*v.grid.size)
+	for i := range grid {
+		grid[i] = *v.slots[v.grid.start+i]
+	}
+	*v.slots[v.result] = Slot{}
+	if recipe := findCraftingRecipe(grid, v.grid.size, v.grid.allowed); recipe != nil {
+		*v.slots[v.result] = recipe.Result
+	}
+}
+
+// takeResult uses an item of each slot of the crafting grid when its result is taken.
+func (v *windowView) takeResult() {
+	if v.grid == nil {
+		return
+	}
+	for i := 0; i < v.grid.size*v.grid.size; i++ {
+		slot := v.slots[v.grid.start+i]
+		*slot = slot.withCount(int(slot.Count) - 1)
+	}
+	v.updateResult()
+}
+
+// canDragInto checks if the items of the cursor can be spread into a slot.
+func (v *windowView) canDragInto(slot int) bool {
+	item := *v.slots[slot]
+	return slot != v.result &&
+		(item.IsEmpty() || (item.stacksWith(v.inv.cursor) && int(item.Count) <= item.maxStack()))
+}
+
+// dragItems runs a stage of the spreading of the items of the cursor: the drag starts,
+// slots are added while the mouse moves over them and the items are spread at the end.
+func (v *windowView) dragItems(slot, button int, creative bool) {
+	inv := v.inv
+	stage, kind := button&3, button>>2&3
+	switch stage {
+	case 0:
+		inv.drag.active = !inv.cursor.IsEmpty() && (kind == dragSplit || kind == dragOne || (kind == dragFill && creative))
+		inv.drag.kind, inv.drag.slots = kind, nil
+
+	case 1:
+		if !inv.drag.active || kind != inv.drag.kind || slot < 0 || !v.canDragInto(slot) {
+			return
+		}
+		for _, added := range inv.drag.slots {
+			if added == slot {
+				return
+			}
+		}
+		if kind == dragFill || int(inv.cursor.Count) > len(inv.drag.slots) {
+			inv.drag.slots = append(inv.drag.slots, slot)
+		}
+
+	case 2:
+		if !inv.drag.active || kind != inv.drag.kind {
+			inv.drag.active = false
+			return
+		}
+		inv.drag.active = false
+		slots := inv.drag.slots
+		if len(slots) == 1 {
+			// A drag on a single slot is a normal click
+			v.pickup(slots[0], kind == dragOne)
+			return
+		}
+
+		cursor := inv.cursor
+		remaining := int(cursor.Count)
+		for _, slot := range slots {
+			if !v.canDragInto(slot) || int(cursor.Count) < len(slots) {
+				continue
+			}
+			current := int(v.slots[slot].Count)
+			placed := 1
+			switch kind {
+			case dragSplit:
+				placed = int(cursor.Count) / len(slots)
+			case dragFill:
+				placed = cursor.maxStack()
+			}
+			count := current + placed
+			if count > cursor.maxStack() {
+				count = cursor.maxStack()
+			}
+			remaining -= count - current
+			*v.slots[slot] = cursor.withCount(count)
+		}
+		inv.cursor = cursor.withCount(remaining)
+	}
+}
+
+// pickupAll collects the items of the same type as the cursor, the full stacks are
+// taken only when the other ones aren't enough.
+func (v *windowView) pickupAll() {
+	inv := v.inv
+	for pass := 0; pass < 2; pass++ {
+		for slot, item := range v.slots {
+			if int(inv.cursor.Count) >= inv.cursor.maxStack() {
+				return
+			}
+			if slot == v.result || !item.stacksWith(inv.cursor) || (pass == 0 && int(item.Count) == item.maxStack()) {
+				continue
+			}
+			taken := inv.cursor.maxStack() - int(inv.cursor.Count)
+			if taken > int(item.Count) {
+				taken = int(item.Count)
+			}
+			*item = item.withCount(int(item.Count) - taken)
+			inv.cursor = inv.cursor.withCount(int(inv.cursor.Count) + taken)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package MinecraftLightServer
+
+import "testing"
+
+// testSlot returns a stack of items with the specified item ID.
+func testSlot(id int32, count int) Slot {
+	return Slot{Present: true, ItemID: id, Count: int8(count)}
+}
+
+func TestWindowClickSwap(t *testing.T) {
+	stone, cobblestone := testSlot(1, 10), testSlot(14, 5)
+	tests := []struct {
+		name   string
+		slot   int
+		button int
+		ok     bool
+		target int // slot of the player inventory that receives the stone, -1 if nothing moves
+	}{
+		{"first hotbar slot", mainInventorySlot, 0, true, hotbarSlot},
+		{"last hotbar slot", mainInventorySlot, hotbarSize - 1, true, hotbarSlot + hotbarSize - 1},
+		{"offhand", mainInventorySlot, offhandButton, true, offhandSlot},
+		{"button after the hotbar", mainInventorySlot, hotbarSize, false, -1},
+		{"negative button", mainInventorySlot, -1, false, -1},
+		{"byte button", mainInventorySlot, 255, false, -1},
+		{"slot outside the window", inventorySize, 0, false, -1},
+		{"negative slot", -1, offhandButton, false, -1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inv := &Inventory{}
+			inv.slots[mainInventorySlot] = stone
+			inv.slots[hotbarSlot] = cobblestone
+			inv.slots[offhandSlot] = cobblestone
+
+			_, dropped, ok := inv.playerView().click(test.slot, test.button, clickSwap, false)
+			if ok != test.ok || dropped != nil {
+				t.Fatalf("click returned ok = %v, dropped = %v", ok, dropped)
+			}
+			if test.target < 0 {
+				if !inv.slots[mainInventorySlot].Equals(stone) {
+					t.Fatal("the clicked slot has changed")
+				}
+				return
+			}
+			if !inv.slots[test.target].Equals(stone) {
+				t.Fatalf("slot %d contains %v", test.target, inv.slots[test.target])
+			}
+			if test.target != hotbarSlot+hotbarSize-1 && !inv.slots[mainInventorySlot].Equals(cobblestone) {
+				t.Fatalf("the clicked slot contains %v", inv.slots[mainInventorySlot])
+			}
+		})
+	}
+}
+
+func TestWindowClickModes(t *testing.T) {
+	stone := testSlot(1, 10)
+	tests := []struct {
+		name     string
+		slot     int
+		button   int
+		mode     int
+		creative bool
+		ok       bool
+		cursor   int // items held by the cursor after the click
+		left     int // items left in the clicked slot
+		dropped  int // items thrown out of the window
+	}{
+		{"left click", mainInventorySlot, 0, clickPickup, false, true, 10, 0, 0},
+		{"right click", mainInventorySlot, 1, clickPickup, false, true, 5, 5, 0},
+		{"pickup with another button", mainInventorySlot, 2, clickPickup, false, false, 0, 10, 0},
+		{"pickup outside the window", outsideSlot, 0, clickPickup, false, true, 0, 10, 0},
+		{"pickup after the last slot", inventorySize, 0, clickPickup, false, false, 0, 10, 0},
+		{"shift click", mainInventorySlot, 0, clickQuickMove, false, true, 0, 0, 0},
+		{"shift click with another button", mainInventorySlot, 5, clickQuickMove, false, false, 0, 10, 0},
+		{"middle click", mainInventorySlot, 2, clickClone, false, true, 0, 10, 0},
+		{"middle click in creative mode", mainInventorySlot, 2, clickClone, true, true, maxStackSize, 10, 0},
+		{"drop key", mainInventorySlot, 0, clickThrow, false, true, 0, 9, 1},
+		{"control drop key", mainInventorySlot, 1, clickThrow, false, true, 0, 0, 10},
+		{"drop key with another button", mainInventorySlot, 2, clickThrow, false, false, 0, 10, 0},
+		{"double click", mainInventorySlot, 0, clickPickupAll, false, true, 0, 10, 0},
+		{"double click with another button", mainInventorySlot, 1, clickPickupAll, false, false, 0, 10, 0},
+		{"unknown mode", mainInventorySlot, 0, clickPickupAll + 1, false, false, 0, 10, 0},
+		{"negative mode", mainInventorySlot, 0, -1, false, false, 0, 10, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inv := &Inventory{}
+			inv.slots[mainInventorySlot] = stone
+
+			_, dropped, ok := inv.playerView().click(test.slot, test.button, test.mode, test.creative)
+			if ok != test.ok {
+				t.Fatalf("click returned ok = %v", ok)
+			}
+			if int(inv.cursor.Count) != test.cursor {
+				t.Errorf("the cursor holds %d items, want %d", inv.cursor.Count, test.cursor)
+			}
+			if int(inv.slots[mainInventorySlot].Count) != test.left {
+				t.Errorf("the slot contains %d items, want %d", inv.slots[mainInventorySlot].Count, test.left)
+			}
+			count := 0
+			for _, item := range dropped {
+				count += int(item.Count)
+			}
+			if count != test.dropped {
+				t.Errorf("%d items were thrown, want %d", count, test.dropped)
+			}
+		})
+	}
+}
+
+func TestWindowPickupFullResult(t *testing.T) {
+	inv := &Inventory{}
+	inv.cursor = testSlot(1, maxStackSize)
+	inv.slots[craftingResultSlot] = testSlot(1, maxStackSize)
+
+	if _, _, ok := inv.playerView().click(craftingResultSlot, 0, clickPickup, false); !ok {
+		t.Fatal("click not accepted")
+	}
+	if int(inv.cursor.Count) != maxStackSize {
+		t.Errorf("the cursor holds %d items, want %d", inv.cursor.Count, maxStackSize)
+	}
+}